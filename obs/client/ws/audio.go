@@ -0,0 +1,314 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type GetVolumeResponse struct {
+	Name string `json:"name"`
+	// Volume is in dB if requested with useDecibel, otherwise a
+	// multiplier between 0 and 1
+	Volume float64 `json:"volume"`
+	Muted  bool    `json:"muted"`
+	responseBase
+
+	useDecibel bool
+}
+
+func (r *GetVolumeResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		InputVolumeMul float64 `json:"inputVolumeMul"`
+		InputVolumeDb  float64 `json:"inputVolumeDb"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Volume = aux.InputVolumeMul
+	if r.useDecibel == true {
+		r.Volume = aux.InputVolumeDb
+	}
+	return nil
+}
+
+type GetMuteResponse struct {
+	Name  string `json:"name"`
+	Muted bool   `json:"muted"`
+	responseBase
+}
+
+func (r *GetMuteResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		InputMuted bool `json:"inputMuted"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Muted = aux.InputMuted
+	return nil
+}
+
+type GetSyncOffsetResponse struct {
+	Name   string        `json:"name"`
+	Offset time.Duration `json:"offset"`
+	responseBase
+}
+
+func (r *GetSyncOffsetResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		InputAudioSyncOffset int64 `json:"inputAudioSyncOffset"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Offset = time.Duration(aux.InputAudioSyncOffset) * time.Millisecond
+	return nil
+}
+
+// GetSpecialSourcesResponse holds the names of the global audio
+// sources. Mic4 is only reported by obs-websocket 5.
+type GetSpecialSourcesResponse struct {
+	Desktop1 string `json:"desktop-1"`
+	Desktop2 string `json:"desktop-2"`
+	Mic1     string `json:"mic-1"`
+	Mic2     string `json:"mic-2"`
+	Mic3     string `json:"mic-3"`
+	Mic4     string `json:"mic-4"`
+	responseBase
+}
+
+func (r *GetSpecialSourcesResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		Desktop1 string `json:"desktop1"`
+		Desktop2 string `json:"desktop2"`
+		Mic1     string `json:"mic1"`
+		Mic2     string `json:"mic2"`
+		Mic3     string `json:"mic3"`
+		Mic4     string `json:"mic4"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Desktop1 = aux.Desktop1
+	r.Desktop2 = aux.Desktop2
+	r.Mic1 = aux.Mic1
+	r.Mic2 = aux.Mic2
+	r.Mic3 = aux.Mic3
+	r.Mic4 = aux.Mic4
+	return nil
+}
+
+func forgeGetVolume(source string, useDecibel bool) request {
+	type getVolume struct {
+		requestBase
+		Source     string `json:"source"`
+		UseDecibel bool   `json:"useDecibel"`
+	}
+	// the response holds the name, which obs-websocket 5 does not
+	// echo
+	return &getVolume{
+		requestBase: requestBase{
+			RequestType: "GetVolume",
+			rType:       &GetVolumeResponse{Name: source, useDecibel: useDecibel},
+			v5Type:      "GetInputVolume",
+			v5Data:      map[string]interface{}{"inputName": source},
+		},
+		Source:     source,
+		UseDecibel: useDecibel,
+	}
+}
+
+func forgeSetVolume(source string, volume float64, useDecibel bool) request {
+	type setVolume struct {
+		requestBase
+		Source     string  `json:"source"`
+		Volume     float64 `json:"volume"`
+		UseDecibel bool    `json:"useDecibel"`
+	}
+	v5Data := map[string]interface{}{
+		"inputName":      source,
+		"inputVolumeMul": volume,
+	}
+	if useDecibel == true {
+		delete(v5Data, "inputVolumeMul")
+		v5Data["inputVolumeDb"] = volume
+	}
+	return &setVolume{
+		requestBase: requestBase{
+			RequestType: "SetVolume",
+			rType:       &responseBase{},
+			v5Type:      "SetInputVolume",
+			v5Data:      v5Data,
+		},
+		Source:     source,
+		Volume:     volume,
+		UseDecibel: useDecibel,
+	}
+}
+
+// forgeSourceRequest forges a request whose only parameter is the
+// source name, named v5Name in obs-websocket 5 where sources are
+// inputs.
+func forgeSourceRequest(name, v5Name, source string, resp response) request {
+	type sourceRequest struct {
+		requestBase
+		Source string `json:"source"`
+	}
+	return &sourceRequest{
+		requestBase: requestBase{
+			RequestType: name,
+			rType:       resp,
+			v5Type:      v5Name,
+			v5Data:      map[string]interface{}{"inputName": source},
+		},
+		Source: source,
+	}
+}
+
+func forgeSetMute(source string, mute bool) request {
+	type setMute struct {
+		requestBase
+		Source string `json:"source"`
+		Mute   bool   `json:"mute"`
+	}
+	return &setMute{
+		requestBase: requestBase{
+			RequestType: "SetMute",
+			rType:       &responseBase{},
+			v5Type:      "SetInputMute",
+			v5Data:      map[string]interface{}{"inputName": source, "inputMuted": mute},
+		},
+		Source: source,
+		Mute:   mute,
+	}
+}
+
+func forgeSetSyncOffset(source string, offset time.Duration) request {
+	type setSyncOffset struct {
+		requestBase
+		Source string        `json:"source"`
+		Offset time.Duration `json:"offset"`
+	}
+	return &setSyncOffset{
+		requestBase: requestBase{
+			RequestType: "SetSyncOffset",
+			rType:       &responseBase{},
+			v5Type:      "SetInputAudioSyncOffset",
+			v5Data: map[string]interface{}{
+				"inputName":            source,
+				"inputAudioSyncOffset": int64(offset / time.Millisecond),
+			},
+		},
+		Source: source,
+		Offset: offset,
+	}
+}
+
+// GetVolume returns the volume of the source, in dB if useDecibel is
+// true, otherwise as a multiplier between 0 and 1, and its mute
+// state. With obs-websocket 5 the mute state is a second request.
+func (c *Client) GetVolume(source string, useDecibel bool) (*GetVolumeResponse, error) {
+	return c.GetVolumeCtx(context.Background(), source, useDecibel)
+}
+
+func (c *Client) GetVolumeCtx(ctx context.Context, source string, useDecibel bool) (*GetVolumeResponse, error) {
+	r := forgeGetVolume(source, useDecibel)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	resp := r.responseType().(*GetVolumeResponse)
+	if c.Protocol() == ProtocolV5 {
+		// the 5.x volume has no mute state
+		mute, err := c.GetMuteCtx(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		resp.Muted = mute.Muted
+	}
+	return resp, nil
+}
+
+// SetVolume changes the volume of the source, given in dB if
+// useDecibel is true, otherwise as a multiplier between 0 and 1.
+func (c *Client) SetVolume(source string, volume float64, useDecibel bool) error {
+	return c.SetVolumeCtx(context.Background(), source, volume, useDecibel)
+}
+
+func (c *Client) SetVolumeCtx(ctx context.Context, source string, volume float64, useDecibel bool) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetVolume(source, volume, useDecibel))
+	return err
+}
+
+// GetMute tells if the source is muted.
+func (c *Client) GetMute(source string) (*GetMuteResponse, error) {
+	return c.GetMuteCtx(context.Background(), source)
+}
+
+func (c *Client) GetMuteCtx(ctx context.Context, source string) (*GetMuteResponse, error) {
+	resp := &GetMuteResponse{Name: source}
+	if _, err := c.submitRequestCtx(ctx, forgeSourceRequest("GetMute", "GetInputMute", source, resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetMute mutes or unmutes the source.
+func (c *Client) SetMute(source string, mute bool) error {
+	return c.SetMuteCtx(context.Background(), source, mute)
+}
+
+func (c *Client) SetMuteCtx(ctx context.Context, source string, mute bool) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetMute(source, mute))
+	return err
+}
+
+// ToggleMute inverts the mute state of the source.
+func (c *Client) ToggleMute(source string) error {
+	return c.ToggleMuteCtx(context.Background(), source)
+}
+
+func (c *Client) ToggleMuteCtx(ctx context.Context, source string) error {
+	_, err := c.submitRequestCtx(ctx, forgeSourceRequest("ToggleMute", "ToggleInputMute", source, &responseBase{}))
+	return err
+}
+
+// SetSyncOffset changes the audio sync offset of the source. The
+// 5.x protocol has a millisecond precision.
+func (c *Client) SetSyncOffset(source string, offset time.Duration) error {
+	return c.SetSyncOffsetCtx(context.Background(), source, offset)
+}
+
+func (c *Client) SetSyncOffsetCtx(ctx context.Context, source string, offset time.Duration) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetSyncOffset(source, offset))
+	return err
+}
+
+// GetSyncOffset returns the audio sync offset of the source.
+func (c *Client) GetSyncOffset(source string) (*GetSyncOffsetResponse, error) {
+	return c.GetSyncOffsetCtx(context.Background(), source)
+}
+
+func (c *Client) GetSyncOffsetCtx(ctx context.Context, source string) (*GetSyncOffsetResponse, error) {
+	resp := &GetSyncOffsetResponse{Name: source}
+	if _, err := c.submitRequestCtx(ctx, forgeSourceRequest("GetSyncOffset", "GetInputAudioSyncOffset", source, resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetSpecialSources returns the names of the global audio sources,
+// like the desktop audio and the microphones.
+func (c *Client) GetSpecialSources() (*GetSpecialSourcesResponse, error) {
+	return c.GetSpecialSourcesCtx(context.Background())
+}
+
+func (c *Client) GetSpecialSourcesCtx(ctx context.Context) (*GetSpecialSourcesResponse, error) {
+	resp := &GetSpecialSourcesResponse{}
+	r := forgeRequestWithExpectedResponse("GetSpecialSources", resp)
+	r.setV5Request("GetSpecialInputs", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,177 @@
+package ws
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type AudioSuite struct{}
+
+var _ = Suite(&AudioSuite{})
+
+func (s *AudioSuite) TestAudioRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetVolume("Mic/Aux", true)
+			},
+			request:  `{"request-type":"GetVolume","source":"Mic/Aux","useDecibel":true}`,
+			response: `{"name":"Mic/Aux","volume":-6.5,"muted":false}`,
+			expected: &GetVolumeResponse{
+				Name:         "Mic/Aux",
+				Volume:       -6.5,
+				responseBase: recordedOK,
+				useDecibel:   true,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetVolume("Music", 0.25, false)
+			},
+			request:  `{"request-type":"SetVolume","source":"Music","volume":0.25,"useDecibel":false}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetMute("Mic/Aux")
+			},
+			request:  `{"request-type":"GetMute","source":"Mic/Aux"}`,
+			response: `{"name":"Mic/Aux","muted":true}`,
+			expected: &GetMuteResponse{
+				Name:         "Mic/Aux",
+				Muted:        true,
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetMute("Mic/Aux", false)
+			},
+			request:  `{"request-type":"SetMute","source":"Mic/Aux","mute":false}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.ToggleMute("Mic/Aux")
+			},
+			request:  `{"request-type":"ToggleMute","source":"Mic/Aux"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetSyncOffset("Cam", 150*time.Millisecond)
+			},
+			request:  `{"request-type":"SetSyncOffset","source":"Cam","offset":150000000}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetSyncOffset("Cam")
+			},
+			request:  `{"request-type":"GetSyncOffset","source":"Cam"}`,
+			response: `{"name":"Cam","offset":150000000}`,
+			expected: &GetSyncOffsetResponse{
+				Name:         "Cam",
+				Offset:       150 * time.Millisecond,
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetSpecialSources()
+			},
+			request:  `{"request-type":"GetSpecialSources"}`,
+			response: `{"desktop-1":"Desktop Audio","mic-1":"Mic/Aux"}`,
+			expected: &GetSpecialSourcesResponse{
+				Desktop1:     "Desktop Audio",
+				Mic1:         "Mic/Aux",
+				responseBase: recordedOK,
+			},
+		},
+	})
+}
+
+func (s *AudioSuite) TestAudioResponsesV5(c *C) {
+	volume := &GetVolumeResponse{useDecibel: true}
+	c.Assert(volume.unmarshalV5([]byte(`{"inputVolumeMul":0.5,"inputVolumeDb":-6.02}`)), IsNil)
+	c.Check(volume.Volume, Equals, -6.02)
+	volume = &GetVolumeResponse{}
+	c.Assert(volume.unmarshalV5([]byte(`{"inputVolumeMul":0.5,"inputVolumeDb":-6.02}`)), IsNil)
+	c.Check(volume.Volume, Equals, 0.5)
+
+	offset := &GetSyncOffsetResponse{}
+	c.Assert(offset.unmarshalV5([]byte(`{"inputAudioSyncOffset":-20}`)), IsNil)
+	c.Check(offset.Offset, Equals, -20*time.Millisecond)
+
+	special := &GetSpecialSourcesResponse{}
+	c.Assert(special.unmarshalV5([]byte(`{"desktop1":"Desktop Audio","desktop2":null,"mic1":"Mic/Aux","mic2":null,"mic3":null,"mic4":null}`)), IsNil)
+	c.Check(special, DeepEquals, &GetSpecialSourcesResponse{Desktop1: "Desktop Audio", Mic1: "Mic/Aux"})
+}
+
+func (s *AudioSuite) TestAudioRequestsV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+	c.Assert(client.SetMute("Mic", true), IsNil)
+
+	volume, err := client.GetVolume("Mic", false)
+	c.Assert(err, IsNil)
+	c.Check(volume.Name, Equals, "Mic")
+	c.Check(volume.Volume, Equals, 1.0)
+	c.Check(volume.Muted, Equals, true)
+	mute, err := client.GetMute("Desktop Audio")
+	c.Assert(err, IsNil)
+	c.Check(mute.Name, Equals, "Desktop Audio")
+	c.Check(mute.Muted, Equals, false)
+}
+
+func (s *AudioSuite) TestAudioEvents(c *C) {
+	v4 := map[string]Event{
+		`{"update-type":"SourceVolumeChanged","sourceName":"Music","volume":0.5,"volumeDb":-6.02}`: &EventSourceVolumeChanged{
+			SourceName: "Music",
+			Volume:     0.5,
+			VolumeDb:   -6.02,
+			rawEvent:   newRawEvent("SourceVolumeChanged"),
+		},
+		`{"update-type":"SourceAudioSyncOffsetChanged","sourceName":"Cam","syncOffset":150000000}`: &EventSourceAudioSyncOffsetChanged{
+			SourceName: "Cam",
+			SyncOffset: 150 * time.Millisecond,
+			rawEvent:   newRawEvent("SourceAudioSyncOffsetChanged"),
+		},
+	}
+	for data, expected := range v4 {
+		ev, err := UnmarshalEvent([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+
+	v5 := map[string]Event{
+		`{"eventType":"InputVolumeChanged","eventIntent":65536,"eventData":{"inputName":"Music","inputVolumeMul":0.5,"inputVolumeDb":-6.02}}`: &EventSourceVolumeChanged{
+			SourceName: "Music",
+			Volume:     0.5,
+			VolumeDb:   -6.02,
+			rawEvent:   newRawEvent("SourceVolumeChanged"),
+		},
+		`{"eventType":"InputMuteStateChanged","eventIntent":8,"eventData":{"inputName":"Mic/Aux","inputMuted":true}}`: &EventSourceMuteStateChanged{
+			SourceName: "Mic/Aux",
+			Muted:      true,
+			rawEvent:   newRawEvent("SourceMuteStateChanged"),
+		},
+		`{"eventType":"InputAudioSyncOffsetChanged","eventIntent":8,"eventData":{"inputName":"Cam","inputAudioSyncOffset":150}}`: &EventSourceAudioSyncOffsetChanged{
+			SourceName: "Cam",
+			SyncOffset: 150 * time.Millisecond,
+			rawEvent:   newRawEvent("SourceAudioSyncOffsetChanged"),
+		},
+	}
+	for data, expected := range v5 {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+}
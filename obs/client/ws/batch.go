@@ -0,0 +1,452 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// A Batch is a list of requests executed in order by
+// ExecuteBatch. The methods of Batch add a request to the list, like
+// the Client methods of the same name do. The zero Batch is empty and
+// ready to use. A Batch can be executed several times, but not
+// concurrently.
+type Batch struct {
+	// HaltOnFailure skips the requests following a failed one
+	HaltOnFailure bool
+
+	steps []batchStep
+}
+
+type batchStep struct {
+	request request
+	// sleep is set for the pauses added with Sleep
+	sleep time.Duration
+	// item is set for the steps addressing a scene item by name
+	item *batchItem
+	// transition is set for TransitionToProgram with an override
+	transition *TransitionOverride
+}
+
+// batchItem is a scene item changed by a step of a Batch.
+// obs-websocket 5 addresses the items by id, which ExecuteBatch looks
+// up before sending the batch.
+type batchItem struct {
+	sceneName string
+	name      string
+	// requestsV5 returns the 5.x requests of the step, for the item
+	// id in the scene
+	requestsV5 func(sceneName string, id int) []requestV5
+}
+
+func (b *Batch) add(r request) {
+	b.steps = append(b.steps, batchStep{request: r})
+}
+
+// Len returns the number of steps of the batch, including the
+// pauses.
+func (b *Batch) Len() int {
+	return len(b.steps)
+}
+
+// Sleep pauses the batch for d, with a millisecond precision.
+func (b *Batch) Sleep(d time.Duration) {
+	b.steps = append(b.steps, batchStep{request: forgeSleep(d), sleep: d})
+}
+
+func (b *Batch) SetCurrentScene(name string) {
+	b.add(forgeSetCurrentScene(name))
+}
+
+func (b *Batch) SetPreviewScene(name string) {
+	b.add(forgeSetPreviewScene(name))
+}
+
+// TransitionToProgram adds a transition of the preview scene to
+// program. With obs-websocket 5 an override changes the current
+// transition and its duration, which the batch restores after a pause
+// of the duration of the transition.
+func (b *Batch) TransitionToProgram(with *TransitionOverride) {
+	step := batchStep{request: forgeTransitionToProgram(with)}
+	if with != nil {
+		override := *with
+		step.transition = &override
+	}
+	b.steps = append(b.steps, step)
+}
+
+func (b *Batch) SetCurrentTransition(name string) {
+	b.add(forgeSetCurrentTransition(name))
+}
+
+func (b *Batch) SetTransitionDuration(duration int) {
+	b.add(forgeSetTransitionDuration(duration))
+}
+
+func (b *Batch) SetVolume(source string, volume float64, useDecibel bool) {
+	b.add(forgeSetVolume(source, volume, useDecibel))
+}
+
+func (b *Batch) SetMute(source string, mute bool) {
+	b.add(forgeSetMute(source, mute))
+}
+
+func (b *Batch) ToggleMute(source string) {
+	b.add(forgeSourceRequest("ToggleMute", "ToggleInputMute", source, &responseBase{}))
+}
+
+func (b *Batch) SetSceneItemRender(sceneName, source string, render bool) {
+	b.steps = append(b.steps, batchStep{
+		request: forgeSetSceneItemRender(sceneName, source, render),
+		item: &batchItem{
+			sceneName: sceneName,
+			name:      source,
+			requestsV5: func(sceneName string, id int) []requestV5 {
+				return []requestV5{setSceneItemEnabledV5(sceneName, id, render)}
+			},
+		},
+	})
+}
+
+func (b *Batch) SetSceneItemProperties(sceneName, item string, props SceneItemPropertiesUpdate) {
+	b.steps = append(b.steps, batchStep{
+		request: forgeSetSceneItemProperties(sceneName, item, props),
+		item: &batchItem{
+			sceneName:  sceneName,
+			name:       item,
+			requestsV5: props.requestsV5,
+		},
+	})
+}
+
+func (b *Batch) SetSourceFilterVisibility(sourceName, filterName string, enabled bool) {
+	b.add(forgeSetSourceFilterVisibility(sourceName, filterName, enabled))
+}
+
+func (b *Batch) SetSourceSettings(sourceName string, settings SourceSettings) {
+	b.add(forgeSetSourceSettings(sourceName, settings))
+}
+
+func (b *Batch) StartStreaming() {
+	b.add(forgeOutputRequest("StartStreaming", "StartStream"))
+}
+
+func (b *Batch) StopStreaming() {
+	b.add(forgeOutputRequest("StopStreaming", "StopStream"))
+}
+
+func (b *Batch) StartRecording() {
+	b.add(forgeOutputRequest("StartRecording", "StartRecord"))
+}
+
+func (b *Batch) StopRecording() {
+	b.add(forgeOutputRequest("StopRecording", "StopRecord"))
+}
+
+func (b *Batch) SaveReplayBuffer() {
+	b.add(forgeOutputRequest("SaveReplayBuffer", "SaveReplayBuffer"))
+}
+
+func forgeSleep(d time.Duration) request {
+	type sleep struct {
+		requestBase
+		SleepMillis int64 `json:"sleepMillis"`
+	}
+	ms := int64(d / time.Millisecond)
+	return &sleep{
+		requestBase: requestBase{
+			RequestType: "Sleep",
+			rType:       &responseBase{},
+			v5Type:      "Sleep",
+			v5Data:      map[string]interface{}{"sleepMillis": ms},
+		},
+		SleepMillis: ms,
+	}
+}
+
+// executeBatchRequest sends the steps of a Batch at once, with the
+// 4.x ExecuteBatch request or an obs-websocket 5 RequestBatch. The
+// steps are identified by their index.
+type executeBatchRequest struct {
+	requestBase
+	Requests    []request `json:"requests"`
+	AbortOnFail bool      `json:"abortOnFail"`
+	// stepsV5 holds the 5.x requests of the steps which are not sent
+	// as their own 5.x request, by index: the scene item steps once
+	// their ids are looked up, and the overridden transitions. A step
+	// may take several requests.
+	stepsV5 map[int][]requestV5
+}
+
+func forgeExecuteBatch(b *Batch) *executeBatchRequest {
+	r := &executeBatchRequest{
+		requestBase: requestBase{
+			RequestType: "ExecuteBatch",
+			rType:       &executeBatchResponse{},
+			v5Type:      "RequestBatch",
+		},
+		Requests:    make([]request, 0, len(b.steps)),
+		AbortOnFail: b.HaltOnFailure,
+	}
+	for i, step := range b.steps {
+		step.request.setMessageID(strconv.Itoa(i))
+		r.Requests = append(r.Requests, step.request)
+	}
+	return r
+}
+
+func (r *executeBatchRequest) v5Batch() ([]requestV5, bool, error) {
+	requests := make([]requestV5, 0, len(r.Requests))
+	for i, step := range r.Requests {
+		if stepRequests, ok := r.stepsV5[i]; ok == true {
+			for _, stepRequest := range stepRequests {
+				stepRequest.RequestID = strconv.Itoa(i)
+				requests = append(requests, stepRequest)
+			}
+			continue
+		}
+		requestType, data := step.v5Request()
+		if len(requestType) == 0 {
+			return nil, false, ErrUnsupportedRequest{RequestType: step.requestType(), Protocol: ProtocolV5}
+		}
+		requests = append(requests, requestV5{
+			RequestType: requestType,
+			RequestID:   strconv.Itoa(i),
+			RequestData: data,
+		})
+	}
+	return requests, r.AbortOnFail, nil
+}
+
+// requestCount returns the number of requests sent for the step i.
+func (r *executeBatchRequest) requestCount(i int) int {
+	if stepRequests, ok := r.stepsV5[i]; ok == true {
+		return len(stepRequests)
+	}
+	return 1
+}
+
+type executeBatchResponse struct {
+	// Results holds the status of the executed steps, whose
+	// message-id is their index
+	Results []responseBase `json:"results"`
+	responseBase
+}
+
+func (r *executeBatchResponse) unmarshalV5Batch(results []responseV5) error {
+	r.Results = make([]responseBase, 0, len(results))
+	for _, result := range results {
+		r.Results = append(r.Results, result.status())
+	}
+	return nil
+}
+
+// BatchResult is the outcome of a step of a Batch.
+type BatchResult struct {
+	RequestType string
+	// Err is nil if the request succeeded, and ErrBatchSkipped if
+	// it was not executed, or only partly for the steps sent as
+	// several obs-websocket 5 requests
+	Err error
+}
+
+// ErrBatchSkipped is the error of the steps of a Batch skipped after
+// a failure, with HaltOnFailure.
+type ErrBatchSkipped struct{}
+
+func (e ErrBatchSkipped) Error() string {
+	return "obsws: request skipped after a failure of the batch"
+}
+
+// ErrBatch is returned by ExecuteBatch when some steps of the Batch
+// failed, along with the results of every step.
+type ErrBatch struct {
+	// Failed counts the failed and the skipped steps
+	Failed int
+	Total  int
+	// First is the error of the first failed step
+	First error
+}
+
+func (e ErrBatch) Error() string {
+	return fmt.Sprintf("obsws: %d of %d batch requests failed, first: %s", e.Failed, e.Total, e.First)
+}
+
+// ExecuteBatch executes the requests of the batch in order, and
+// returns the result of each of them. The batch is sent at once with
+// obs-websocket 5 and 4.9+, which execute it without waiting between
+// the requests. With older versions the requests are sent one after
+// the other. Unless the batch could not be sent at all, the results
+// are returned even if the error is not nil.
+//
+// With obs-websocket 5 the ids of the scene items changed by the batch
+// are looked up first, and the batch is not sent if one of them is
+// not found. So is the current transition if TransitionToProgram
+// overrides it.
+func (c *Client) ExecuteBatch(b *Batch) ([]BatchResult, error) {
+	return c.ExecuteBatchCtx(context.Background(), b)
+}
+
+func (c *Client) ExecuteBatchCtx(ctx context.Context, b *Batch) ([]BatchResult, error) {
+	native, err := c.supportsBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []BatchResult
+	if native == true {
+		results, err = c.executeNativeBatch(ctx, b)
+	} else {
+		results, err = c.executeSequentialBatch(ctx, b)
+	}
+	if err != nil {
+		return results, err
+	}
+
+	errBatch := ErrBatch{Total: len(results)}
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if errBatch.Failed == 0 {
+			errBatch.First = result.Err
+		}
+		errBatch.Failed++
+	}
+	if errBatch.Failed > 0 {
+		return results, errBatch
+	}
+	return results, nil
+}
+
+// supportsBatch tells if the instance executes batches itself. The
+// answer is kept for the current connection.
+func (c *Client) supportsBatch(ctx context.Context) (bool, error) {
+	if c.Protocol() == ProtocolV5 {
+		return true, nil
+	}
+	_, _, generation := c.connection()
+	c.batchLock.Lock()
+	if c.batchGeneration == generation {
+		defer c.batchLock.Unlock()
+		return c.nativeBatch, nil
+	}
+	c.batchLock.Unlock()
+
+	version, err := c.GetVersionCtx(ctx)
+	if err != nil {
+		return false, err
+	}
+	c.batchLock.Lock()
+	defer c.batchLock.Unlock()
+	c.batchGeneration = generation
+	c.nativeBatch = version.HasRequest("ExecuteBatch")
+	return c.nativeBatch, nil
+}
+
+// resolveStepsV5 looks up the ids of the scene items of the batch and
+// the current transition if it is overridden, and sets the 5.x
+// requests of their steps.
+func (c *Client) resolveStepsV5(ctx context.Context, b *Batch, r *executeBatchRequest) error {
+	type itemKey struct {
+		sceneName string
+		name      string
+	}
+	type itemRef struct {
+		sceneName string
+		id        int
+	}
+	ids := make(map[itemKey]itemRef)
+	var current *GetCurrentTransitionResponse
+	r.stepsV5 = make(map[int][]requestV5)
+	for i, step := range b.steps {
+		if step.transition != nil {
+			if current == nil {
+				var err error
+				if current, err = c.GetCurrentTransitionCtx(ctx); err != nil {
+					return err
+				}
+			}
+			r.stepsV5[i] = step.transition.requestsV5(current)
+		}
+		if step.item == nil {
+			continue
+		}
+		key := itemKey{sceneName: step.item.sceneName, name: step.item.name}
+		ref, ok := ids[key]
+		if ok == false {
+			sceneName, id, err := c.sceneItemV5(ctx, key.sceneName, SceneItemRef{Name: key.name})
+			if err != nil {
+				return err
+			}
+			ref = itemRef{sceneName: sceneName, id: id}
+			ids[key] = ref
+		}
+		r.stepsV5[i] = step.item.requestsV5(ref.sceneName, ref.id)
+	}
+	return nil
+}
+
+func (c *Client) executeNativeBatch(ctx context.Context, b *Batch) ([]BatchResult, error) {
+	r := forgeExecuteBatch(b)
+	if c.Protocol() == ProtocolV5 {
+		if err := c.resolveStepsV5(ctx, b, r); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, 0, len(b.steps))
+	for _, step := range b.steps {
+		results = append(results, BatchResult{RequestType: step.request.requestType()})
+	}
+	executed := make([]int, len(results))
+	for _, status := range r.responseType().(*executeBatchResponse).Results {
+		i, err := strconv.Atoi(status.MessageID)
+		if err != nil || i < 0 || i >= len(results) {
+			return results, fmt.Errorf("obsws: unexpected batch result '%s'", status.MessageID)
+		}
+		executed[i]++
+		// a step sent as several requests keeps its first error
+		if results[i].Err == nil {
+			results[i].Err = status.error()
+		}
+	}
+	for i := range results {
+		// a step succeeds only if all its requests did
+		if results[i].Err == nil && executed[i] < r.requestCount(i) {
+			results[i].Err = ErrBatchSkipped{}
+		}
+	}
+	return results, nil
+}
+
+func (c *Client) executeSequentialBatch(ctx context.Context, b *Batch) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(b.steps))
+	failed := false
+	for _, step := range b.steps {
+		result := BatchResult{RequestType: step.request.requestType()}
+		switch {
+		case failed == true && b.HaltOnFailure == true:
+			result.Err = ErrBatchSkipped{}
+		case step.sleep > 0:
+			select {
+			case <-time.After(step.sleep):
+			case <-ctx.Done():
+				return results, ctx.Err()
+			}
+		default:
+			_, result.Err = c.submitRequestCtx(ctx, step.request)
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+		}
+		if result.Err != nil {
+			failed = true
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
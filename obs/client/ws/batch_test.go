@@ -0,0 +1,251 @@
+package ws
+
+import (
+	"encoding/json"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type BatchSuite struct{}
+
+var _ = Suite(&BatchSuite{})
+
+func brbBatch() *Batch {
+	b := &Batch{}
+	b.SetMute("Mic", true)
+	b.Sleep(20 * time.Millisecond)
+	b.SetCurrentScene("BRB")
+	return b
+}
+
+func (s *BatchSuite) TestNativeV4(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	server.FailNext("SetCurrentScene", "requested scene does not exist")
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	b := brbBatch()
+	b.HaltOnFailure = true
+	results, err := client.ExecuteBatch(b)
+	c.Check(err, ErrorMatches, "obsws: 1 of 3 batch requests failed, first: obsws: status:error error:requested scene does not exist")
+	c.Check(results, DeepEquals, []BatchResult{
+		{RequestType: "SetMute"},
+		{RequestType: "Sleep"},
+		{RequestType: "SetCurrentScene", Err: results[2].Err},
+	})
+	mic, _ := server.Input("Mic")
+	c.Check(mic.Muted, Equals, true)
+	c.Check(server.CurrentScene(), Equals, "Live")
+
+	// the support of ExecuteBatch is only asked once
+	_, err = client.ExecuteBatch(&Batch{})
+	c.Check(err, IsNil)
+
+	var batch map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(`{"abortOnFail":true,"requests":[
+		{"request-type":"SetMute","message-id":"0","source":"Mic","mute":true},
+		{"request-type":"Sleep","message-id":"1","sleepMillis":20},
+		{"request-type":"SetCurrentScene","message-id":"2","scene-name":"BRB"}
+	]}`), &batch), IsNil)
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetVersion", Fields: map[string]interface{}{}},
+		{Type: "ExecuteBatch", Fields: batch},
+		{Type: "ExecuteBatch", Fields: map[string]interface{}{"abortOnFail": false, "requests": []interface{}{}}},
+	})
+}
+
+func (s *BatchSuite) TestSequentialV4(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	// an obs-websocket 4.8, without ExecuteBatch nor SetCurrentScene
+	server.Handle("GetVersion", func(map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{
+			"version":               1.1,
+			"obs-websocket-version": "4.8.0",
+			"available-requests":    "GetVersion,SetMute",
+		}, nil
+	})
+	server.FailNext("SetCurrentScene", "invalid request type")
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	b := brbBatch()
+	b.SetMute("Cam", false)
+	b.HaltOnFailure = true
+	start := time.Now()
+	results, err := client.ExecuteBatch(b)
+	c.Check(time.Since(start) >= 20*time.Millisecond, Equals, true)
+	c.Check(err, FitsTypeOf, ErrBatch{})
+	c.Check(err.(ErrBatch).Failed, Equals, 2)
+	c.Check(results[0], DeepEquals, BatchResult{RequestType: "SetMute"})
+	c.Check(results[1], DeepEquals, BatchResult{RequestType: "Sleep"})
+	c.Check(results[2].Err, ErrorMatches, ".*invalid request type")
+	c.Check(results[3], DeepEquals, BatchResult{RequestType: "SetMute", Err: ErrBatchSkipped{}})
+
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetVersion", Fields: map[string]interface{}{}},
+		{Type: "SetMute", Fields: map[string]interface{}{"source": "Mic", "mute": true}},
+		{Type: "SetCurrentScene", Fields: map[string]interface{}{"scene-name": "BRB"}},
+	})
+}
+
+func (s *BatchSuite) TestV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+	events, cancel := client.Subscribe("SwitchScenes")
+	defer cancel()
+
+	b := &Batch{}
+	b.Sleep(10 * time.Millisecond)
+	b.SetCurrentScene("BRB")
+	b.SetCurrentScene("Nope")
+	results, err := client.ExecuteBatch(b)
+	c.Check(err, ErrorMatches, "obsws: 1 of 3 batch requests failed, .*code 600.*")
+	c.Check(results[0].Err, IsNil)
+	c.Check(results[1].Err, IsNil)
+	c.Check(results[2].Err, NotNil)
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "SwitchScenes")
+	c.Check(server.CurrentScene(), Equals, "BRB")
+}
+
+func (s *BatchSuite) TestSceneItemsV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	server.Handle("SetSceneItemTransform", func(map[string]interface{}) (map[string]interface{}, error) {
+		return nil, nil
+	})
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	hidden, rotation := false, 90.0
+	b := &Batch{HaltOnFailure: true}
+	b.SetMute("Mic", true)
+	b.SetSceneItemRender("", "Game", false)
+	b.SetSceneItemProperties("BRB", "BRB Screen", SceneItemPropertiesUpdate{Rotation: &rotation, Visible: &hidden})
+	b.SetSceneItemRender("", "Game", true)
+	results, err := client.ExecuteBatch(b)
+	c.Assert(err, IsNil)
+	c.Check(results, DeepEquals, []BatchResult{
+		{RequestType: "SetMute"},
+		{RequestType: "SetSceneItemRender"},
+		{RequestType: "SetSceneItemProperties"},
+		{RequestType: "SetSceneItemRender"},
+	})
+	for _, scene := range server.Scenes() {
+		for _, item := range scene.Items {
+			c.Check(item.Visible, Equals, item.Name != "BRB Screen", Commentf(item.Name))
+		}
+	}
+
+	request := func(id, requestType string, data map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"requestId": id, "requestType": requestType, "requestData": data}
+	}
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetCurrentProgramScene", Fields: nil},
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "Live", "sourceName": "Game"}},
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "BRB", "sourceName": "BRB Screen"}},
+		{Type: "RequestBatch", Fields: map[string]interface{}{
+			"haltOnFailure": true,
+			"requests": []interface{}{
+				request("0", "SetInputMute", map[string]interface{}{"inputName": "Mic", "inputMuted": true}),
+				request("1", "SetSceneItemEnabled", map[string]interface{}{"sceneName": "Live", "sceneItemId": 2.0, "sceneItemEnabled": false}),
+				request("2", "SetSceneItemTransform", map[string]interface{}{"sceneName": "BRB", "sceneItemId": 3.0, "sceneItemTransform": map[string]interface{}{"rotation": 90.0}}),
+				request("2", "SetSceneItemEnabled", map[string]interface{}{"sceneName": "BRB", "sceneItemId": 3.0, "sceneItemEnabled": false}),
+				request("3", "SetSceneItemEnabled", map[string]interface{}{"sceneName": "Live", "sceneItemId": 2.0, "sceneItemEnabled": true}),
+			},
+		}},
+	})
+
+	b = &Batch{}
+	b.SetSceneItemRender("Live", "Nope", false)
+	_, err = client.ExecuteBatch(b)
+	c.Check(err, ErrorMatches, ".*specified scene item doesn't exist.*")
+}
+
+func (s *BatchSuite) TestPartialStepV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	server.Handle("SetSceneItemTransform", func(map[string]interface{}) (map[string]interface{}, error) {
+		return nil, nil
+	})
+	server.FailNext("SetSceneItemEnabled", "nope")
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	hidden, rotation := false, 90.0
+	b := &Batch{HaltOnFailure: true}
+	b.SetSceneItemProperties("BRB", "BRB Screen", SceneItemPropertiesUpdate{Rotation: &rotation, Visible: &hidden})
+	b.SetCurrentScene("BRB")
+	results, err := client.ExecuteBatch(b)
+	c.Check(err, ErrorMatches, "obsws: 2 of 2 batch requests failed, .*nope.*")
+	c.Assert(results, HasLen, 2)
+	c.Check(results[0].Err, ErrorMatches, ".*nope.*")
+	c.Check(results[1].Err, FitsTypeOf, ErrBatchSkipped{})
+	c.Check(server.CurrentScene(), Equals, "Live")
+}
+
+func (s *BatchSuite) TestTransitionV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+	c.Assert(client.ToggleStudioMode(), IsNil)
+	c.Assert(client.SetPreviewScene("BRB"), IsNil)
+
+	b := &Batch{}
+	b.TransitionToProgram(&TransitionOverride{Name: "Cut", Duration: 50})
+	b.TransitionToProgram(&TransitionOverride{Name: "Fade"})
+	results, err := client.ExecuteBatch(b)
+	c.Assert(err, IsNil)
+	c.Check(results, DeepEquals, []BatchResult{
+		{RequestType: "TransitionToProgram"},
+		{RequestType: "TransitionToProgram"},
+	})
+	// the second transition brought back the scene swapped to preview
+	c.Check(server.CurrentScene(), Equals, "Live")
+	transition, duration := server.CurrentTransition()
+	c.Check(transition, Equals, "Fade")
+	c.Check(duration, Equals, 300*time.Millisecond)
+
+	request := func(id, requestType string, data map[string]interface{}) map[string]interface{} {
+		if data == nil {
+			return map[string]interface{}{"requestId": id, "requestType": requestType}
+		}
+		return map[string]interface{}{"requestId": id, "requestType": requestType, "requestData": data}
+	}
+	requests := server.Requests()
+	c.Check(requests[len(requests)-2:], DeepEquals, []wstest.Request{
+		{Type: "GetCurrentSceneTransition", Fields: nil},
+		{Type: "RequestBatch", Fields: map[string]interface{}{
+			"haltOnFailure": false,
+			"requests": []interface{}{
+				request("0", "SetCurrentSceneTransition", map[string]interface{}{"transitionName": "Cut"}),
+				request("0", "SetCurrentSceneTransitionDuration", map[string]interface{}{"transitionDuration": 50.0}),
+				request("0", "TriggerStudioModeTransition", nil),
+				request("0", "Sleep", map[string]interface{}{"sleepMillis": 150.0}),
+				request("0", "SetCurrentSceneTransition", map[string]interface{}{"transitionName": "Fade"}),
+				request("0", "SetCurrentSceneTransitionDuration", map[string]interface{}{"transitionDuration": 300.0}),
+				request("1", "TriggerStudioModeTransition", nil),
+			},
+		}},
+	})
+}
+
+func (s *BatchSuite) TestGetVersionV5(c *C) {
+	resp := &GetVersionResponse{}
+	c.Assert(resp.unmarshalV5([]byte(`{"obsVersion":"29.1.0","obsWebSocketVersion":"5.2.0","rpcVersion":1,"availableRequests":["GetVersion","Sleep"],"supportedImageFormats":["png","jpg"]}`)), IsNil)
+	c.Check(resp, DeepEquals, &GetVersionResponse{
+		OBSWebsocketVersion:         "5.2.0",
+		OBSStudioVersion:            "29.1.0",
+		AvailableRequests:           "GetVersion,Sleep",
+		SupportedImageExportFormats: "png,jpg",
+	})
+	c.Check(resp.HasRequest("Sleep"), Equals, true)
+	c.Check(resp.HasRequest("ExecuteBatch"), Equals, false)
+}
@@ -0,0 +1,276 @@
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// CaptureSent is the direction of the requests sent by the
+	// client.
+	CaptureSent = "sent"
+	// CaptureReceived is the direction of the frames received from
+	// the instance.
+	CaptureReceived = "received"
+)
+
+// CaptureRecord is a line of a capture file written by a Client
+// created with WithCapture.
+type CaptureRecord struct {
+	Time time.Time `json:"time"`
+	// Direction is CaptureSent or CaptureReceived
+	Direction string `json:"direction"`
+	// Protocol is the protocol of the connection, ProtocolV4 or
+	// ProtocolV5
+	Protocol Protocol `json:"protocol"`
+	// Frame is the frame as sent on the wire, or as a JSON string if
+	// it was not valid JSON
+	Frame json.RawMessage `json:"frame"`
+}
+
+// captureBufferSize is the number of records waiting to be written
+// before the frames are dropped from the capture.
+const captureBufferSize = 1024
+
+// redacted replaces the secrets of the captured frames.
+const redacted = "[redacted]"
+
+// captureSecrets are the fields redacted from the stream service
+// settings and the 5.x Hello authentication, by name of the object in
+// the 4.x and 5.x frames.
+var captureSecrets = map[string][]string{
+	"settings":              {"key", "password"},
+	"streamServiceSettings": {"key", "password"},
+	"authentication":        {"challenge", "salt"},
+}
+
+// captureAuthSecrets are the fields redacted from the 4.x frames, and
+// from the data of the 5.x frames: the authentication of 4.x
+// GetAuthRequired and Authenticate, and of 5.x Identify.
+var captureAuthSecrets = []string{"auth", "salt", "challenge", "authentication"}
+
+// captureMarkers are the fragments of the frames which may hold
+// secrets. The other frames are written without being decoded.
+var captureMarkers = [][]byte{[]byte(`ettings"`), []byte(`"auth`), []byte(`"salt"`), []byte(`"challenge"`)}
+
+// capture writes the frames of a Client as JSON lines from its own
+// goroutine, so a slow writer does not hold the connection. The
+// frames are dropped while the buffer is full, and the capture stops
+// at the first write error. Both are reported with report.
+type capture struct {
+	records chan CaptureRecord
+	done    chan struct{}
+	w       io.Writer
+	report  func(error)
+
+	// guarded by lock
+	lock     sync.Mutex
+	dropping bool
+}
+
+func newCapture(w io.Writer, report func(error)) *capture {
+	c := &capture{
+		records: make(chan CaptureRecord, captureBufferSize),
+		done:    make(chan struct{}),
+		w:       w,
+		report:  report,
+	}
+	go c.writeLoop()
+	return c
+}
+
+// record queues the frame to be written.
+func (c *capture) record(direction string, proto Protocol, frame []byte) {
+	record := CaptureRecord{
+		Time:      time.Now(),
+		Direction: direction,
+		Protocol:  proto,
+		Frame:     frame,
+	}
+	select {
+	case c.records <- record:
+		c.lock.Lock()
+		c.dropping = false
+		c.lock.Unlock()
+	default:
+		c.lock.Lock()
+		first := c.dropping == false
+		c.dropping = true
+		c.lock.Unlock()
+		if first == true {
+			c.report(fmt.Errorf("obsws: capture buffer full, dropping frames"))
+		}
+	}
+}
+
+func (c *capture) writeLoop() {
+	defer close(c.done)
+	enc := json.NewEncoder(c.w)
+	failed := false
+	for record := range c.records {
+		if failed == true {
+			continue
+		}
+		record.Frame = redactFrame(record.Frame)
+		if err := enc.Encode(record); err != nil {
+			failed = true
+			c.report(fmt.Errorf("obsws: capture stopped: %s", err))
+		}
+	}
+}
+
+// close writes the queued records. record must not be called anymore.
+func (c *capture) close() {
+	close(c.records)
+	<-c.done
+}
+
+// redactFrame returns the frame without the secrets of the stream
+// service settings and of the authentication, or as a JSON string if
+// it is not valid JSON.
+func redactFrame(frame []byte) json.RawMessage {
+	if json.Valid(frame) == false {
+		quoted, _ := json.Marshal(string(frame))
+		return quoted
+	}
+	marked := false
+	for _, marker := range captureMarkers {
+		if bytes.Contains(frame, marker) == true {
+			marked = true
+			break
+		}
+	}
+	if marked == false {
+		return frame
+	}
+	dec := json.NewDecoder(bytes.NewReader(frame))
+	// keep the numbers as sent
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return frame
+	}
+	found := redactAuth(v)
+	if obj, ok := v.(map[string]interface{}); ok == true && redactAuth(obj["d"]) == true {
+		found = true
+	}
+	if redactValue(v) == true {
+		found = true
+	}
+	if found == false {
+		return frame
+	}
+	redactedFrame, err := json.Marshal(v)
+	if err != nil {
+		return frame
+	}
+	return redactedFrame
+}
+
+// redactAuth redacts the authentication strings of the object v, and
+// tells if there were any.
+func redactAuth(v interface{}) bool {
+	obj, ok := v.(map[string]interface{})
+	if ok == false {
+		return false
+	}
+	found := false
+	for _, secret := range captureAuthSecrets {
+		if value, ok := obj[secret].(string); ok == true && len(value) > 0 {
+			obj[secret] = redacted
+			found = true
+		}
+	}
+	return found
+}
+
+// redactValue redacts the secrets in v, and tells if there were any.
+func redactValue(v interface{}) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if settings, ok := field.(map[string]interface{}); ok == true {
+				for _, secret := range captureSecrets[name] {
+					if value, ok := settings[secret].(string); ok == true && len(value) > 0 {
+						settings[secret] = redacted
+						found = true
+					}
+				}
+			}
+			if redactValue(field) == true {
+				found = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if redactValue(item) == true {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// captureFrame records the frame if the client was created with
+// WithCapture.
+func (c *Client) captureFrame(direction string, proto protocol, frame []byte) {
+	if c.capture == nil {
+		return
+	}
+	c.capture.record(direction, proto.version(), frame)
+}
+
+// ReadCapture reads the records of a capture file written by a Client
+// created with WithCapture.
+func ReadCapture(r io.Reader) ([]CaptureRecord, error) {
+	var records []CaptureRecord
+	scanner := bufio.NewScanner(r)
+	// frames such as screenshots are large
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record CaptureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return records, fmt.Errorf("obsws: invalid capture record at line %d: %s", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// ReplayEvents decodes the events received in records, as a Client
+// does: the events of unknown types come as EventUnknown, and the
+// frames which are not events are skipped. It stops at the first
+// malformed frame with an ErrMalformedFrame, and returns the events
+// decoded so far.
+func ReplayEvents(records []CaptureRecord) ([]Event, error) {
+	var events []Event
+	for _, record := range records {
+		if record.Direction != CaptureReceived {
+			continue
+		}
+		var proto protocol = protocolV4{}
+		if record.Protocol == ProtocolV5 {
+			proto = protocolV5{}
+		}
+		ev, err := proto.unmarshalEvent(record.Frame)
+		switch e := err.(type) {
+		case nil:
+			events = append(events, ev)
+		case ErrNotEventMessage:
+		case ErrUnknownEventType:
+			events = append(events, &EventUnknown{Data: e.Data, rawEvent: newRawEvent(e.Type)})
+		default:
+			return events, ErrMalformedFrame{Frame: record.Frame, Err: err}
+		}
+	}
+	return events, nil
+}
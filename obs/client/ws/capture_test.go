@@ -0,0 +1,182 @@
+package ws
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type CaptureSuite struct{}
+
+var _ = Suite(&CaptureSuite{})
+
+func (s *CaptureSuite) TestCapture(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := newServerClient(c, server, WithCapture(&buf))
+	events := client.EventChannel()
+
+	_, err := client.GetSceneList()
+	c.Assert(err, IsNil)
+	server.Emit("SwitchScenes", map[string]interface{}{"scene-name": "BRB", "sources": []interface{}{}})
+	nextEvent(c, events)
+	server.Emit("FutureEvent", map[string]interface{}{"answer": 42})
+	nextEvent(c, events)
+	client.Close()
+
+	records, err := ReadCapture(&buf)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 4)
+	c.Check(records[0].Direction, Equals, CaptureSent)
+	c.Check(records[0].Protocol, Equals, ProtocolV4)
+	c.Check(string(records[0].Frame), Equals, `{"message-id":"1","request-type":"GetSceneList"}`)
+	c.Check(records[1].Direction, Equals, CaptureReceived)
+	c.Check(records[3].Time.Before(records[0].Time), Equals, false)
+
+	replayed, err := ReplayEvents(records)
+	c.Assert(err, IsNil)
+	c.Assert(replayed, HasLen, 2)
+	c.Check(replayed[0].(*EventSwitchScenes).SceneName, Equals, "BRB")
+	c.Check(replayed[1].UpdateType(), Equals, "FutureEvent")
+}
+
+func (s *CaptureSuite) TestReplayEventsV5(c *C) {
+	capture := `{"time":"2024-03-01T20:00:00Z","direction":"sent","protocol":"5.x","frame":{"op":6,"d":{"requestType":"GetVersion","requestId":"1"}}}
+{"time":"2024-03-01T20:00:01Z","direction":"received","protocol":"5.x","frame":{"op":5,"d":{"eventType":"InputMuteStateChanged","eventIntent":8,"eventData":{"inputName":"Mic","inputMuted":true}}}}
+
+{"time":"2024-03-01T20:00:02Z","direction":"received","protocol":"5.x","frame":"not json"}
+`
+	records, err := ReadCapture(strings.NewReader(capture))
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+	c.Check(records[2].Time.Sub(records[0].Time), Equals, 2*time.Second)
+
+	replayed, err := ReplayEvents(records)
+	c.Check(err, FitsTypeOf, ErrMalformedFrame{})
+	c.Assert(replayed, HasLen, 1)
+	c.Check(replayed[0].(*EventSourceMuteStateChanged).SourceName, Equals, "Mic")
+
+	_, err = ReadCapture(strings.NewReader("{}\n{"))
+	c.Check(err, ErrorMatches, ".* line 2: .*")
+}
+
+func (s *CaptureSuite) TestRedactFrame(c *C) {
+	frames := [][2]string{
+		{
+			`not json`,
+			`"not json"`,
+		},
+		{
+			`{"message-id":"1","request-type":"GetSceneList"}`,
+			`{"message-id":"1","request-type":"GetSceneList"}`,
+		},
+		{
+			`{"message-id":"2","request-type":"SetStreamSettings","type":"rtmp_custom","settings":{"key":"live_123","use_auth":false}}`,
+			`{"message-id":"2","request-type":"SetStreamSettings","settings":{"key":"[redacted]","use_auth":false},"type":"rtmp_custom"}`,
+		},
+		{
+			`{"message-id":"3","status":"ok","settings":{"server":"rtmp://a","key":"","password":"hunter2","bitrate":6000}}`,
+			`{"message-id":"3","settings":{"bitrate":6000,"key":"","password":"[redacted]","server":"rtmp://a"},"status":"ok"}`,
+		},
+		{
+			`{"op":7,"d":{"requestType":"GetStreamServiceSettings","responseData":{"streamServiceSettings":{"key":"live_123"}}}}`,
+			`{"d":{"requestType":"GetStreamServiceSettings","responseData":{"streamServiceSettings":{"key":"[redacted]"}}},"op":7}`,
+		},
+		{
+			`{"op":9,"d":{"results":[{"responseData":{"streamServiceSettings":{"server":"rtmp://a"}}}]}}`,
+			`{"op":9,"d":{"results":[{"responseData":{"streamServiceSettings":{"server":"rtmp://a"}}}]}}`,
+		},
+		{
+			`{"message-id":"4","status":"ok","authRequired":true,"challenge":"c2FsdA==","salt":"Y2hhbA=="}`,
+			`{"authRequired":true,"challenge":"[redacted]","message-id":"4","salt":"[redacted]","status":"ok"}`,
+		},
+		{
+			`{"message-id":"5","request-type":"Authenticate","auth":"YXV0aA=="}`,
+			`{"auth":"[redacted]","message-id":"5","request-type":"Authenticate"}`,
+		},
+		{
+			`{"op":0,"d":{"obsWebSocketVersion":"5.2.0","rpcVersion":1,"authentication":{"challenge":"c2FsdA==","salt":"Y2hhbA=="}}}`,
+			`{"d":{"authentication":{"challenge":"[redacted]","salt":"[redacted]"},"obsWebSocketVersion":"5.2.0","rpcVersion":1},"op":0}`,
+		},
+		{
+			`{"op":1,"d":{"rpcVersion":1,"authentication":"YXV0aA==","eventSubscriptions":33}}`,
+			`{"d":{"authentication":"[redacted]","eventSubscriptions":33,"rpcVersion":1},"op":1}`,
+		},
+		{
+			`{"op":0,"d":{"obsWebSocketVersion":"5.2.0","rpcVersion":1}}`,
+			`{"op":0,"d":{"obsWebSocketVersion":"5.2.0","rpcVersion":1}}`,
+		},
+	}
+	for _, f := range frames {
+		c.Check(string(redactFrame([]byte(f[0]))), Equals, f[1])
+	}
+}
+
+type failingWriter struct{}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func (s *CaptureSuite) TestCaptureFailure(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+
+	client := newServerClient(c, server, WithCapture(failingWriter{}))
+	defer client.Close()
+
+	// the requests go on without the capture
+	for i := 0; i < 2; i++ {
+		_, err := client.GetSceneList()
+		c.Check(err, IsNil)
+	}
+	select {
+	case err := <-client.Errors():
+		c.Check(err, ErrorMatches, "obsws: capture stopped: disk full")
+	case <-time.After(time.Second):
+		c.Fatal("capture failure not reported")
+	}
+	select {
+	case err := <-client.Errors():
+		c.Errorf("unexpected error %s", err)
+	default:
+	}
+}
+
+// blockingWriter blocks the writes until unblock is closed.
+type blockingWriter struct {
+	unblock chan struct{}
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	return w.buf.Write(p)
+}
+
+func (s *CaptureSuite) TestCaptureSlowWriter(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+
+	w := &blockingWriter{unblock: make(chan struct{})}
+	client := newServerClient(c, server, WithCapture(w))
+
+	// the connection goes on while the capture is stuck
+	for i := 0; i < 3; i++ {
+		_, err := client.GetSceneList()
+		c.Check(err, IsNil)
+	}
+	close(w.unblock)
+	client.Close()
+
+	records, err := ReadCapture(&w.buf)
+	c.Assert(err, IsNil)
+	c.Check(records, HasLen, 6)
+}
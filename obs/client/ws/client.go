@@ -1,190 +1,524 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"sync"
 
 	"golang.org/x/net/websocket"
 )
 
-type responseData struct {
+// errorsBufferSize is the number of errors kept for the Errors
+// channel before they are only logged.
+const errorsBufferSize = 16
+
+type pendingRequest struct {
 	channel chan response
 	rType   response
+	// request is kept to send it again after a reconnection
+	request request
+	// generation is the connection the request was last sent on
+	generation int
 }
 
 // A Client connects to a obs-studio websocket to get event and
 // perform request on OBS instance remotely
+//
+// A Client runs one goroutine reading the frames of the current
+// connection, one goroutine writing the requests, and one goroutine
+// supervising the connection. Pending requests are kept in a table
+// shared by them and the callers, guarded by pendingLock.
 type Client struct {
-	eventChannelLock sync.RWMutex
-	wg               sync.WaitGroup
+	eventChannelLock sync.Mutex
+	connLock         sync.RWMutex
+	pendingLock      sync.Mutex
+	queueLock        sync.Mutex
+	// sceneSwitchLock serializes the scene and transition changes
+	sceneSwitchLock sync.Mutex
+	sourceTypesLock sync.Mutex
+	batchLock       sync.Mutex
+	closeOnce       sync.Once
+	wg              sync.WaitGroup
 
-	ws *websocket.Conn
+	address string
+	port    int
+	conf    config
 
-	events       chan Event
-	requests     chan request
-	frames       chan []byte
-	responsesMap map[string]responseData
-}
+	// guarded by connLock
+	ws         *websocket.Conn
+	proto      protocol
+	generation int
 
-// NewClient connects to a websocket instance.
-func NewClient(address string, port int) (*Client, error) {
+	// guarded by pendingLock
+	requestUID int
+	pending    map[string]*pendingRequest
+	// disconnected is set once the client gave up reconnecting or
+	// was closed, and while it reconnects with InFlightFail
+	disconnected error
 
-	ws, err := websocket.Dial(fmt.Sprintf("ws://%s:%d/", address, port),
-		"",
-		fmt.Sprintf("http://%s:%d/", address, port))
+	subscribers *subscriberSet
+
+	// guarded by eventChannelLock
+	eventChannel *subscriber
+
+	// events waiting to be delivered by deliverLoop, guarded by
+	// queueLock
+	queue  []Event
+	queued chan struct{}
+
+	// sourceTypes caches the type of the sources by name, guarded by
+	// sourceTypesLock
+	sourceTypes map[string]string
+
+	// nativeBatch tells if the instance of the connection
+	// batchGeneration supports ExecuteBatch, guarded by batchLock
+	batchGeneration int
+	nativeBatch     bool
+
+	// clipping holds a token while a Clip waits for its file
+	clipping chan struct{}
 
+	// capture records the frames if the client was created with
+	// WithCapture
+	capture *capture
+
+	// errors is closed by Close, errorsClosed is guarded by
+	// errorsLock
+	errorsLock   sync.Mutex
+	errorsClosed bool
+	errors       chan error
+	outgoing     chan string
+	closing      chan struct{}
+}
+
+// NewClient connects to a websocket instance. The protocol version
+// is negotiated with the server unless forced with WithProtocol. If a
+// password is given with WithPassword, the client authenticates
+// before returning.
+func NewClient(address string, port int, opts ...Option) (*Client, error) {
+	conf := defaultConfig()
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	ws, proto, early, err := connect(address, port, conf, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	res := &Client{
-		ws:           ws,
-		requests:     make(chan request),
-		responsesMap: make(map[string]responseData),
+		address:     address,
+		port:        port,
+		conf:        conf,
+		ws:          ws,
+		proto:       proto,
+		generation:  1,
+		pending:     make(map[string]*pendingRequest),
+		subscribers: newSubscriberSet(),
+		queued:      make(chan struct{}, 1),
+		sourceTypes: make(map[string]string),
+		clipping:    make(chan struct{}, 1),
+		errors:      make(chan error, errorsBufferSize),
+		outgoing:    make(chan string),
+		closing:     make(chan struct{}),
+	}
+	if conf.capture != nil {
+		res.capture = newCapture(conf.capture, res.reportError)
+	}
+	if early != nil {
+		res.receive(proto, early)
 	}
 
-	go res.internalLoop()
+	res.wg.Add(3)
+	go res.writeLoop()
+	go res.deliverLoop()
+	go res.supervise()
 	return res, nil
 }
 
-func (c *Client) handleResponse(frame []byte) {
-	//check if the message is an event
-	ev, err := UnmarshalEvent(frame)
+// NewClientWithPassword connects to a websocket instance and
+// authenticates with the given password before returning.
+func NewClientWithPassword(address string, port int, psswd string, opts ...Option) (*Client, error) {
+	return NewClient(address, port, append(opts, WithPassword(psswd))...)
+}
+
+// connect dials the instance and performs the protocol handshake. The
+// handshake fails after the handshake timeout, or as soon as abort is
+// closed. It returns the frame received during the handshake to
+// handle first, if any.
+func connect(address string, port int, conf config, abort <-chan struct{}) (*websocket.Conn, protocol, []byte, error) {
+	wsConf, err := websocket.NewConfig(fmt.Sprintf("ws://%s:%d/", address, port),
+		fmt.Sprintf("http://%s:%d/", address, port))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.handshakeTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-abort:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	netConn, err := (&net.Dialer{}).DialContext(ctx, "tcp", wsConf.Location.Host)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// a server which accepts but never answers would block the
+	// handshake forever
+	stop := context.AfterFunc(ctx, func() { netConn.Close() })
+
+	ws, err := websocket.NewClient(wsConf, netConn)
+	var proto protocol
+	var early []byte
 	if err == nil {
-		//check if use is listening events
-		c.eventChannelLock.RLock()
-		defer c.eventChannelLock.RUnlock()
-		if c.events != nil {
-			c.events <- ev
+		proto, early, err = negotiate(ws, conf)
+	}
+	if stop() == false && err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		netConn.Close()
+		if ctx.Err() != nil {
+			err = fmt.Errorf("obsws: handshake did not complete: %s", ctx.Err())
 		}
+		return nil, nil, nil, err
+	}
+	return ws, proto, early, nil
+}
+
+func (c *Client) connection() (*websocket.Conn, protocol, int) {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+	return c.ws, c.proto, c.generation
+}
+
+func (c *Client) setConnection(ws *websocket.Conn, proto protocol) {
+	c.connLock.Lock()
+	defer c.connLock.Unlock()
+	c.ws = ws
+	c.proto = proto
+	c.generation++
+}
+
+// ErrMalformedFrame is reported when a frame received from the
+// instance cannot be decoded.
+type ErrMalformedFrame struct {
+	Frame []byte
+	Err   error
+}
+
+func (e ErrMalformedFrame) Error() string {
+	return fmt.Sprintf("obsws: malformed frame '%s': %s", e.Frame, e.Err)
+}
+
+// ErrUnknownMessageID is reported when the instance answers to a
+// request we did not send, or which was already answered.
+type ErrUnknownMessageID struct {
+	MessageID string
+	Frame     []byte
+}
+
+func (e ErrUnknownMessageID) Error() string {
+	return fmt.Sprintf("obsws: unknown message-id '%s'", e.MessageID)
+}
+
+// ErrClosed is returned by requests sent to, or pending on, a closed
+// Client.
+type ErrClosed struct{}
+
+func (e ErrClosed) Error() string {
+	return "obsws: client closed"
+}
+
+func (c *Client) handleFrame(proto protocol, frame []byte) {
+	//check if the message is an event
+	ev, err := proto.unmarshalEvent(frame)
+	if err == nil {
+		c.dispatchEvent(ev)
 		return
 	}
 	if _, ok := err.(ErrNotEventMessage); ok == false {
 		//handle error
-		if _, ok := err.(ErrUnknownEventType); ok == true {
-			//we only log unknown eventype
-			log.Printf("%s", err)
+		if unknown, ok := err.(ErrUnknownEventType); ok == true {
+			//unknown events are forwarded untyped
+			c.dispatchEvent(&EventUnknown{
+				Data:     unknown.Data,
+				rawEvent: newRawEvent(unknown.Type),
+			})
 			return
 		} else {
-			panic(fmt.Sprintf("obsws: %s", err))
+			c.reportError(ErrMalformedFrame{Frame: frame, Err: err})
+			return
 		}
 	}
 
 	// handle response
-	var respBase responseBase
-	err = json.Unmarshal(frame, &respBase)
+	messageID, err := proto.messageID(frame)
 	if err != nil {
-		panic(fmt.Sprintf("obsws: %s\n'%s'", err, frame))
+		c.reportError(ErrMalformedFrame{Frame: frame, Err: err})
+		return
 	}
 
-	respData, ok := c.responsesMap[respBase.messageID()]
+	p, ok := c.removeRequest(messageID)
 	if ok == false {
-		panic(fmt.Sprintf("obsws: unknown message-id '%s'\n'%s'", respBase.messageID(), frame))
+		c.reportError(ErrUnknownMessageID{MessageID: messageID, Frame: frame})
+		return
 	}
-	err = json.Unmarshal(frame, &(respData.rType))
+
+	err = proto.unmarshalResponse(frame, p.rType)
 	if err != nil {
-		panic(fmt.Sprintf("obsws: %s\n'%s'", err, frame))
+		err = ErrMalformedFrame{Frame: frame, Err: err}
+		c.reportError(err)
+		// unblock the caller with the error
+		p.channel <- errorResponse{err}
+		return
+	}
+	p.channel <- p.rType
+}
+
+// reportError sends err to the Errors channel, or logs it if the
+// channel is full.
+func (c *Client) reportError(err error) {
+	c.errorsLock.Lock()
+	defer c.errorsLock.Unlock()
+	if c.errorsClosed == true {
+		// the client is closed, nobody is listening anymore
+		return
+	}
+	select {
+	case c.errors <- err:
+	default:
+		log.Printf("%s", err)
 	}
-	respData.channel <- respData.rType
-	delete(c.responsesMap, respBase.messageID())
-	close(respData.channel)
 }
 
-func (c *Client) internalLoop() {
-	c.wg.Add(1)
+// addRequest registers r as waiting for a response and returns its
+// message-id.
+func (c *Client) addRequest(r request) (string, error) {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	if c.disconnected != nil {
+		return "", c.disconnected
+	}
+	c.requestUID++
+	messageID := fmt.Sprintf("%d", c.requestUID)
+	c.pending[messageID] = &pendingRequest{
+		channel: r.getResponseChannel(),
+		rType:   r.responseType(),
+		request: r,
+	}
+	return messageID, nil
+}
+
+func (c *Client) removeRequest(messageID string) (*pendingRequest, bool) {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	p, ok := c.pending[messageID]
+	delete(c.pending, messageID)
+	return p, ok
+}
+
+// failPendingRequests answers every request waiting for a response
+// with err. If final is true, later requests fail with err too.
+func (c *Client) failPendingRequests(err error, final bool) {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	if final == true && c.disconnected == nil {
+		c.disconnected = err
+	}
+	for messageID, p := range c.pending {
+		p.channel <- errorResponse{err}
+		delete(c.pending, messageID)
+	}
+}
+
+// acceptRequests accepts the requests again once reconnected.
+func (c *Client) acceptRequests() {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	c.disconnected = nil
+}
+
+// pendingMessageIDs returns the message-ids of the requests waiting
+// for a response.
+func (c *Client) pendingMessageIDs() []string {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	res := make([]string, 0, len(c.pending))
+	for messageID := range c.pending {
+		res = append(res, messageID)
+	}
+	return res
+}
+
+// writeLoop sends the requests to the current connection.
+func (c *Client) writeLoop() {
 	defer c.wg.Done()
+	for {
+		select {
+		case messageID := <-c.outgoing:
+			c.write(messageID)
+		case <-c.closing:
+			return
+		}
+	}
+}
+
+// write sends the pending request messageID, unless it was cancelled
+// or already sent on the current connection.
+func (c *Client) write(messageID string) {
+	ws, proto, generation := c.connection()
+
+	c.pendingLock.Lock()
+	p, ok := c.pending[messageID]
+	if ok == false || p.generation == generation {
+		c.pendingLock.Unlock()
+		return
+	}
+	p.generation = generation
+	msg, err := proto.marshalRequest(p.request, messageID)
+	var data []byte
+	if err == nil {
+		data, err = json.Marshal(msg)
+	}
+	if err != nil {
+		delete(c.pending, messageID)
+		c.pendingLock.Unlock()
+		p.channel <- errorResponse{err}
+		return
+	}
+	c.pendingLock.Unlock()
+
+	c.captureFrame(CaptureSent, proto, data)
+	if err := websocket.Message.Send(ws, string(data)); err != nil {
+		// the reader will notice the connection dropped
+		ws.Close()
+	}
+}
 
-	c.frames = make(chan []byte)
+// readLoop reads and handles the frames of one connection until it
+// drops.
+func (c *Client) readLoop(ws *websocket.Conn, proto protocol, dropped chan<- error) {
+	defer c.wg.Done()
+	for {
+		var frame []byte
+		if err := websocket.Message.Receive(ws, &frame); err != nil {
+			dropped <- err
+			return
+		}
+		c.receive(proto, frame)
+	}
+}
 
-	requestUID := 0
+// receive captures and handles a frame received from the instance.
+func (c *Client) receive(proto protocol, frame []byte) {
+	c.captureFrame(CaptureReceived, proto, frame)
+	c.handleFrame(proto, frame)
+}
 
+// supervise watches the connection, and reconnects when it drops.
+func (c *Client) supervise() {
+	defer c.wg.Done()
 	for {
+		ws, proto, _ := c.connection()
+		dropped := make(chan error, 1)
 		c.wg.Add(1)
-		defer c.wg.Done()
-		go func() {
-			// read a response asynchronously
-			frame := make([]byte, 0, 100)
-			websocket.Message.Receive(c.ws, &frame)
-			// send response to channel
-			c.frames <- frame
-		}()
+		go c.readLoop(ws, proto, dropped)
 
+		var err error
 		select {
-		case f := <-c.frames:
-			c.handleResponse(f)
-		case r, ok := <-c.requests:
-			if ok == false {
-				c.requests = nil
-				break
-			}
-			// send the right request, with an UID
-			requestUID++
-			rUID := fmt.Sprintf("%d", requestUID)
-			c.responsesMap[rUID] = responseData{
-				channel: r.getResponseChannel(),
-				rType:   r.responseType(),
-			}
-			r.setMessageID(rUID)
-			websocket.JSON.Send(c.ws, r)
+		case err = <-dropped:
+		case <-c.closing:
+			ws.Close()
+			return
 		}
 
-		// we are closing the for loop
-		if c.requests == nil {
-			break
+		ws.Close()
+		c.dispatchEvent(&EventDisconnected{Err: err, rawEvent: newRawEvent("Disconnected")})
+		if c.conf.inFlightPolicy == InFlightFail {
+			// the requests made until reconnected fail too,
+			// instead of being sent again on the new connection
+			c.failPendingRequests(ErrDisconnected{err}, true)
+		}
+		if c.reconnect() == false {
+			c.failPendingRequests(ErrDisconnected{err}, true)
+			return
+		}
+		if c.conf.inFlightPolicy == InFlightFail {
+			c.acceptRequests()
+		}
+		// send the requests still waiting for a response on the
+		// new connection
+		for _, messageID := range c.pendingMessageIDs() {
+			select {
+			case c.outgoing <- messageID:
+			case <-c.closing:
+			}
 		}
-
 	}
-
-	//will discard the next response, either error or anything...
-	c.frames = nil
 }
 
-// Authentify performs the authenfication to this websocket instance.
+// Authentify performs the authenfication to this websocket
+// instance. It does nothing if the instance does not require
+// authentication. With the 5.x protocol, authentication happens
+// while connecting (see WithPassword), so it does nothing either.
 func (c *Client) Authentify(psswd string) error {
-	return NotYetImplemented()
+	return c.AuthentifyCtx(context.Background(), psswd)
 }
 
-// Close terminates the connection to the instance
-func (c *Client) Close() {
-	close(c.requests)
-	// wait to be done
-	c.wg.Wait()
-
-	c.eventChannelLock.Lock()
-	defer c.eventChannelLock.Unlock()
-	if c.events != nil {
-		close(c.events)
+func (c *Client) AuthentifyCtx(ctx context.Context, psswd string) error {
+	if c.Protocol() == ProtocolV5 {
+		return nil
+	}
+	authReq, err := c.GetAuthRequiredCtx(ctx)
+	if err != nil {
+		return err
 	}
+	if authReq.AuthRequired == false {
+		return nil
+	}
+	_, err = c.submitRequestCtx(ctx, forgeAuthenticate(authResponse(psswd, authReq.Salt, authReq.Challenge)))
+	return err
 }
 
-// EventChannel returns a channel to read Event from
-func (c *Client) EventChannel() <-chan Event {
-	c.eventChannelLock.RLock()
-	if c.events != nil {
-		defer c.eventChannelLock.RUnlock()
-		return c.events
-	}
+// Protocol returns the obs-websocket protocol version spoken with the
+// instance.
+func (c *Client) Protocol() Protocol {
+	_, proto, _ := c.connection()
+	return proto.version()
+}
 
-	res := make(chan chan Event)
-	go func() {
-		defer close(res)
-		c.eventChannelLock.Lock()
-		defer c.eventChannelLock.Unlock()
-		if c.events != nil {
-			res <- c.events
+// Close terminates the connection to the instance. Pending and later
+// requests fail with ErrClosed.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closing)
+		// wait to be done
+		c.wg.Wait()
+
+		c.failPendingRequests(ErrClosed{}, true)
+		if c.capture != nil {
+			c.capture.close()
 		}
-		c.events = make(chan Event)
-		res <- c.events
-	}()
-	c.eventChannelLock.RUnlock()
-	events := <-res
-	return events
+		c.errorsLock.Lock()
+		c.errorsClosed = true
+		close(c.errors)
+		c.errorsLock.Unlock()
+		c.subscribers.close()
+	})
 }
 
-func (c *Client) eventSender() chan<- Event {
-	c.eventChannelLock.RLock()
-	defer c.eventChannelLock.RUnlock()
-	return c.events
+// Errors returns a channel reporting the frames received from the
+// instance that could not be handled, as ErrMalformedFrame or
+// ErrUnknownMessageID, the events dropped as ErrEventDropped, and the
+// failures of a State to reload. Errors
+// are only logged while the channel is full.
+func (c *Client) Errors() <-chan error {
+	return c.errors
 }
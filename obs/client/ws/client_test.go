@@ -0,0 +1,604 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+// newServerClient returns a Client connected to the wstest server,
+// with its protocol.
+func newServerClient(c *C, server *wstest.Server, opts ...Option) *Client {
+	host, port := server.Address()
+	p := ProtocolV4
+	if server.Protocol() == wstest.ProtocolV5 {
+		p = ProtocolV5
+	}
+	client, err := NewClient(host, port, append(opts, WithProtocol(p))...)
+	c.Assert(err, IsNil)
+	return client
+}
+
+// serverDelay is the delay of the responses injected with
+// wstest.Server.DelayNext.
+const serverDelay = 100 * time.Millisecond
+
+type ClientSuite struct{}
+
+var _ = Suite(&ClientSuite{})
+
+func (s *ClientSuite) TestAuthResponse(c *C) {
+	c.Check(authResponse("supersecretpassword", "PZVbYpvAnZut2SS6JNJytDm9", "ztTBnnuqrqaKDzRM3xcVdbYm"),
+		Equals, "zZgWipvwSGrw748kHN4gNpBC1IaeiiWX3Hjkrm849Sc=")
+}
+
+func (s *ClientSuite) TestAuthentify(c *C) {
+	server := wstest.NewServer(wstest.WithPassword("supersecretpassword"))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	authReq, err := client.GetAuthRequired()
+	c.Assert(err, IsNil)
+	c.Check(authReq.AuthRequired, Equals, true)
+	c.Check(authReq.Salt, Not(Equals), "")
+	c.Check(authReq.Challenge, Not(Equals), "")
+
+	_, err = client.GetSceneList()
+	c.Check(err, ErrorMatches, "obsws: status:error error:Not Authenticated")
+	c.Check(client.Authentify("wrong"), ErrorMatches, "obsws: status:error error:Authentication Failed.")
+	c.Check(client.Authentify("supersecretpassword"), IsNil)
+	_, err = client.GetSceneList()
+	c.Check(err, IsNil)
+}
+
+func (s *ClientSuite) TestNewClientWithPassword(c *C) {
+	tdata := []struct {
+		protocol       wstest.Protocol
+		serverPassword string
+		clientPassword string
+		errorMatch     string
+	}{
+		{wstest.ProtocolV4, "", "", ""},
+		{wstest.ProtocolV4, "", "ignored", ""},
+		{wstest.ProtocolV4, "supersecretpassword", "supersecretpassword", ""},
+		{wstest.ProtocolV4, "supersecretpassword", "wrong", "obsws: status:error error:Authentication Failed."},
+		{wstest.ProtocolV5, "", "", ""},
+		{wstest.ProtocolV5, "", "ignored", ""},
+		{wstest.ProtocolV5, "supersecretpassword", "supersecretpassword", ""},
+		{wstest.ProtocolV5, "supersecretpassword", "wrong", "obsws: identification failed: .*"},
+		{wstest.ProtocolV5, "supersecretpassword", "", "obsws: server requires authentication but no password was given"},
+	}
+
+	for _, d := range tdata {
+		opts := []wstest.Option{wstest.WithProtocol(d.protocol)}
+		if len(d.serverPassword) > 0 {
+			opts = append(opts, wstest.WithPassword(d.serverPassword))
+		}
+		server := wstest.NewServer(opts...)
+		host, port := server.Address()
+		protocol := ProtocolV4
+		if d.protocol == wstest.ProtocolV5 {
+			protocol = ProtocolV5
+		}
+		client, err := NewClientWithPassword(host, port, d.clientPassword, WithProtocol(protocol))
+		if len(d.errorMatch) == 0 {
+			if c.Check(err, IsNil, Commentf("%+v", d)) == true {
+				c.Check(client.Protocol(), Equals, protocol)
+				_, err = client.GetSceneList()
+				c.Check(err, IsNil, Commentf("%+v", d))
+				client.Close()
+			}
+		} else {
+			c.Check(err, ErrorMatches, d.errorMatch, Commentf("%+v", d))
+			c.Check(client, IsNil)
+		}
+		server.Close()
+	}
+}
+
+func (s *ClientSuite) TestNegotiateProtocol(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	host, port := server.Address()
+
+	client, err := NewClient(host, port, WithHelloTimeout(50*time.Millisecond))
+	c.Assert(err, IsNil)
+	c.Check(client.Protocol(), Equals, ProtocolV4)
+	client.Close()
+
+	_, err = NewClient(host, port, WithProtocol(ProtocolV5), WithHelloTimeout(50*time.Millisecond))
+	c.Check(err, ErrorMatches, "obsws: did not receive Hello message: .*")
+
+	server5 := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server5.Close()
+	host, port = server5.Address()
+
+	client, err = NewClient(host, port, WithEventSubscriptions(EventSubscriptionScenes))
+	c.Assert(err, IsNil)
+	c.Check(client.Protocol(), Equals, ProtocolV5)
+	c.Check(server5.EventSubscriptions(), DeepEquals, []uint32{uint32(EventSubscriptionScenes)})
+	client.Close()
+}
+
+func (s *ClientSuite) TestNegotiateEarlyEvent(c *C) {
+	// a streaming 4.x OBS pushes StreamStatus to unauthenticated
+	// clients
+	frame := `{"update-type":"StreamStatus","streaming":true,"recording":false,"bytes-per-sec":0,"kbits-per-sec":0,"strain":0.0,"total-stream-time":2,"num-total-frames":0,"num-dropped-frames":0,"fps":30.0}`
+	for _, password := range []string{"", "supersecretpassword"} {
+		opts := []wstest.Option{wstest.WithGreeting(frame)}
+		if len(password) > 0 {
+			opts = append(opts, wstest.WithPassword(password))
+		}
+		server := wstest.NewServer(opts...)
+		host, port := server.Address()
+
+		var buf bytes.Buffer
+		client, err := NewClient(host, port, WithPassword(password), WithCapture(&buf))
+		if c.Check(err, IsNil, Commentf("password %q", password)) == false {
+			server.Close()
+			continue
+		}
+		c.Check(client.Protocol(), Equals, ProtocolV4)
+		_, err = client.GetSceneList()
+		c.Check(err, IsNil)
+		client.Close()
+		server.Close()
+
+		// the event is handled as the first frame of the connection
+		records, err := ReadCapture(&buf)
+		c.Assert(err, IsNil)
+		c.Assert(len(records) > 0, Equals, true)
+		c.Check(records[0].Direction, Equals, CaptureReceived)
+		c.Check(string(records[0].Frame), Equals, frame)
+	}
+}
+
+func (s *ClientSuite) TestRequestsV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5), wstest.WithPassword("supersecretpassword"))
+	defer server.Close()
+	client := newServerClient(c, server, WithPassword("supersecretpassword"))
+	defer client.Close()
+	c.Check(server.EventSubscriptions(), DeepEquals, []uint32{uint32(EventSubscriptionAll)})
+
+	scenes, err := client.GetSceneList()
+	c.Assert(err, IsNil)
+	c.Check(scenes.CurrentScene, Equals, "Live")
+	c.Check(scenes.Scenes, DeepEquals, []Scene{{Name: "Live"}, {Name: "BRB"}})
+
+	events := client.EventChannel()
+	c.Check(client.SetCurrentScene("Nope"), ErrorMatches, "obsws: status:error error:requested scene does not exist \\(code 600\\)")
+	c.Check(client.SetCurrentScene("BRB"), IsNil)
+	c.Check(nextEvent(c, events), DeepEquals, &EventSwitchScenes{
+		rawEvent:  rawEvent{"SwitchScenes", -1, -1},
+		SceneName: "BRB",
+	})
+
+	_, err = client.GetAuthRequired()
+	c.Check(err, FitsTypeOf, ErrUnsupportedRequest{})
+	c.Check(err, ErrorMatches, "obsws: request 'GetAuthRequired' is not supported by protocol 5.x")
+	c.Check(client.Authentify("anything"), IsNil)
+
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetSceneList", Fields: nil},
+		{Type: "SetCurrentProgramScene", Fields: map[string]interface{}{"sceneName": "Nope"}},
+		{Type: "SetCurrentProgramScene", Fields: map[string]interface{}{"sceneName": "BRB"}},
+	})
+}
+
+func (s *ClientSuite) TestUnmarshalEventV5(c *C) {
+	tdata := map[string]Event{
+		`{"eventType":"SceneItemCreated","eventIntent":128,"eventData":{"sceneName":"Live","sourceName":"Cam","sceneItemId":3,"sceneItemIndex":2}}`: &EventSceneItemAdded{
+			rawEvent:  rawEvent{"SceneItemAdded", -1, -1},
+			SceneName: "Live",
+			ItemName:  "Cam",
+			ItemID:    3,
+		},
+		`{"eventType":"SceneListChanged","eventIntent":4,"eventData":{"scenes":[]}}`: &EventScenesChanged{
+			rawEvent: rawEvent{"ScenesChanged", -1, -1},
+		},
+	}
+	for data, expected := range tdata {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil) == false {
+			continue
+		}
+		c.Check(ev, DeepEquals, expected)
+	}
+
+	_, err := unmarshalEventV5(json.RawMessage(`{"eventType":"VendorEvent","eventData":{}}`))
+	c.Check(err, ErrorMatches, "obsws: unknown event type 'VendorEvent'")
+}
+
+func (s *ClientSuite) TestMalformedFrames(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	server.Handle("SendGarbage", func(map[string]interface{}) (map[string]interface{}, error) {
+		// not for the obs-websocket API, tests malformed frames
+		server.SendFrame("not json")
+		server.SendFrame(`{"message-id":"unknown","status":"ok"}`)
+		return map[string]interface{}{"scenes": 42}, nil
+	})
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	_, err := client.submitRequest(forgeRequestWithExpectedResponse("SendGarbage", &GetSceneListResponse{}))
+	c.Check(err, FitsTypeOf, ErrMalformedFrame{})
+	c.Check(err, ErrorMatches, "obsws: malformed frame '.*\"scenes\":42.*': json: .*")
+
+	expected := []string{
+		"obsws: malformed frame 'not json': .*",
+		"obsws: unknown message-id 'unknown'",
+		"obsws: malformed frame '.*\"scenes\":42.*': json: .*",
+	}
+	for _, errorMatch := range expected {
+		select {
+		case err := <-client.Errors():
+			c.Check(err, ErrorMatches, errorMatch)
+		case <-time.After(time.Second):
+			c.Fatalf("did not receive error matching '%s'", errorMatch)
+		}
+	}
+
+	// the client is still usable
+	_, err = client.GetSceneList()
+	c.Check(err, IsNil)
+}
+
+func nextEvent(c *C, events <-chan Event) Event {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(time.Second):
+		c.Fatal("did not receive event")
+	}
+	return nil
+}
+
+func (s *ClientSuite) TestDisconnected(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+	events := client.EventChannel()
+
+	server.DropNext()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ev := nextEvent(c, events)
+		c.Check(ev.UpdateType(), Equals, "Disconnected")
+	}()
+	_, err := client.GetSceneList()
+	c.Check(err, FitsTypeOf, ErrDisconnected{})
+	<-done
+
+	// requests fail without blocking once disconnected
+	_, err = client.GetSceneList()
+	c.Check(err, FitsTypeOf, ErrDisconnected{})
+}
+
+func (s *ClientSuite) TestUnknownEvent(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+	events := client.EventChannel()
+
+	frame := `{"update-type":"FutureEvent","answer":42}`
+	server.SendFrame(frame)
+	ev := nextEvent(c, events)
+	if c.Check(ev, FitsTypeOf, &EventUnknown{}) == true {
+		c.Check(ev.UpdateType(), Equals, "FutureEvent")
+		c.Check(string(ev.(*EventUnknown).Data), Equals, frame)
+	}
+}
+
+func (s *ClientSuite) TestReconnect(c *C) {
+	for _, protocol := range []wstest.Protocol{wstest.ProtocolV4, wstest.ProtocolV5} {
+		server := wstest.NewServer(wstest.WithProtocol(protocol), wstest.WithPassword("supersecretpassword"))
+		client := newServerClient(c, server, WithPassword("supersecretpassword"),
+			WithReconnect(Backoff{Initial: 10 * time.Millisecond}))
+		events := client.EventChannel()
+
+		server.DropConnections()
+		ev := nextEvent(c, events)
+		c.Check(ev.UpdateType(), Equals, "Disconnected")
+		ev = nextEvent(c, events)
+		c.Check(ev, DeepEquals, &EventReconnecting{
+			Attempt:  1,
+			Delay:    10 * time.Millisecond,
+			rawEvent: newRawEvent("Reconnecting"),
+		})
+		ev = nextEvent(c, events)
+		c.Check(ev.UpdateType(), Equals, "Connected")
+		if protocol == wstest.ProtocolV5 {
+			// event subscriptions are sent again
+			c.Check(server.EventSubscriptions(), DeepEquals, []uint32{
+				uint32(EventSubscriptionAll), uint32(EventSubscriptionAll),
+			})
+		}
+
+		// the client authenticated again
+		scenes, err := client.GetSceneList()
+		c.Check(err, IsNil)
+		c.Check(scenes.CurrentScene, Equals, "Live")
+
+		client.Close()
+		server.Close()
+	}
+}
+
+func (s *ClientSuite) TestReconnectInFlight(c *C) {
+	tdata := map[InFlightPolicy]Checker{
+		InFlightFail:  NotNil,
+		InFlightRetry: IsNil,
+	}
+	for policy, checker := range tdata {
+		server := wstest.NewServer()
+		client := newServerClient(c, server, WithInFlightPolicy(policy),
+			WithReconnect(Backoff{Initial: 10 * time.Millisecond}))
+		events := client.EventChannel()
+
+		server.DropNext()
+		done := make(chan error)
+		go func() {
+			_, err := client.GetSceneList()
+			done <- err
+		}()
+		for _, expected := range []string{"Disconnected", "Reconnecting", "Connected"} {
+			c.Check(nextEvent(c, events).UpdateType(), Equals, expected)
+		}
+		c.Check(<-done, checker, Commentf("policy %d", policy))
+
+		client.Close()
+		server.Close()
+	}
+}
+
+func (s *ClientSuite) TestReconnectFailsNewRequests(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server, WithReconnect(Backoff{Initial: serverDelay}))
+	defer client.Close()
+	events := client.EventChannel()
+
+	server.DropConnections()
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Disconnected")
+	// with InFlightFail, a request made while reconnecting is not
+	// sent again on the new connection
+	_, err := client.GetSceneList()
+	c.Check(err, FitsTypeOf, ErrDisconnected{})
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Reconnecting")
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Connected")
+
+	_, err = client.GetSceneList()
+	c.Check(err, IsNil)
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetSceneList", Fields: map[string]interface{}{}},
+	})
+}
+
+// hangingListener accepts connections and never answers.
+func hangingListener(c *C, address string) net.Listener {
+	l, err := net.Listen("tcp", address)
+	c.Assert(err, IsNil)
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	return l
+}
+
+func (s *ClientSuite) TestHandshakeTimeout(c *C) {
+	l := hangingListener(c, "127.0.0.1:0")
+	defer l.Close()
+	addr := l.Addr().(*net.TCPAddr)
+
+	start := time.Now()
+	_, err := NewClient("127.0.0.1", addr.Port, WithHandshakeTimeout(serverDelay))
+	c.Check(err, ErrorMatches, "obsws: handshake did not complete: context deadline exceeded")
+	c.Check(time.Since(start) < time.Second, Equals, true)
+}
+
+func (s *ClientSuite) TestCloseWhileReconnecting(c *C) {
+	server := wstest.NewServer()
+	host, port := server.Address()
+	client := newServerClient(c, server, WithReconnect(Backoff{Initial: time.Millisecond}))
+	events := client.EventChannel()
+
+	// the instance comes back but never answers the handshake
+	server.Close()
+	l := hangingListener(c, net.JoinHostPort(host, strconv.Itoa(port)))
+	defer l.Close()
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Disconnected")
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Reconnecting")
+	time.Sleep(serverDelay)
+
+	closed := make(chan struct{})
+	go func() {
+		client.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		c.Fatal("Close waited for the handshake")
+	}
+}
+
+func (s *ClientSuite) TestReconnectGiveUp(c *C) {
+	server := wstest.NewServer()
+	client := newServerClient(c, server, WithReconnect(Backoff{Initial: time.Millisecond, MaxAttempts: 3}))
+	defer client.Close()
+	events := client.EventChannel()
+
+	server.Close()
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Disconnected")
+	for i := 1; i <= 3; i++ {
+		ev := nextEvent(c, events)
+		c.Check(ev, FitsTypeOf, &EventReconnecting{})
+		c.Check(ev.(*EventReconnecting).Attempt, Equals, i)
+	}
+	_, err := client.GetSceneList()
+	c.Check(err, FitsTypeOf, ErrDisconnected{})
+}
+
+func (s *ClientSuite) TestBackoffDelay(c *C) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second}.withDefaults()
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, d := range expected {
+		c.Check(b.delay(i+1), Equals, d)
+	}
+}
+
+func (s *ClientSuite) TestRequestContext(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	server.DelayNext("GetSceneList", serverDelay)
+	ctx, cancel := context.WithTimeout(context.Background(), serverDelay/5)
+	defer cancel()
+	_, err := client.GetSceneListCtx(ctx)
+	c.Check(err, Equals, context.DeadlineExceeded)
+
+	// the late response is not waited for anymore
+	select {
+	case err := <-client.Errors():
+		c.Check(err, FitsTypeOf, ErrUnknownMessageID{})
+	case <-time.After(time.Second):
+		c.Fatal("late response was not reported")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	c.Check(client.SetCurrentSceneCtx(ctx, "BRB"), Equals, context.Canceled)
+
+	_, err = client.GetSceneListCtx(context.Background())
+	c.Check(err, IsNil)
+}
+
+func (s *ClientSuite) TestRequestTimeout(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server, WithRequestTimeout(serverDelay/5))
+	defer client.Close()
+
+	server.DelayNext("GetSceneList", serverDelay)
+	_, err := client.GetSceneList()
+	c.Check(err, Equals, context.DeadlineExceeded)
+
+	// wait for the late response
+	<-client.Errors()
+	_, err = client.GetSceneList()
+	c.Check(err, IsNil)
+}
+
+func (s *ClientSuite) TestCloseFailsRequests(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+
+	server.DelayNext("GetSceneList", serverDelay)
+	done := make(chan error)
+	go func() {
+		_, err := client.GetSceneList()
+		done <- err
+	}()
+	time.Sleep(serverDelay / 5)
+	client.Close()
+	c.Check(<-done, Equals, ErrClosed{})
+
+	_, err := client.GetSceneList()
+	c.Check(err, Equals, ErrClosed{})
+	// closing twice is harmless
+	client.Close()
+}
+
+func (s *ClientSuite) TestConcurrentRequests(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	events := client.EventChannel()
+	received := make(chan int)
+	go func() {
+		n := 0
+		for range events {
+			n++
+		}
+		received <- n
+	}()
+
+	const workers, requests = 20, 10
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < requests; j++ {
+				if _, err := client.GetSceneList(); err != nil {
+					c.Error(err)
+				}
+				if err := client.SetCurrentScene("BRB"); err != nil {
+					c.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	client.Close()
+	// every event is sent after its response, some may be lost on close
+	c.Check(<-received <= workers*requests, Equals, true)
+}
+
+func (s *ClientSuite) TestCloseDoesNotLeak(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		client := newServerClient(c, server, WithReconnect(Backoff{}))
+		go func() {
+			for range client.EventChannel() {
+			}
+		}()
+		_, err := client.GetSceneList()
+		c.Check(err, IsNil)
+		client.Close()
+	}
+
+	// the server goroutines need a bit of time to notice the
+	// connections closed
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		buf := make([]byte, 1<<16)
+		c.Fatalf("%d goroutines leaked:\n%s", after-before, buf[:runtime.Stack(buf, true)])
+	}
+}
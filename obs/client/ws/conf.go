@@ -0,0 +1,135 @@
+package ws
+
+import (
+	"io"
+	"time"
+)
+
+type config struct {
+	protocol           Protocol
+	password           string
+	helloTimeout       time.Duration
+	handshakeTimeout   time.Duration
+	eventSubscriptions EventSubscription
+	reconnect          bool
+	backoff            Backoff
+	inFlightPolicy     InFlightPolicy
+	requestTimeout     time.Duration
+	capture            io.Writer
+	deliveryTimeout    time.Duration
+}
+
+func defaultConfig() config {
+	return config{
+		protocol:           ProtocolAuto,
+		helloTimeout:       500 * time.Millisecond,
+		handshakeTimeout:   10 * time.Second,
+		eventSubscriptions: EventSubscriptionAll,
+		backoff:            DefaultBackoff,
+		inFlightPolicy:     InFlightFail,
+		deliveryTimeout:    DefaultDeliveryTimeout,
+	}
+}
+
+// DefaultDeliveryTimeout is the delivery timeout of the DeliveryBlock
+// subscribers unless set with WithDeliveryTimeout.
+const DefaultDeliveryTimeout = 10 * time.Second
+
+// An Option customizes a Client when it is created with NewClient.
+type Option func(conf *config)
+
+// WithProtocol forces the obs-websocket protocol version to speak
+// instead of negotiating it on connection.
+func WithProtocol(p Protocol) Option {
+	return func(conf *config) {
+		conf.protocol = p
+	}
+}
+
+// WithPassword sets the password used to authenticate on
+// connection.
+func WithPassword(password string) Option {
+	return func(conf *config) {
+		conf.password = password
+	}
+}
+
+// WithHelloTimeout sets how long the client waits for the
+// obs-websocket 5 Hello message before falling back to the 4.x
+// protocol, or failing if ProtocolV5 is forced.
+func WithHelloTimeout(timeout time.Duration) Option {
+	return func(conf *config) {
+		conf.helloTimeout = timeout
+	}
+}
+
+// WithHandshakeTimeout sets how long the client waits to connect to
+// the instance and negotiate the protocol, on creation and on each
+// reconnection attempt. It is 10 seconds by default.
+func WithHandshakeTimeout(timeout time.Duration) Option {
+	return func(conf *config) {
+		conf.handshakeTimeout = timeout
+	}
+}
+
+// WithEventSubscriptions sets the event categories to receive from
+// an obs-websocket 5 server. The 4.x protocol always sends every
+// event.
+func WithEventSubscriptions(subscriptions EventSubscription) Option {
+	return func(conf *config) {
+		conf.eventSubscriptions = subscriptions
+	}
+}
+
+// WithReconnect makes the client reconnect to the instance when the
+// connection drops, waiting between attempts according to backoff. The
+// client authenticates and subscribes to events again on
+// reconnection.
+func WithReconnect(backoff Backoff) Option {
+	return func(conf *config) {
+		conf.reconnect = true
+		conf.backoff = backoff.withDefaults()
+	}
+}
+
+// WithInFlightPolicy sets what happens to the requests waiting for an
+// answer when the connection drops.
+func WithInFlightPolicy(policy InFlightPolicy) Option {
+	return func(conf *config) {
+		conf.inFlightPolicy = policy
+	}
+}
+
+// WithRequestTimeout sets a timeout applied to every request, in
+// addition to the deadline of the context given to the Ctx variants
+// of the requests. A zero timeout, the default, waits forever.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(conf *config) {
+		conf.requestTimeout = timeout
+	}
+}
+
+// WithCapture writes every request sent and every frame received by
+// the client to w, as the JSON lines of CaptureRecord, to reproduce
+// incidents with ReadCapture and ReplayEvents or wstest. The handshake
+// is not captured, and the stream key and password of the stream
+// service settings and the authentication secrets are redacted, but
+// the other frames are written as is. The frames are written from a goroutine: they are dropped while
+// w lags too far behind, and writing stops at the first error, both
+// reported on the Errors channel. Close writes the pending frames;
+// the client does not close w.
+func WithCapture(w io.Writer) Option {
+	return func(conf *config) {
+		conf.capture = w
+	}
+}
+
+// WithDeliveryTimeout sets how long an event waits for each
+// DeliveryBlock subscriber but EventChannel to receive it. The
+// event is then dropped for that subscriber and an ErrEventDropped is
+// reported on the Errors channel. A zero timeout waits forever.
+func WithDeliveryTimeout(timeout time.Duration) Option {
+	return func(conf *config) {
+		conf.deliveryTimeout = timeout
+	}
+}
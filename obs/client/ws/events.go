@@ -80,6 +80,8 @@ func (e ErrNotEventMessage) Error() string {
 
 type ErrUnknownEventType struct {
 	Type string
+	// Data is the raw JSON of the event
+	Data json.RawMessage
 }
 
 func (e ErrUnknownEventType) Error() string {
@@ -128,7 +130,7 @@ func UnmarshalEvent(data []byte) (Event, error) {
 	// now we extract the generic part
 	evType, ok := eventFactory[rawE.UpdateType()]
 	if ok == false {
-		return nil, ErrUnknownEventType{rawE.UpdateType()}
+		return nil, ErrUnknownEventType{Type: rawE.UpdateType(), Data: data}
 	}
 
 	evInst := reflect.New(evType)
@@ -145,8 +147,64 @@ func UnmarshalEvent(data []byte) (Event, error) {
 
 var eventFactory map[string]reflect.Type
 
+// EventUnknown is dispatched by the Client for the events it does not
+// know, instead of dropping them. Data is the raw JSON of the event,
+// in the format of the protocol spoken with the instance.
+type EventUnknown struct {
+	Data json.RawMessage
+	rawEvent
+}
+
+// General events
+
+type EventHeartbeat struct {
+	Pulse             bool     `json:"pulse"`
+	CurrentProfile    string   `json:"current-profile"`
+	CurrentScene      string   `json:"current-scene"`
+	Streaming         bool     `json:"streaming"`
+	TotalStreamTime   int      `json:"total-stream-time"`
+	TotalStreamBytes  int64    `json:"total-stream-bytes"`
+	TotalStreamFrames int      `json:"total-stream-frames"`
+	Recording         bool     `json:"recording"`
+	TotalRecordTime   int      `json:"total-record-time"`
+	TotalRecordBytes  int64    `json:"total-record-bytes"`
+	TotalRecordFrames int      `json:"total-record-frames"`
+	Stats             OBSStats `json:"stats"`
+	rawEvent
+}
+
+// OBSStats are the performance statistics of the OBS instance.
+type OBSStats struct {
+	FPS                 float64 `json:"fps"`
+	RenderTotalFrames   int     `json:"render-total-frames"`
+	RenderMissedFrames  int     `json:"render-missed-frames"`
+	OutputTotalFrames   int     `json:"output-total-frames"`
+	OutputSkippedFrames int     `json:"output-skipped-frames"`
+	// AverageFrameTime is in milliseconds
+	AverageFrameTime float64 `json:"average-frame-time"`
+	// CPUUsage is in percent
+	CPUUsage float64 `json:"cpu-usage"`
+	// MemoryUsage is in megabytes
+	MemoryUsage float64 `json:"memory-usage"`
+	// FreeDiskSpace is in megabytes
+	FreeDiskSpace float64 `json:"free-disk-space"`
+}
+
+type EventBroadcastCustomMessage struct {
+	Realm string                 `json:"realm"`
+	Data  map[string]interface{} `json:"data"`
+	rawEvent
+}
+
+type EventExiting struct {
+	rawEvent
+}
+
+// Scenes events
+
 type EventSwitchScenes struct {
-	SceneName string `json:"scene-name"`
+	SceneName string   `json:"scene-name"`
+	Sources   []Source `json:"sources"`
 	rawEvent
 }
 
@@ -154,20 +212,93 @@ type EventScenesChanged struct {
 	rawEvent
 }
 
-type EventSourceOrderChanged struct {
-	SceneName string `json:"scene-name"`
+type EventSceneCollectionChanged struct {
+	SceneCollection string `json:"sceneCollection"`
 	rawEvent
 }
 
-type EventSceneItemAdded struct {
-	SceneName string `json:"scene-name"`
-	ItemName  string `json:"item-name"`
+type EventSceneCollectionListChanged struct {
+	SceneCollections []ListItem `json:"sceneCollections"`
 	rawEvent
 }
 
-type EventSceneItemRemoved struct {
-	SceneName string `json:"scene-name"`
-	ItemName  string `json:"item-name"`
+// Transitions events
+
+type EventSwitchTransition struct {
+	TransitionName string `json:"transition-name"`
+	rawEvent
+}
+
+type EventTransitionListChanged struct {
+	Transitions []Transition `json:"transitions"`
+	rawEvent
+}
+
+type EventTransitionDurationChanged struct {
+	// OldDuration and NewDuration are in milliseconds
+	OldDuration int `json:"old-duration"`
+	NewDuration int `json:"new-duration"`
+	rawEvent
+}
+
+type EventTransitionBegin struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Duration is in milliseconds
+	Duration  int    `json:"duration"`
+	FromScene string `json:"from-scene"`
+	ToScene   string `json:"to-scene"`
+	rawEvent
+}
+
+type EventTransitionEnd struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Duration is in milliseconds
+	Duration int    `json:"duration"`
+	ToScene  string `json:"to-scene"`
+	rawEvent
+}
+
+type EventTransitionVideoEnd struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Duration is in milliseconds
+	Duration  int    `json:"duration"`
+	FromScene string `json:"from-scene"`
+	ToScene   string `json:"to-scene"`
+	rawEvent
+}
+
+// Profiles events
+
+type EventProfileChanged struct {
+	Profile string `json:"profile"`
+	rawEvent
+}
+
+type EventProfileListChanged struct {
+	Profiles []ListItem `json:"profiles"`
+	rawEvent
+}
+
+// Streaming events
+
+type EventStreamStarting struct {
+	PreviewOnly bool `json:"preview-only"`
+	rawEvent
+}
+
+type EventStreamStarted struct {
+	rawEvent
+}
+
+type EventStreamStopping struct {
+	PreviewOnly bool `json:"preview-only"`
+	rawEvent
+}
+
+type EventStreamStopped struct {
 	rawEvent
 }
 
@@ -185,13 +316,378 @@ type EventStreamStatus struct {
 	rawEvent
 }
 
+// Recording events
+
+type EventRecordingStarting struct {
+	rawEvent
+}
+
+type EventRecordingStarted struct {
+	RecordingFilename string `json:"recordingFilename"`
+	rawEvent
+}
+
+type EventRecordingStopping struct {
+	RecordingFilename string `json:"recordingFilename"`
+	rawEvent
+}
+
+type EventRecordingStopped struct {
+	RecordingFilename string `json:"recordingFilename"`
+	rawEvent
+}
+
+type EventRecordingPaused struct {
+	rawEvent
+}
+
+type EventRecordingResumed struct {
+	rawEvent
+}
+
+// Virtual cam events
+
+type EventVirtualCamStarted struct {
+	rawEvent
+}
+
+type EventVirtualCamStopped struct {
+	rawEvent
+}
+
+// Replay buffer events
+
+type EventReplayStarting struct {
+	rawEvent
+}
+
+type EventReplayStarted struct {
+	rawEvent
+}
+
+type EventReplayStopping struct {
+	rawEvent
+}
+
+type EventReplayStopped struct {
+	rawEvent
+}
+
+// EventReplayBufferSaved is only sent by obs-websocket 5.
+type EventReplayBufferSaved struct {
+	SavedReplayPath string `json:"savedReplayPath"`
+	rawEvent
+}
+
+// Media events
+
+type EventMediaPlaying struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+type EventMediaPaused struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+type EventMediaRestarted struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+type EventMediaStopped struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+type EventMediaNext struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+type EventMediaPrevious struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+type EventMediaStarted struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+type EventMediaEnded struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+// Sources events
+
+type EventSourceCreated struct {
+	SourceName     string         `json:"sourceName"`
+	SourceType     string         `json:"sourceType"`
+	SourceKind     string         `json:"sourceKind"`
+	SourceSettings SourceSettings `json:"sourceSettings"`
+	rawEvent
+}
+
+type EventSourceDestroyed struct {
+	SourceName string `json:"sourceName"`
+	SourceType string `json:"sourceType"`
+	SourceKind string `json:"sourceKind"`
+	rawEvent
+}
+
+type EventSourceVolumeChanged struct {
+	SourceName string  `json:"sourceName"`
+	Volume     float64 `json:"volume"`
+	VolumeDb   float64 `json:"volumeDb"`
+	rawEvent
+}
+
+type EventSourceMuteStateChanged struct {
+	SourceName string `json:"sourceName"`
+	Muted      bool   `json:"muted"`
+	rawEvent
+}
+
+type EventSourceAudioDeactivated struct {
+	SourceName string `json:"sourceName"`
+	rawEvent
+}
+
+type EventSourceAudioActivated struct {
+	SourceName string `json:"sourceName"`
+	rawEvent
+}
+
+type EventSourceAudioSyncOffsetChanged struct {
+	SourceName string        `json:"sourceName"`
+	SyncOffset time.Duration `json:"syncOffset"`
+	rawEvent
+}
+
+type AudioMixer struct {
+	ID      int  `json:"id"`
+	Enabled bool `json:"enabled"`
+}
+
+type EventSourceAudioMixersChanged struct {
+	SourceName     string       `json:"sourceName"`
+	Mixers         []AudioMixer `json:"mixers"`
+	HexMixersValue string       `json:"hexMixersValue"`
+	rawEvent
+}
+
+type EventSourceRenamed struct {
+	PreviousName string `json:"previousName"`
+	NewName      string `json:"newName"`
+	SourceType   string `json:"sourceType"`
+	rawEvent
+}
+
+type EventSourceFilterAdded struct {
+	SourceName     string         `json:"sourceName"`
+	FilterName     string         `json:"filterName"`
+	FilterType     string         `json:"filterType"`
+	FilterSettings FilterSettings `json:"filterSettings"`
+	rawEvent
+}
+
+type EventSourceFilterRemoved struct {
+	SourceName string `json:"sourceName"`
+	FilterName string `json:"filterName"`
+	FilterType string `json:"filterType"`
+	rawEvent
+}
+
+type EventSourceFilterVisibilityChanged struct {
+	SourceName    string `json:"sourceName"`
+	FilterName    string `json:"filterName"`
+	FilterEnabled bool   `json:"filterEnabled"`
+	rawEvent
+}
+
+type EventSourceFiltersReordered struct {
+	SourceName string   `json:"sourceName"`
+	Filters    []Filter `json:"filters"`
+	rawEvent
+}
+
+// Scene items events
+
+type SceneItemOrder struct {
+	SourceName string `json:"source-name"`
+	ItemID     int    `json:"item-id"`
+}
+
+type EventSourceOrderChanged struct {
+	SceneName  string           `json:"scene-name"`
+	SceneItems []SceneItemOrder `json:"scene-items"`
+	rawEvent
+}
+
+type EventSceneItemAdded struct {
+	SceneName string `json:"scene-name"`
+	ItemName  string `json:"item-name"`
+	ItemID    int    `json:"item-id"`
+	rawEvent
+}
+
+type EventSceneItemRemoved struct {
+	SceneName string `json:"scene-name"`
+	ItemName  string `json:"item-name"`
+	ItemID    int    `json:"item-id"`
+	rawEvent
+}
+
+type EventSceneItemVisibilityChanged struct {
+	SceneName   string `json:"scene-name"`
+	ItemName    string `json:"item-name"`
+	ItemID      int    `json:"item-id"`
+	ItemVisible bool   `json:"item-visible"`
+	rawEvent
+}
+
+type EventSceneItemLockChanged struct {
+	SceneName  string `json:"scene-name"`
+	ItemName   string `json:"item-name"`
+	ItemID     int    `json:"item-id"`
+	ItemLocked bool   `json:"item-locked"`
+	rawEvent
+}
+
+type EventSceneItemTransformChanged struct {
+	SceneName string              `json:"scene-name"`
+	ItemName  string              `json:"item-name"`
+	ItemID    int                 `json:"item-id"`
+	Transform SceneItemProperties `json:"transform"`
+	rawEvent
+}
+
+type EventSceneItemSelected struct {
+	SceneName string `json:"scene-name"`
+	ItemName  string `json:"item-name"`
+	ItemID    int    `json:"item-id"`
+	rawEvent
+}
+
+type EventSceneItemDeselected struct {
+	SceneName string `json:"scene-name"`
+	ItemName  string `json:"item-name"`
+	ItemID    int    `json:"item-id"`
+	rawEvent
+}
+
+// Studio mode events
+
+type EventPreviewSceneChanged struct {
+	SceneName string   `json:"scene-name"`
+	Sources   []Source `json:"sources"`
+	rawEvent
+}
+
+type EventStudioModeSwitched struct {
+	NewState bool `json:"new-state"`
+	rawEvent
+}
+
 func init() {
 	eventFactory = map[string]reflect.Type{
-		"SwitchScenes":       reflect.TypeOf(EventSwitchScenes{}),
-		"ScenesChanged":      reflect.TypeOf(EventScenesChanged{}),
-		"SourceOrderChanged": reflect.TypeOf(EventSourceOrderChanged{}),
-		"SceneItemAdded":     reflect.TypeOf(EventSceneItemAdded{}),
-		"SceneItemRemoved":   reflect.TypeOf(EventSceneItemRemoved{}),
-		"StreamStatus":       reflect.TypeOf(EventStreamStatus{}),
+		// General
+		"Heartbeat":              reflect.TypeOf(EventHeartbeat{}),
+		"BroadcastCustomMessage": reflect.TypeOf(EventBroadcastCustomMessage{}),
+		"Exiting":                reflect.TypeOf(EventExiting{}),
+
+		// Scenes
+		"SwitchScenes":               reflect.TypeOf(EventSwitchScenes{}),
+		"ScenesChanged":              reflect.TypeOf(EventScenesChanged{}),
+		"SceneCollectionChanged":     reflect.TypeOf(EventSceneCollectionChanged{}),
+		"SceneCollectionListChanged": reflect.TypeOf(EventSceneCollectionListChanged{}),
+
+		// Transitions
+		"SwitchTransition":          reflect.TypeOf(EventSwitchTransition{}),
+		"TransitionListChanged":     reflect.TypeOf(EventTransitionListChanged{}),
+		"TransitionDurationChanged": reflect.TypeOf(EventTransitionDurationChanged{}),
+		"TransitionBegin":           reflect.TypeOf(EventTransitionBegin{}),
+		"TransitionEnd":             reflect.TypeOf(EventTransitionEnd{}),
+		"TransitionVideoEnd":        reflect.TypeOf(EventTransitionVideoEnd{}),
+
+		// Profiles
+		"ProfileChanged":     reflect.TypeOf(EventProfileChanged{}),
+		"ProfileListChanged": reflect.TypeOf(EventProfileListChanged{}),
+
+		// Streaming
+		"StreamStarting": reflect.TypeOf(EventStreamStarting{}),
+		"StreamStarted":  reflect.TypeOf(EventStreamStarted{}),
+		"StreamStopping": reflect.TypeOf(EventStreamStopping{}),
+		"StreamStopped":  reflect.TypeOf(EventStreamStopped{}),
+		"StreamStatus":   reflect.TypeOf(EventStreamStatus{}),
+
+		// Recording
+		"RecordingStarting": reflect.TypeOf(EventRecordingStarting{}),
+		"RecordingStarted":  reflect.TypeOf(EventRecordingStarted{}),
+		"RecordingStopping": reflect.TypeOf(EventRecordingStopping{}),
+		"RecordingStopped":  reflect.TypeOf(EventRecordingStopped{}),
+		"RecordingPaused":   reflect.TypeOf(EventRecordingPaused{}),
+		"RecordingResumed":  reflect.TypeOf(EventRecordingResumed{}),
+
+		// Virtual cam
+		"VirtualCamStarted": reflect.TypeOf(EventVirtualCamStarted{}),
+		"VirtualCamStopped": reflect.TypeOf(EventVirtualCamStopped{}),
+
+		// Replay buffer
+		"ReplayStarting":    reflect.TypeOf(EventReplayStarting{}),
+		"ReplayStarted":     reflect.TypeOf(EventReplayStarted{}),
+		"ReplayStopping":    reflect.TypeOf(EventReplayStopping{}),
+		"ReplayStopped":     reflect.TypeOf(EventReplayStopped{}),
+		"ReplayBufferSaved": reflect.TypeOf(EventReplayBufferSaved{}),
+
+		// Media
+		"MediaPlaying":   reflect.TypeOf(EventMediaPlaying{}),
+		"MediaPaused":    reflect.TypeOf(EventMediaPaused{}),
+		"MediaRestarted": reflect.TypeOf(EventMediaRestarted{}),
+		"MediaStopped":   reflect.TypeOf(EventMediaStopped{}),
+		"MediaNext":      reflect.TypeOf(EventMediaNext{}),
+		"MediaPrevious":  reflect.TypeOf(EventMediaPrevious{}),
+		"MediaStarted":   reflect.TypeOf(EventMediaStarted{}),
+		"MediaEnded":     reflect.TypeOf(EventMediaEnded{}),
+
+		// Sources
+		"SourceCreated":                 reflect.TypeOf(EventSourceCreated{}),
+		"SourceDestroyed":               reflect.TypeOf(EventSourceDestroyed{}),
+		"SourceVolumeChanged":           reflect.TypeOf(EventSourceVolumeChanged{}),
+		"SourceMuteStateChanged":        reflect.TypeOf(EventSourceMuteStateChanged{}),
+		"SourceAudioDeactivated":        reflect.TypeOf(EventSourceAudioDeactivated{}),
+		"SourceAudioActivated":          reflect.TypeOf(EventSourceAudioActivated{}),
+		"SourceAudioSyncOffsetChanged":  reflect.TypeOf(EventSourceAudioSyncOffsetChanged{}),
+		"SourceAudioMixersChanged":      reflect.TypeOf(EventSourceAudioMixersChanged{}),
+		"SourceRenamed":                 reflect.TypeOf(EventSourceRenamed{}),
+		"SourceFilterAdded":             reflect.TypeOf(EventSourceFilterAdded{}),
+		"SourceFilterRemoved":           reflect.TypeOf(EventSourceFilterRemoved{}),
+		"SourceFilterVisibilityChanged": reflect.TypeOf(EventSourceFilterVisibilityChanged{}),
+		"SourceFiltersReordered":        reflect.TypeOf(EventSourceFiltersReordered{}),
+
+		// Scene items
+		"SourceOrderChanged":         reflect.TypeOf(EventSourceOrderChanged{}),
+		"SceneItemAdded":             reflect.TypeOf(EventSceneItemAdded{}),
+		"SceneItemRemoved":           reflect.TypeOf(EventSceneItemRemoved{}),
+		"SceneItemVisibilityChanged": reflect.TypeOf(EventSceneItemVisibilityChanged{}),
+		"SceneItemLockChanged":       reflect.TypeOf(EventSceneItemLockChanged{}),
+		"SceneItemTransformChanged":  reflect.TypeOf(EventSceneItemTransformChanged{}),
+		"SceneItemSelected":          reflect.TypeOf(EventSceneItemSelected{}),
+		"SceneItemDeselected":        reflect.TypeOf(EventSceneItemDeselected{}),
+
+		// Studio mode
+		"PreviewSceneChanged": reflect.TypeOf(EventPreviewSceneChanged{}),
+		"StudioModeSwitched":  reflect.TypeOf(EventStudioModeSwitched{}),
 	}
 }
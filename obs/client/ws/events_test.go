@@ -103,6 +103,52 @@ func (s *EventSuite) TestEventFactory(c *C) {
 			NumTotalFrames:   200,
 			NumDroppedFrames: 1,
 		}: `{"update-type":"StreamStatus","fps":29.97,"streaming":true,"bytes-per-sec":1234,"kbits-per-sec":1,"preview-only":false,"strain":0.001,"total-stream-time":122,"num-total-frames":200,"num-dropped-frames":1}`,
+		&EventHeartbeat{
+			rawEvent:       rawEvent{"Heartbeat", -1, -1},
+			Pulse:          true,
+			CurrentProfile: "Twitch",
+			CurrentScene:   "Live",
+			Streaming:      true,
+			Stats: OBSStats{
+				FPS:           60,
+				CPUUsage:      12.5,
+				FreeDiskSpace: 1024,
+			},
+		}: `{"update-type":"Heartbeat","pulse":true,"current-profile":"Twitch","current-scene":"Live","streaming":true,"stats":{"fps":60,"cpu-usage":12.5,"free-disk-space":1024}}`,
+		&EventExiting{
+			rawEvent: rawEvent{"Exiting", -1, -1},
+		}: `{"update-type":"Exiting"}`,
+		&EventBroadcastCustomMessage{
+			rawEvent: rawEvent{"BroadcastCustomMessage", -1, -1},
+			Realm:    "overlay",
+			Data:     map[string]interface{}{"alert": "follow"},
+		}: `{"update-type":"BroadcastCustomMessage","realm":"overlay","data":{"alert":"follow"}}`,
+		&EventSourceOrderChanged{
+			rawEvent:  rawEvent{"SourceOrderChanged", -1, -1},
+			SceneName: "Live",
+			SceneItems: []SceneItemOrder{
+				{SourceName: "Cam", ItemID: 3},
+				{SourceName: "Game", ItemID: 1},
+			},
+		}: `{"update-type":"SourceOrderChanged","scene-name":"Live","scene-items":[{"source-name":"Cam","item-id":3},{"source-name":"Game","item-id":1}]}`,
+		&EventSceneItemVisibilityChanged{
+			rawEvent:    rawEvent{"SceneItemVisibilityChanged", -1, -1},
+			SceneName:   "Live",
+			ItemName:    "Cam",
+			ItemID:      3,
+			ItemVisible: true,
+		}: `{"update-type":"SceneItemVisibilityChanged","scene-name":"Live","item-name":"Cam","item-id":3,"item-visible":true}`,
+		&EventSourceAudioMixersChanged{
+			rawEvent:       rawEvent{"SourceAudioMixersChanged", -1, -1},
+			SourceName:     "Mic",
+			Mixers:         []AudioMixer{{ID: 1, Enabled: true}, {ID: 2, Enabled: false}},
+			HexMixersValue: "01",
+		}: `{"update-type":"SourceAudioMixersChanged","sourceName":"Mic","mixers":[{"id":1,"enabled":true},{"id":2,"enabled":false}],"hexMixersValue":"01"}`,
+		&EventMediaEnded{
+			rawEvent:   rawEvent{"MediaEnded", -1, -1},
+			SourceName: "Intro",
+			SourceKind: "ffmpeg_source",
+		}: `{"update-type":"MediaEnded","sourceName":"Intro","sourceKind":"ffmpeg_source"}`,
 	}
 
 	for expected, jsonData := range tdata {
@@ -114,3 +160,50 @@ func (s *EventSuite) TestEventFactory(c *C) {
 	}
 
 }
+
+func (s *EventSuite) TestUnknownEventData(c *C) {
+	data := `{"update-type":"foo","bar":1}`
+	_, err := UnmarshalEvent([]byte(data))
+	if c.Check(err, FitsTypeOf, ErrUnknownEventType{}) == true {
+		c.Check(string(err.(ErrUnknownEventType).Data), Equals, data)
+	}
+}
+
+func (s *EventSuite) TestEventCatalogueV5(c *C) {
+	tdata := map[string]Event{
+		`{"eventType":"ExitStarted","eventIntent":1}`: &EventExiting{
+			rawEvent: newRawEvent("Exiting"),
+		},
+		`{"eventType":"VirtualcamStateChanged","eventIntent":64,"eventData":{"outputActive":true,"outputState":"OBS_WEBSOCKET_OUTPUT_STARTED"}}`: &EventVirtualCamStarted{
+			rawEvent: newRawEvent("VirtualCamStarted"),
+		},
+		`{"eventType":"InputNameChanged","eventIntent":8,"eventData":{"oldInputName":"Cam","inputName":"Webcam"}}`: &EventSourceRenamed{
+			PreviousName: "Cam",
+			NewName:      "Webcam",
+			rawEvent:     newRawEvent("SourceRenamed"),
+		},
+		`{"eventType":"SceneItemLockStateChanged","eventIntent":128,"eventData":{"sceneName":"Live","sceneItemId":3,"sceneItemLocked":true}}`: &EventSceneItemLockChanged{
+			SceneName:  "Live",
+			ItemID:     3,
+			ItemLocked: true,
+			rawEvent:   newRawEvent("SceneItemLockChanged"),
+		},
+		`{"eventType":"MediaInputActionTriggered","eventIntent":256,"eventData":{"inputName":"Intro","mediaAction":"OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PAUSE"}}`: &EventMediaPaused{
+			SourceName: "Intro",
+			rawEvent:   newRawEvent("MediaPaused"),
+		},
+		`{"eventType":"MediaInputPlaybackEnded","eventIntent":256,"eventData":{"inputName":"Intro"}}`: &EventMediaEnded{
+			SourceName: "Intro",
+			rawEvent:   newRawEvent("MediaEnded"),
+		},
+	}
+	for data, expected := range tdata {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+
+	_, err := unmarshalEventV5([]byte(`{"eventType":"MediaInputActionTriggered","eventData":{"inputName":"Intro","mediaAction":"OBS_WEBSOCKET_MEDIA_INPUT_ACTION_NONE"}}`))
+	c.Check(err, ErrorMatches, "obsws: unknown event type 'MediaInputActionTriggered/OBS_WEBSOCKET_MEDIA_INPUT_ACTION_NONE'")
+}
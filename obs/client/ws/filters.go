@@ -0,0 +1,273 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// FilterSettings are the settings of a filter, which depend on its
+// type.
+type FilterSettings map[string]interface{}
+
+type Filter struct {
+	Enabled  bool           `json:"enabled"`
+	Type     string         `json:"type"`
+	Name     string         `json:"name"`
+	Settings FilterSettings `json:"settings,omitempty"`
+}
+
+// filterV5 is a Filter in obs-websocket 5.
+type filterV5 struct {
+	FilterEnabled  bool           `json:"filterEnabled"`
+	FilterKind     string         `json:"filterKind"`
+	FilterName     string         `json:"filterName"`
+	FilterSettings FilterSettings `json:"filterSettings"`
+}
+
+func (f filterV5) filter() Filter {
+	return Filter{
+		Enabled:  f.FilterEnabled,
+		Type:     f.FilterKind,
+		Name:     f.FilterName,
+		Settings: f.FilterSettings,
+	}
+}
+
+// filtersV5ToV4 converts the filter list of a 5.x event.
+func filtersV5ToV4(value json.RawMessage) (json.RawMessage, error) {
+	var filters []filterV5
+	if err := json.Unmarshal(value, &filters); err != nil {
+		return nil, err
+	}
+	res := make([]Filter, 0, len(filters))
+	for _, f := range filters {
+		res = append(res, f.filter())
+	}
+	return json.Marshal(res)
+}
+
+type GetSourceFiltersResponse struct {
+	Filters []Filter `json:"filters"`
+	responseBase
+}
+
+func (r *GetSourceFiltersResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		Filters []filterV5 `json:"filters"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Filters = make([]Filter, 0, len(aux.Filters))
+	for _, f := range aux.Filters {
+		r.Filters = append(r.Filters, f.filter())
+	}
+	return nil
+}
+
+type GetSourceFilterInfoResponse struct {
+	Filter
+	responseBase
+
+	name string
+}
+
+func (r *GetSourceFilterInfoResponse) unmarshalV5(data []byte) error {
+	var aux filterV5
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Filter = aux.filter()
+	// the name is not part of the 5.x response
+	r.Name = r.name
+	return nil
+}
+
+// filterRequestBase holds the parameters of every filter request.
+type filterRequestBase struct {
+	requestBase
+	SourceName string `json:"sourceName"`
+	FilterName string `json:"filterName"`
+}
+
+func newFilterRequestBase(name, v5Name, sourceName, filterName string, resp response) filterRequestBase {
+	return filterRequestBase{
+		requestBase: requestBase{
+			RequestType: name,
+			rType:       resp,
+			v5Type:      v5Name,
+			v5Data: map[string]interface{}{
+				"sourceName": sourceName,
+				"filterName": filterName,
+			},
+		},
+		SourceName: sourceName,
+		FilterName: filterName,
+	}
+}
+
+// setV5Param adds a parameter to the requestData of the 5.x request.
+func (r *filterRequestBase) setV5Param(name string, value interface{}) {
+	r.v5Data.(map[string]interface{})[name] = value
+}
+
+func forgeGetSourceFilters(sourceName string) request {
+	type getSourceFilters struct {
+		requestBase
+		SourceName string `json:"sourceName"`
+	}
+	return &getSourceFilters{
+		requestBase: requestBase{
+			RequestType: "GetSourceFilters",
+			rType:       &GetSourceFiltersResponse{},
+			v5Type:      "GetSourceFilterList",
+			v5Data:      map[string]interface{}{"sourceName": sourceName},
+		},
+		SourceName: sourceName,
+	}
+}
+
+func forgeGetSourceFilterInfo(sourceName, filterName string) request {
+	r := newFilterRequestBase("GetSourceFilterInfo", "GetSourceFilter", sourceName, filterName,
+		&GetSourceFilterInfoResponse{name: filterName})
+	return &r
+}
+
+func forgeAddFilterToSource(sourceName, filterName, filterType string, settings FilterSettings) request {
+	type addFilterToSource struct {
+		filterRequestBase
+		FilterType     string         `json:"filterType"`
+		FilterSettings FilterSettings `json:"filterSettings"`
+	}
+	r := &addFilterToSource{
+		filterRequestBase: newFilterRequestBase("AddFilterToSource", "CreateSourceFilter", sourceName, filterName, &responseBase{}),
+		FilterType:        filterType,
+		FilterSettings:    settings,
+	}
+	r.setV5Param("filterKind", filterType)
+	r.setV5Param("filterSettings", settings)
+	return r
+}
+
+func forgeRemoveFilterFromSource(sourceName, filterName string) request {
+	r := newFilterRequestBase("RemoveFilterFromSource", "RemoveSourceFilter", sourceName, filterName, &responseBase{})
+	return &r
+}
+
+func forgeReorderSourceFilter(sourceName, filterName string, newIndex int) request {
+	type reorderSourceFilter struct {
+		filterRequestBase
+		NewIndex int `json:"newIndex"`
+	}
+	r := &reorderSourceFilter{
+		filterRequestBase: newFilterRequestBase("ReorderSourceFilter", "SetSourceFilterIndex", sourceName, filterName, &responseBase{}),
+		NewIndex:          newIndex,
+	}
+	r.setV5Param("filterIndex", newIndex)
+	return r
+}
+
+func forgeSetSourceFilterSettings(sourceName, filterName string, settings FilterSettings) request {
+	type setSourceFilterSettings struct {
+		filterRequestBase
+		FilterSettings FilterSettings `json:"filterSettings"`
+	}
+	r := &setSourceFilterSettings{
+		filterRequestBase: newFilterRequestBase("SetSourceFilterSettings", "SetSourceFilterSettings", sourceName, filterName, &responseBase{}),
+		FilterSettings:    settings,
+	}
+	r.setV5Param("filterSettings", settings)
+	return r
+}
+
+func forgeSetSourceFilterVisibility(sourceName, filterName string, enabled bool) request {
+	type setSourceFilterVisibility struct {
+		filterRequestBase
+		FilterEnabled bool `json:"filterEnabled"`
+	}
+	r := &setSourceFilterVisibility{
+		filterRequestBase: newFilterRequestBase("SetSourceFilterVisibility", "SetSourceFilterEnabled", sourceName, filterName, &responseBase{}),
+		FilterEnabled:     enabled,
+	}
+	r.setV5Param("filterEnabled", enabled)
+	return r
+}
+
+// GetSourceFilters returns the filters of the source, in order.
+func (c *Client) GetSourceFilters(sourceName string) (*GetSourceFiltersResponse, error) {
+	return c.GetSourceFiltersCtx(context.Background(), sourceName)
+}
+
+func (c *Client) GetSourceFiltersCtx(ctx context.Context, sourceName string) (*GetSourceFiltersResponse, error) {
+	r := forgeGetSourceFilters(sourceName)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.responseType().(*GetSourceFiltersResponse), nil
+}
+
+// GetSourceFilterInfo returns the filter of the source.
+func (c *Client) GetSourceFilterInfo(sourceName, filterName string) (*GetSourceFilterInfoResponse, error) {
+	return c.GetSourceFilterInfoCtx(context.Background(), sourceName, filterName)
+}
+
+func (c *Client) GetSourceFilterInfoCtx(ctx context.Context, sourceName, filterName string) (*GetSourceFilterInfoResponse, error) {
+	r := forgeGetSourceFilterInfo(sourceName, filterName)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.responseType().(*GetSourceFilterInfoResponse), nil
+}
+
+// AddFilterToSource adds a filter of the given type, like
+// "color_filter", to the source.
+func (c *Client) AddFilterToSource(sourceName, filterName, filterType string, settings FilterSettings) error {
+	return c.AddFilterToSourceCtx(context.Background(), sourceName, filterName, filterType, settings)
+}
+
+func (c *Client) AddFilterToSourceCtx(ctx context.Context, sourceName, filterName, filterType string, settings FilterSettings) error {
+	_, err := c.submitRequestCtx(ctx, forgeAddFilterToSource(sourceName, filterName, filterType, settings))
+	return err
+}
+
+// RemoveFilterFromSource removes the filter from the source.
+func (c *Client) RemoveFilterFromSource(sourceName, filterName string) error {
+	return c.RemoveFilterFromSourceCtx(context.Background(), sourceName, filterName)
+}
+
+func (c *Client) RemoveFilterFromSourceCtx(ctx context.Context, sourceName, filterName string) error {
+	_, err := c.submitRequestCtx(ctx, forgeRemoveFilterFromSource(sourceName, filterName))
+	return err
+}
+
+// ReorderSourceFilter moves the filter of the source to newIndex,
+// starting from 0.
+func (c *Client) ReorderSourceFilter(sourceName, filterName string, newIndex int) error {
+	return c.ReorderSourceFilterCtx(context.Background(), sourceName, filterName, newIndex)
+}
+
+func (c *Client) ReorderSourceFilterCtx(ctx context.Context, sourceName, filterName string, newIndex int) error {
+	_, err := c.submitRequestCtx(ctx, forgeReorderSourceFilter(sourceName, filterName, newIndex))
+	return err
+}
+
+// SetSourceFilterSettings merges settings into the settings of the
+// filter.
+func (c *Client) SetSourceFilterSettings(sourceName, filterName string, settings FilterSettings) error {
+	return c.SetSourceFilterSettingsCtx(context.Background(), sourceName, filterName, settings)
+}
+
+func (c *Client) SetSourceFilterSettingsCtx(ctx context.Context, sourceName, filterName string, settings FilterSettings) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetSourceFilterSettings(sourceName, filterName, settings))
+	return err
+}
+
+// SetSourceFilterVisibility enables or disables the filter.
+func (c *Client) SetSourceFilterVisibility(sourceName, filterName string, enabled bool) error {
+	return c.SetSourceFilterVisibilityCtx(context.Background(), sourceName, filterName, enabled)
+}
+
+func (c *Client) SetSourceFilterVisibilityCtx(ctx context.Context, sourceName, filterName string, enabled bool) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetSourceFilterVisibility(sourceName, filterName, enabled))
+	return err
+}
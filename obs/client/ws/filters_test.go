@@ -0,0 +1,120 @@
+package ws
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type FilterSuite struct{}
+
+var _ = Suite(&FilterSuite{})
+
+func (s *FilterSuite) TestFilterRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetSourceFilters("Cam")
+			},
+			request:  `{"request-type":"GetSourceFilters","sourceName":"Cam"}`,
+			response: `{"filters":[{"enabled":true,"type":"color_filter","name":"Grade","settings":{"saturation":-0.5}},{"enabled":false,"type":"chroma_key_filter","name":"Green","settings":{}}]}`,
+			expected: &GetSourceFiltersResponse{
+				Filters: []Filter{
+					{Enabled: true, Type: "color_filter", Name: "Grade", Settings: FilterSettings{"saturation": -0.5}},
+					{Type: "chroma_key_filter", Name: "Green", Settings: FilterSettings{}},
+				},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetSourceFilterInfo("Cam", "Grade")
+			},
+			request:  `{"request-type":"GetSourceFilterInfo","sourceName":"Cam","filterName":"Grade"}`,
+			response: `{"enabled":true,"type":"color_filter","name":"Grade","settings":{"saturation":-0.5}}`,
+			expected: &GetSourceFilterInfoResponse{
+				Filter:       Filter{Enabled: true, Type: "color_filter", Name: "Grade", Settings: FilterSettings{"saturation": -0.5}},
+				responseBase: recordedOK,
+				name:         "Grade",
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.AddFilterToSource("Cam", "Blur", "streamfx-filter-blur", FilterSettings{"Size": 5})
+			},
+			request:  `{"request-type":"AddFilterToSource","sourceName":"Cam","filterName":"Blur","filterType":"streamfx-filter-blur","filterSettings":{"Size":5}}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.RemoveFilterFromSource("Cam", "Blur")
+			},
+			request:  `{"request-type":"RemoveFilterFromSource","sourceName":"Cam","filterName":"Blur"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.ReorderSourceFilter("Cam", "Grade", 1)
+			},
+			request:  `{"request-type":"ReorderSourceFilter","sourceName":"Cam","filterName":"Grade","newIndex":1}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetSourceFilterSettings("Cam", "Grade", FilterSettings{"saturation": 0})
+			},
+			request:  `{"request-type":"SetSourceFilterSettings","sourceName":"Cam","filterName":"Grade","filterSettings":{"saturation":0}}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetSourceFilterVisibility("Cam", "Green", true)
+			},
+			request:  `{"request-type":"SetSourceFilterVisibility","sourceName":"Cam","filterName":"Green","filterEnabled":true}`,
+			response: `{}`,
+		},
+	})
+}
+
+func (s *FilterSuite) TestFilterRequestsV5(c *C) {
+	msg, err := protocolV5{}.marshalRequest(forgeReorderSourceFilter("Cam", "Grade", 1), "1")
+	c.Assert(err, IsNil)
+	c.Check(msg, DeepEquals, outgoingMessageV5{
+		Op: opRequest,
+		D: requestV5{
+			RequestType: "SetSourceFilterIndex",
+			RequestID:   "1",
+			RequestData: map[string]interface{}{"sourceName": "Cam", "filterName": "Grade", "filterIndex": 1},
+		},
+	})
+
+	info := &GetSourceFilterInfoResponse{name: "Grade"}
+	c.Assert(info.unmarshalV5([]byte(`{"filterEnabled":true,"filterIndex":0,"filterKind":"color_filter","filterSettings":{"saturation":-0.5}}`)), IsNil)
+	c.Check(info.Filter, DeepEquals, Filter{Enabled: true, Type: "color_filter", Name: "Grade", Settings: FilterSettings{"saturation": -0.5}})
+}
+
+func (s *FilterSuite) TestFilterEventsV5(c *C) {
+	tdata := map[string]Event{
+		`{"eventType":"SourceFilterCreated","eventIntent":32,"eventData":{"sourceName":"Cam","filterName":"Blur","filterKind":"streamfx-filter-blur","filterIndex":2,"filterSettings":{"Size":5},"defaultFilterSettings":{}}}`: &EventSourceFilterAdded{
+			SourceName:     "Cam",
+			FilterName:     "Blur",
+			FilterType:     "streamfx-filter-blur",
+			FilterSettings: FilterSettings{"Size": 5.0},
+			rawEvent:       newRawEvent("SourceFilterAdded"),
+		},
+		`{"eventType":"SourceFilterEnableStateChanged","eventIntent":32,"eventData":{"sourceName":"Cam","filterName":"Blur","filterEnabled":false}}`: &EventSourceFilterVisibilityChanged{
+			SourceName: "Cam",
+			FilterName: "Blur",
+			rawEvent:   newRawEvent("SourceFilterVisibilityChanged"),
+		},
+		`{"eventType":"SourceFilterListReindexed","eventIntent":32,"eventData":{"sourceName":"Cam","filters":[{"filterEnabled":true,"filterIndex":0,"filterKind":"color_filter","filterName":"Grade","filterSettings":{}}]}}`: &EventSourceFiltersReordered{
+			SourceName: "Cam",
+			Filters:    []Filter{{Enabled: true, Type: "color_filter", Name: "Grade"}},
+			rawEvent:   newRawEvent("SourceFiltersReordered"),
+		},
+	}
+	for data, expected := range tdata {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+}
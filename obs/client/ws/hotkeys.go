@@ -0,0 +1,74 @@
+package ws
+
+import (
+	"context"
+)
+
+// HotkeyModifiers are the modifier keys held while a hotkey sequence
+// is pressed.
+type HotkeyModifiers struct {
+	Shift   bool `json:"shift"`
+	Alt     bool `json:"alt"`
+	Control bool `json:"control"`
+	// Command is the macOS command key
+	Command bool `json:"command"`
+}
+
+func forgeTriggerHotkeyByName(hotkeyName string) request {
+	type triggerHotkeyByName struct {
+		requestBase
+		HotkeyName string `json:"hotkeyName"`
+	}
+	return &triggerHotkeyByName{
+		requestBase: requestBase{
+			RequestType: "TriggerHotkeyByName",
+			rType:       &responseBase{},
+			v5Type:      "TriggerHotkeyByName",
+			v5Data:      map[string]interface{}{"hotkeyName": hotkeyName},
+		},
+		HotkeyName: hotkeyName,
+	}
+}
+
+func forgeTriggerHotkeyBySequence(keyID string, modifiers HotkeyModifiers) request {
+	type triggerHotkeyBySequence struct {
+		requestBase
+		KeyID        string          `json:"keyId"`
+		KeyModifiers HotkeyModifiers `json:"keyModifiers"`
+	}
+	return &triggerHotkeyBySequence{
+		requestBase: requestBase{
+			RequestType: "TriggerHotkeyBySequence",
+			rType:       &responseBase{},
+			v5Type:      "TriggerHotkeyByKeySequence",
+			v5Data:      map[string]interface{}{"keyId": keyID, "keyModifiers": modifiers},
+		},
+		KeyID:        keyID,
+		KeyModifiers: modifiers,
+	}
+}
+
+// TriggerHotkeyByName runs the action bound to the hotkey, named as
+// in the OBS settings file, e.g. "OBSBasic.StartStreaming" or the
+// name registered by a plugin. The action runs even if no key is
+// bound to it.
+func (c *Client) TriggerHotkeyByName(hotkeyName string) error {
+	return c.TriggerHotkeyByNameCtx(context.Background(), hotkeyName)
+}
+
+func (c *Client) TriggerHotkeyByNameCtx(ctx context.Context, hotkeyName string) error {
+	_, err := c.submitRequestCtx(ctx, forgeTriggerHotkeyByName(hotkeyName))
+	return err
+}
+
+// TriggerHotkeyBySequence simulates pressing the key, identified as
+// in libobs/obs-hotkeys.h, e.g. "OBS_KEY_F5", with the modifiers. The
+// actions bound to this sequence run.
+func (c *Client) TriggerHotkeyBySequence(keyID string, modifiers HotkeyModifiers) error {
+	return c.TriggerHotkeyBySequenceCtx(context.Background(), keyID, modifiers)
+}
+
+func (c *Client) TriggerHotkeyBySequenceCtx(ctx context.Context, keyID string, modifiers HotkeyModifiers) error {
+	_, err := c.submitRequestCtx(ctx, forgeTriggerHotkeyBySequence(keyID, modifiers))
+	return err
+}
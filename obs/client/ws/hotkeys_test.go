@@ -0,0 +1,37 @@
+package ws
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type HotkeySuite struct{}
+
+var _ = Suite(&HotkeySuite{})
+
+func (s *HotkeySuite) TestHotkeyRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.TriggerHotkeyByName("OBSBasic.StartRecording")
+			},
+			request:  `{"request-type":"TriggerHotkeyByName","hotkeyName":"OBSBasic.StartRecording"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.TriggerHotkeyBySequence("OBS_KEY_F5", HotkeyModifiers{Shift: true, Control: true})
+			},
+			request:  `{"request-type":"TriggerHotkeyBySequence","keyId":"OBS_KEY_F5","keyModifiers":{"shift":true,"alt":false,"control":true,"command":false}}`,
+			response: `{}`,
+		},
+	})
+}
+
+func (s *HotkeySuite) TestHotkeyRequestsV5(c *C) {
+	requestType, data := forgeTriggerHotkeyBySequence("OBS_KEY_F5", HotkeyModifiers{Alt: true}).v5Request()
+	c.Check(requestType, Equals, "TriggerHotkeyByKeySequence")
+	c.Check(data, DeepEquals, map[string]interface{}{
+		"keyId":        "OBS_KEY_F5",
+		"keyModifiers": HotkeyModifiers{Alt: true},
+	})
+}
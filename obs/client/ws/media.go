@@ -0,0 +1,301 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// MediaSource is a media source and its playback state, see
+// GetMediaState for the states.
+type MediaSource struct {
+	SourceName string `json:"sourceName"`
+	SourceKind string `json:"sourceKind"`
+	MediaState string `json:"mediaState"`
+}
+
+type GetMediaDurationResponse struct {
+	// MediaDuration is in milliseconds
+	MediaDuration int `json:"mediaDuration"`
+	responseBase
+}
+
+func (r *GetMediaDurationResponse) unmarshalV5(data []byte) error {
+	status, err := unmarshalMediaInputStatus(data)
+	if err != nil {
+		return err
+	}
+	r.MediaDuration = status.MediaDuration
+	return nil
+}
+
+type GetMediaTimeResponse struct {
+	// Timestamp is the position of the playback, in milliseconds
+	Timestamp int `json:"timestamp"`
+	responseBase
+}
+
+func (r *GetMediaTimeResponse) unmarshalV5(data []byte) error {
+	status, err := unmarshalMediaInputStatus(data)
+	if err != nil {
+		return err
+	}
+	r.Timestamp = status.MediaCursor
+	return nil
+}
+
+type GetMediaStateResponse struct {
+	// MediaState is one of "none", "playing", "opening",
+	// "buffering", "paused", "stopped", "ended", "error" or
+	// "unknown"
+	MediaState string `json:"mediaState"`
+	responseBase
+}
+
+func (r *GetMediaStateResponse) unmarshalV5(data []byte) error {
+	status, err := unmarshalMediaInputStatus(data)
+	if err != nil {
+		return err
+	}
+	// OBS_MEDIA_STATE_PLAYING is playing in 4.x
+	r.MediaState = strings.ToLower(strings.TrimPrefix(status.MediaState, "OBS_MEDIA_STATE_"))
+	return nil
+}
+
+type GetMediaSourcesListResponse struct {
+	MediaSources []MediaSource `json:"mediaSources"`
+	responseBase
+}
+
+// mediaInputStatus is the 5.x answer to GetMediaInputStatus, which
+// replaces GetMediaDuration, GetMediaTime and GetMediaState. The
+// duration and the cursor are null when no media is loaded.
+type mediaInputStatus struct {
+	MediaState    string `json:"mediaState"`
+	MediaDuration int    `json:"mediaDuration"`
+	MediaCursor   int    `json:"mediaCursor"`
+}
+
+func unmarshalMediaInputStatus(data []byte) (mediaInputStatus, error) {
+	var status mediaInputStatus
+	err := json.Unmarshal(data, &status)
+	return status, err
+}
+
+// forgeMediaRequest forges a request whose only parameter is the
+// source name. obs-websocket 5 replaces the playback requests with
+// TriggerMediaInputAction and the given action, and the status
+// requests with GetMediaInputStatus when action is empty.
+func forgeMediaRequest(name, action, sourceName string, resp response) request {
+	type mediaRequest struct {
+		requestBase
+		SourceName string `json:"sourceName"`
+	}
+	r := &mediaRequest{
+		requestBase: requestBase{
+			RequestType: name,
+			rType:       resp,
+			v5Type:      "GetMediaInputStatus",
+			v5Data:      map[string]interface{}{"inputName": sourceName},
+		},
+		SourceName: sourceName,
+	}
+	if len(action) > 0 {
+		r.setV5Request("TriggerMediaInputAction", map[string]interface{}{
+			"inputName":   sourceName,
+			"mediaAction": "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_" + action,
+		})
+	}
+	return r
+}
+
+func forgePlayPauseMedia(sourceName string, pause bool) request {
+	type playPauseMedia struct {
+		requestBase
+		SourceName string `json:"sourceName"`
+		PlayPause  bool   `json:"playPause"`
+	}
+	action := "PLAY"
+	if pause == true {
+		action = "PAUSE"
+	}
+	return &playPauseMedia{
+		requestBase: requestBase{
+			RequestType: "PlayPauseMedia",
+			rType:       &responseBase{},
+			v5Type:      "TriggerMediaInputAction",
+			v5Data: map[string]interface{}{
+				"inputName":   sourceName,
+				"mediaAction": "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_" + action,
+			},
+		},
+		SourceName: sourceName,
+		PlayPause:  pause,
+	}
+}
+
+func forgeSetMediaTime(sourceName string, timestamp int) request {
+	type setMediaTime struct {
+		requestBase
+		SourceName string `json:"sourceName"`
+		Timestamp  int    `json:"timestamp"`
+	}
+	return &setMediaTime{
+		requestBase: requestBase{
+			RequestType: "SetMediaTime",
+			rType:       &responseBase{},
+			v5Type:      "SetMediaInputCursor",
+			v5Data:      map[string]interface{}{"inputName": sourceName, "mediaCursor": timestamp},
+		},
+		SourceName: sourceName,
+		Timestamp:  timestamp,
+	}
+}
+
+func forgeScrubMedia(sourceName string, offset int) request {
+	type scrubMedia struct {
+		requestBase
+		SourceName string `json:"sourceName"`
+		TimeOffset int    `json:"timeOffset"`
+	}
+	return &scrubMedia{
+		requestBase: requestBase{
+			RequestType: "ScrubMedia",
+			rType:       &responseBase{},
+			v5Type:      "OffsetMediaInputCursor",
+			v5Data:      map[string]interface{}{"inputName": sourceName, "mediaCursorOffset": offset},
+		},
+		SourceName: sourceName,
+		TimeOffset: offset,
+	}
+}
+
+// PlayPauseMedia pauses the media source if pause is true, and plays
+// it otherwise.
+func (c *Client) PlayPauseMedia(sourceName string, pause bool) error {
+	return c.PlayPauseMediaCtx(context.Background(), sourceName, pause)
+}
+
+func (c *Client) PlayPauseMediaCtx(ctx context.Context, sourceName string, pause bool) error {
+	_, err := c.submitRequestCtx(ctx, forgePlayPauseMedia(sourceName, pause))
+	return err
+}
+
+// RestartMedia plays the media source from the start.
+func (c *Client) RestartMedia(sourceName string) error {
+	return c.RestartMediaCtx(context.Background(), sourceName)
+}
+
+func (c *Client) RestartMediaCtx(ctx context.Context, sourceName string) error {
+	_, err := c.submitRequestCtx(ctx, forgeMediaRequest("RestartMedia", "RESTART", sourceName, &responseBase{}))
+	return err
+}
+
+// StopMedia stops the media source.
+func (c *Client) StopMedia(sourceName string) error {
+	return c.StopMediaCtx(context.Background(), sourceName)
+}
+
+func (c *Client) StopMediaCtx(ctx context.Context, sourceName string) error {
+	_, err := c.submitRequestCtx(ctx, forgeMediaRequest("StopMedia", "STOP", sourceName, &responseBase{}))
+	return err
+}
+
+// NextMedia skips to the next media of a VLC source playlist.
+func (c *Client) NextMedia(sourceName string) error {
+	return c.NextMediaCtx(context.Background(), sourceName)
+}
+
+func (c *Client) NextMediaCtx(ctx context.Context, sourceName string) error {
+	_, err := c.submitRequestCtx(ctx, forgeMediaRequest("NextMedia", "NEXT", sourceName, &responseBase{}))
+	return err
+}
+
+// PreviousMedia goes back to the previous media of a VLC source
+// playlist.
+func (c *Client) PreviousMedia(sourceName string) error {
+	return c.PreviousMediaCtx(context.Background(), sourceName)
+}
+
+func (c *Client) PreviousMediaCtx(ctx context.Context, sourceName string) error {
+	_, err := c.submitRequestCtx(ctx, forgeMediaRequest("PreviousMedia", "PREVIOUS", sourceName, &responseBase{}))
+	return err
+}
+
+// GetMediaDuration returns the duration of the media loaded in the
+// source.
+func (c *Client) GetMediaDuration(sourceName string) (*GetMediaDurationResponse, error) {
+	return c.GetMediaDurationCtx(context.Background(), sourceName)
+}
+
+func (c *Client) GetMediaDurationCtx(ctx context.Context, sourceName string) (*GetMediaDurationResponse, error) {
+	resp := &GetMediaDurationResponse{}
+	if _, err := c.submitRequestCtx(ctx, forgeMediaRequest("GetMediaDuration", "", sourceName, resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetMediaTime returns the position of the playback of the media
+// source.
+func (c *Client) GetMediaTime(sourceName string) (*GetMediaTimeResponse, error) {
+	return c.GetMediaTimeCtx(context.Background(), sourceName)
+}
+
+func (c *Client) GetMediaTimeCtx(ctx context.Context, sourceName string) (*GetMediaTimeResponse, error) {
+	resp := &GetMediaTimeResponse{}
+	if _, err := c.submitRequestCtx(ctx, forgeMediaRequest("GetMediaTime", "", sourceName, resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetMediaTime moves the playback of the media source to timestamp,
+// in milliseconds.
+func (c *Client) SetMediaTime(sourceName string, timestamp int) error {
+	return c.SetMediaTimeCtx(context.Background(), sourceName, timestamp)
+}
+
+func (c *Client) SetMediaTimeCtx(ctx context.Context, sourceName string, timestamp int) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetMediaTime(sourceName, timestamp))
+	return err
+}
+
+// ScrubMedia moves the playback of the media source by offset
+// milliseconds, backwards if negative.
+func (c *Client) ScrubMedia(sourceName string, offset int) error {
+	return c.ScrubMediaCtx(context.Background(), sourceName, offset)
+}
+
+func (c *Client) ScrubMediaCtx(ctx context.Context, sourceName string, offset int) error {
+	_, err := c.submitRequestCtx(ctx, forgeScrubMedia(sourceName, offset))
+	return err
+}
+
+// GetMediaState returns the playback state of the media source.
+func (c *Client) GetMediaState(sourceName string) (*GetMediaStateResponse, error) {
+	return c.GetMediaStateCtx(context.Background(), sourceName)
+}
+
+func (c *Client) GetMediaStateCtx(ctx context.Context, sourceName string) (*GetMediaStateResponse, error) {
+	resp := &GetMediaStateResponse{}
+	if _, err := c.submitRequestCtx(ctx, forgeMediaRequest("GetMediaState", "", sourceName, resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetMediaSourcesList lists the media sources and their playback
+// state. obs-websocket 5 has no equivalent, it fails with
+// ErrUnsupportedRequest.
+func (c *Client) GetMediaSourcesList() (*GetMediaSourcesListResponse, error) {
+	return c.GetMediaSourcesListCtx(context.Background())
+}
+
+func (c *Client) GetMediaSourcesListCtx(ctx context.Context) (*GetMediaSourcesListResponse, error) {
+	resp := &GetMediaSourcesListResponse{}
+	if _, err := c.submitRequestCtx(ctx, forgeRequestWithExpectedResponse("GetMediaSourcesList", resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
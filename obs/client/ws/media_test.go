@@ -0,0 +1,146 @@
+package ws
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type MediaSuite struct{}
+
+var _ = Suite(&MediaSuite{})
+
+func (s *MediaSuite) TestMediaRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.PlayPauseMedia("Intro", true)
+			},
+			request:  `{"request-type":"PlayPauseMedia","sourceName":"Intro","playPause":true}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.RestartMedia("Intro")
+			},
+			request:  `{"request-type":"RestartMedia","sourceName":"Intro"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.StopMedia("Intro")
+			},
+			request:  `{"request-type":"StopMedia","sourceName":"Intro"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.NextMedia("Playlist")
+			},
+			request:  `{"request-type":"NextMedia","sourceName":"Playlist"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.PreviousMedia("Playlist")
+			},
+			request:  `{"request-type":"PreviousMedia","sourceName":"Playlist"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetMediaDuration("Intro")
+			},
+			request:  `{"request-type":"GetMediaDuration","sourceName":"Intro"}`,
+			response: `{"mediaDuration":12500}`,
+			expected: &GetMediaDurationResponse{
+				MediaDuration: 12500,
+				responseBase:  recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetMediaTime("Intro")
+			},
+			request:  `{"request-type":"GetMediaTime","sourceName":"Intro"}`,
+			response: `{"timestamp":3000}`,
+			expected: &GetMediaTimeResponse{
+				Timestamp:    3000,
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetMediaTime("Intro", 5000)
+			},
+			request:  `{"request-type":"SetMediaTime","sourceName":"Intro","timestamp":5000}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.ScrubMedia("Intro", -2000)
+			},
+			request:  `{"request-type":"ScrubMedia","sourceName":"Intro","timeOffset":-2000}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetMediaState("Intro")
+			},
+			request:  `{"request-type":"GetMediaState","sourceName":"Intro"}`,
+			response: `{"mediaState":"playing"}`,
+			expected: &GetMediaStateResponse{
+				MediaState:   "playing",
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetMediaSourcesList()
+			},
+			request:  `{"request-type":"GetMediaSourcesList"}`,
+			response: `{"mediaSources":[{"sourceName":"Intro","sourceKind":"ffmpeg_source","mediaState":"ended"}]}`,
+			expected: &GetMediaSourcesListResponse{
+				MediaSources: []MediaSource{
+					{SourceName: "Intro", SourceKind: "ffmpeg_source", MediaState: "ended"},
+				},
+				responseBase: recordedOK,
+			},
+		},
+	})
+}
+
+func (s *MediaSuite) TestMediaRequestsV5(c *C) {
+	requestType, data := forgePlayPauseMedia("Intro", false).v5Request()
+	c.Check(requestType, Equals, "TriggerMediaInputAction")
+	c.Check(data, DeepEquals, map[string]interface{}{
+		"inputName":   "Intro",
+		"mediaAction": "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PLAY",
+	})
+	requestType, data = forgeMediaRequest("RestartMedia", "RESTART", "Intro", &responseBase{}).v5Request()
+	c.Check(requestType, Equals, "TriggerMediaInputAction")
+	c.Check(data, DeepEquals, map[string]interface{}{
+		"inputName":   "Intro",
+		"mediaAction": "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_RESTART",
+	})
+	requestType, _ = forgeMediaRequest("GetMediaTime", "", "Intro", &GetMediaTimeResponse{}).v5Request()
+	c.Check(requestType, Equals, "GetMediaInputStatus")
+	requestType, data = forgeScrubMedia("Intro", -2000).v5Request()
+	c.Check(requestType, Equals, "OffsetMediaInputCursor")
+	c.Check(data, DeepEquals, map[string]interface{}{"inputName": "Intro", "mediaCursorOffset": -2000})
+}
+
+func (s *MediaSuite) TestMediaResponsesV5(c *C) {
+	data := []byte(`{"mediaState":"OBS_MEDIA_STATE_PAUSED","mediaDuration":12500,"mediaCursor":3000}`)
+	duration := &GetMediaDurationResponse{}
+	c.Assert(duration.unmarshalV5(data), IsNil)
+	c.Check(duration.MediaDuration, Equals, 12500)
+	position := &GetMediaTimeResponse{}
+	c.Assert(position.unmarshalV5(data), IsNil)
+	c.Check(position.Timestamp, Equals, 3000)
+	state := &GetMediaStateResponse{}
+	c.Assert(state.unmarshalV5(data), IsNil)
+	c.Check(state.MediaState, Equals, "paused")
+
+	// nothing loaded
+	c.Assert(position.unmarshalV5([]byte(`{"mediaState":"OBS_MEDIA_STATE_NONE","mediaDuration":null,"mediaCursor":null}`)), IsNil)
+	c.Check(position.Timestamp, Equals, 0)
+}
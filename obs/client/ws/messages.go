@@ -0,0 +1,117 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CustomMessage is an envelope for the JSON payloads exchanged
+// through OBS with the other websocket clients, such as browser
+// overlays. It is sent as the data of BroadcastCustomMessage:
+//
+//	{"type": "alert", "payload": {"user": "someone"}}
+//
+// so a client knows how to decode the payload from its type.
+type CustomMessage struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewCustomMessage returns the message of the type, with payload
+// encoded to JSON.
+func NewCustomMessage(msgType string, payload interface{}) (*CustomMessage, error) {
+	m := &CustomMessage{Type: msgType}
+	if payload != nil {
+		var err error
+		if m.Payload, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Decode decodes the payload into v, like json.Unmarshal.
+func (m *CustomMessage) Decode(v interface{}) error {
+	if len(m.Payload) == 0 {
+		return fmt.Errorf("obsws: custom message '%s' has no payload", m.Type)
+	}
+	return json.Unmarshal(m.Payload, v)
+}
+
+// ErrNotCustomMessage is returned by EventBroadcastCustomMessage.Message
+// when the data of the event is not a CustomMessage.
+type ErrNotCustomMessage struct {
+	Realm string
+}
+
+func (e ErrNotCustomMessage) Error() string {
+	return fmt.Sprintf("obsws: message of realm '%s' is not a custom message", e.Realm)
+}
+
+// Message returns the CustomMessage sent as the data of the event.
+func (e *EventBroadcastCustomMessage) Message() (*CustomMessage, error) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	m := &CustomMessage{}
+	if err := json.Unmarshal(data, m); err != nil || len(m.Type) == 0 {
+		return nil, ErrNotCustomMessage{Realm: e.Realm}
+	}
+	return m, nil
+}
+
+func forgeBroadcastCustomMessage(realm string, data map[string]interface{}) request {
+	type broadcastCustomMessage struct {
+		requestBase
+		Realm string                 `json:"realm"`
+		Data  map[string]interface{} `json:"data"`
+	}
+	return &broadcastCustomMessage{
+		requestBase: requestBase{
+			RequestType: "BroadcastCustomMessage",
+			rType:       &responseBase{},
+			// 5.x events have no realm, the eventData keeps the
+			// 4.x fields for the clients to filter on it
+			v5Type: "BroadcastCustomEvent",
+			v5Data: map[string]interface{}{
+				"eventData": map[string]interface{}{"realm": realm, "data": data},
+			},
+		},
+		Realm: realm,
+		Data:  data,
+	}
+}
+
+// BroadcastCustomMessage sends data to every client of the instance,
+// this one included, in an EventBroadcastCustomMessage of the
+// realm. With obs-websocket 5 the clients receive a CustomEvent whose
+// eventData holds the realm and data fields, and only such CustomEvent
+// are understood by the client.
+func (c *Client) BroadcastCustomMessage(realm string, data map[string]interface{}) error {
+	return c.BroadcastCustomMessageCtx(context.Background(), realm, data)
+}
+
+func (c *Client) BroadcastCustomMessageCtx(ctx context.Context, realm string, data map[string]interface{}) error {
+	_, err := c.submitRequestCtx(ctx, forgeBroadcastCustomMessage(realm, data))
+	return err
+}
+
+// SendCustomMessage broadcasts the CustomMessage of the type, with
+// payload encoded to JSON, in the realm. See BroadcastCustomMessage.
+func (c *Client) SendCustomMessage(realm, msgType string, payload interface{}) error {
+	return c.SendCustomMessageCtx(context.Background(), realm, msgType, payload)
+}
+
+func (c *Client) SendCustomMessageCtx(ctx context.Context, realm, msgType string, payload interface{}) error {
+	m, err := NewCustomMessage(msgType, payload)
+	if err != nil {
+		return err
+	}
+	data := map[string]interface{}{"type": m.Type}
+	if len(m.Payload) > 0 {
+		data["payload"] = m.Payload
+	}
+	return c.BroadcastCustomMessageCtx(ctx, realm, data)
+}
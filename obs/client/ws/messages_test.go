@@ -0,0 +1,76 @@
+package ws
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type MessageSuite struct{}
+
+var _ = Suite(&MessageSuite{})
+
+type alert struct {
+	User   string `json:"user"`
+	Amount int    `json:"amount"`
+}
+
+func (s *MessageSuite) TestMessageRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.BroadcastCustomMessage("overlay", map[string]interface{}{"alert": "follow"})
+			},
+			request:  `{"request-type":"BroadcastCustomMessage","realm":"overlay","data":{"alert":"follow"}}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SendCustomMessage("overlay", "cheer", alert{User: "someone", Amount: 100})
+			},
+			request:  `{"request-type":"BroadcastCustomMessage","realm":"overlay","data":{"type":"cheer","payload":{"user":"someone","amount":100}}}`,
+			response: `{}`,
+		},
+	})
+
+	requestType, data := forgeBroadcastCustomMessage("overlay", map[string]interface{}{"alert": "follow"}).v5Request()
+	c.Check(requestType, Equals, "BroadcastCustomEvent")
+	c.Check(data, DeepEquals, map[string]interface{}{
+		"eventData": map[string]interface{}{
+			"realm": "overlay",
+			"data":  map[string]interface{}{"alert": "follow"},
+		},
+	})
+}
+
+func (s *MessageSuite) TestCustomMessage(c *C) {
+	m, err := NewCustomMessage("cheer", alert{User: "someone", Amount: 100})
+	c.Assert(err, IsNil)
+	var decoded alert
+	c.Check(m.Decode(&decoded), IsNil)
+	c.Check(decoded, Equals, alert{User: "someone", Amount: 100})
+
+	m, err = NewCustomMessage("clear", nil)
+	c.Assert(err, IsNil)
+	c.Check(m.Decode(&decoded), ErrorMatches, "obsws: custom message 'clear' has no payload")
+}
+
+func (s *MessageSuite) TestMessageEvents(c *C) {
+	v4, err := UnmarshalEvent([]byte(`{"update-type":"BroadcastCustomMessage","realm":"overlay","data":{"type":"cheer","payload":{"user":"someone","amount":100}}}`))
+	c.Assert(err, IsNil)
+	v5, err := unmarshalEventV5([]byte(`{"eventType":"CustomEvent","eventIntent":1,"eventData":{"realm":"overlay","data":{"type":"cheer","payload":{"user":"someone","amount":100}}}}`))
+	c.Assert(err, IsNil)
+	for _, ev := range []Event{v4, v5} {
+		custom, ok := ev.(*EventBroadcastCustomMessage)
+		c.Assert(ok, Equals, true)
+		c.Check(custom.Realm, Equals, "overlay")
+		m, err := custom.Message()
+		c.Assert(err, IsNil)
+		c.Check(m.Type, Equals, "cheer")
+		var decoded alert
+		c.Check(m.Decode(&decoded), IsNil)
+		c.Check(decoded, Equals, alert{User: "someone", Amount: 100})
+	}
+
+	ev := &EventBroadcastCustomMessage{Realm: "chat", Data: map[string]interface{}{"text": "hi"}}
+	_, err = ev.Message()
+	c.Check(err, Equals, ErrNotCustomMessage{Realm: "chat"})
+}
@@ -0,0 +1,464 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AlertKind is the measure of the health of the instance an Alert is
+// about.
+type AlertKind int
+
+const (
+	// AlertDroppedFrames is about the percentage of frames dropped by
+	// the stream output, usually because of the network.
+	AlertDroppedFrames AlertKind = iota
+	// AlertBitrateCollapse is about the stream bitrate falling far
+	// below its average.
+	AlertBitrateCollapse
+	// AlertRenderLag is about the percentage of frames missed by the
+	// renderer, because of the GPU.
+	AlertRenderLag
+	// AlertCPUUsage is about the CPU usage of OBS.
+	AlertCPUUsage
+)
+
+var alertKindNames = map[AlertKind]string{
+	AlertDroppedFrames:   "dropped frames",
+	AlertBitrateCollapse: "bitrate collapse",
+	AlertRenderLag:       "render lag",
+	AlertCPUUsage:        "CPU usage",
+}
+
+func (k AlertKind) String() string {
+	if name, ok := alertKindNames[k]; ok == true {
+		return name
+	}
+	return fmt.Sprintf("AlertKind(%d)", int(k))
+}
+
+// Alert is raised by a Monitor when a measure crosses its threshold,
+// and raised again with Resolved set once it is back to normal.
+type Alert struct {
+	Kind     AlertKind
+	Resolved bool
+	// Value is the measure and Threshold the limit it crossed, in
+	// percent for dropped frames, render lag and CPU usage, and in
+	// kbit/s for the bitrate
+	Value     float64
+	Threshold float64
+	Time      time.Time
+}
+
+func (a Alert) String() string {
+	if a.Resolved == true {
+		return fmt.Sprintf("%s resolved: %.1f (threshold %.1f)", a.Kind, a.Value, a.Threshold)
+	}
+	return fmt.Sprintf("%s: %.1f (threshold %.1f)", a.Kind, a.Value, a.Threshold)
+}
+
+// MonitorThresholds are the limits over which a Monitor raises an
+// Alert. A negative threshold disables its alert.
+type MonitorThresholds struct {
+	// DroppedFrames is the percentage of frames dropped by the stream
+	// output over the window
+	DroppedFrames float64
+	// BitrateCollapse is the ratio of the last bitrate to the average
+	// bitrate of the window under which the bitrate collapsed
+	BitrateCollapse float64
+	// RenderLag is the percentage of frames missed by the renderer
+	// over the window
+	RenderLag float64
+	// CPUUsage is the average CPU usage over the window, in percent
+	CPUUsage float64
+}
+
+// MonitorConfig configures a Monitor. Zero fields take their value
+// in DefaultMonitorConfig.
+type MonitorConfig struct {
+	// Window is the duration over which the measures are aggregated
+	Window time.Duration
+	// PollInterval is the interval between the GetStats, and
+	// GetOutputInfo of StreamOutput, requests. A negative interval
+	// disables polling.
+	PollInterval time.Duration
+	// StreamOutput is the output polled with GetOutputInfo, which is
+	// needed with obs-websocket 5 as it sends no StreamStatus
+	// events. When empty only the StreamStatus events are used.
+	StreamOutput string
+	Thresholds   MonitorThresholds
+}
+
+// DefaultMonitorConfig is used for the zero fields of the
+// MonitorConfig given to NewMonitor.
+var DefaultMonitorConfig = MonitorConfig{
+	Window:       30 * time.Second,
+	PollInterval: 5 * time.Second,
+	Thresholds: MonitorThresholds{
+		DroppedFrames:   5,
+		BitrateCollapse: 0.5,
+		RenderLag:       5,
+		CPUUsage:        90,
+	},
+}
+
+func (conf MonitorConfig) withDefaults() MonitorConfig {
+	if conf.Window <= 0 {
+		conf.Window = DefaultMonitorConfig.Window
+	}
+	if conf.PollInterval == 0 {
+		conf.PollInterval = DefaultMonitorConfig.PollInterval
+	}
+	t, d := &conf.Thresholds, DefaultMonitorConfig.Thresholds
+	if t.DroppedFrames == 0 {
+		t.DroppedFrames = d.DroppedFrames
+	}
+	if t.BitrateCollapse == 0 {
+		t.BitrateCollapse = d.BitrateCollapse
+	}
+	if t.RenderLag == 0 {
+		t.RenderLag = d.RenderLag
+	}
+	if t.CPUUsage == 0 {
+		t.CPUUsage = d.CPUUsage
+	}
+	return conf
+}
+
+// Health holds the measures of a Monitor over its window. A measure
+// is zero until enough samples were received.
+type Health struct {
+	// Streaming is set once the stream output reported its state
+	Streaming bool
+	// DroppedFrames is in percent
+	DroppedFrames float64
+	// KBitsPerSec is the last bitrate of the stream
+	KBitsPerSec        float64
+	AverageKBitsPerSec float64
+	// RenderLag is in percent
+	RenderLag float64
+	// CPUUsage is in percent
+	CPUUsage float64
+}
+
+// outputSample is a measure of the stream output. kbitsPerSec is only
+// known for the StreamStatus events, and the polls following a first
+// one.
+type outputSample struct {
+	time          time.Time
+	totalFrames   int
+	droppedFrames int
+	bytes         int64
+	kbitsPerSec   float64
+	hasBitrate    bool
+}
+
+type statsSample struct {
+	time         time.Time
+	renderTotal  int
+	renderMissed int
+	cpuUsage     float64
+}
+
+// Monitor watches the health of an OBS instance: it aggregates the
+// StreamStatus and Heartbeat events and the GetStats and
+// GetOutputInfo responses over a rolling window, and raises an Alert
+// when a measure crosses its threshold. The Heartbeat events only hold
+// statistics with the 4.x protocol, once enabled with SetHeartbeat.
+type Monitor struct {
+	client *Client
+	conf   MonitorConfig
+	alert  func(Alert)
+	// cancel stops the subscription to the events of client, and
+	// stopPoll cancels the requests of pollCtx
+	cancel   func()
+	stopPoll context.CancelFunc
+	pollCtx  context.Context
+	done     chan struct{}
+	// lastPolled is the previous sample of StreamOutput, only used
+	// by the run goroutine
+	lastPolled *outputSample
+
+	// guarded by lock
+	lock    sync.Mutex
+	outputs []outputSample
+	stats   []statsSample
+	active  map[AlertKind]bool
+}
+
+// NewMonitor watches the instance of client until Close is called or
+// client is closed. alert is called with the alerts from a single
+// goroutine, and should not block for long.
+func NewMonitor(client *Client, conf MonitorConfig, alert func(Alert)) *Monitor {
+	m := newMonitor(client, conf, alert)
+	var events <-chan Event
+	events, m.cancel = client.Subscribe("StreamStatus", "StreamStopped", "Heartbeat")
+	go m.run(events)
+	return m
+}
+
+func newMonitor(client *Client, conf MonitorConfig, alert func(Alert)) *Monitor {
+	m := &Monitor{
+		client: client,
+		conf:   conf.withDefaults(),
+		alert:  alert,
+		done:   make(chan struct{}),
+		active: make(map[AlertKind]bool),
+	}
+	m.pollCtx, m.stopPoll = context.WithCancel(context.Background())
+	return m
+}
+
+// Close stops watching the instance.
+func (m *Monitor) Close() {
+	m.stopPoll()
+	m.cancel()
+	<-m.done
+}
+
+func (m *Monitor) run(events <-chan Event) {
+	defer close(m.done)
+	var tick <-chan time.Time
+	if m.conf.PollInterval > 0 {
+		ticker := time.NewTicker(m.conf.PollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case ev, ok := <-events:
+			if ok == false {
+				return
+			}
+			m.handleEvent(ev, time.Now())
+		case <-tick:
+			m.poll()
+		}
+	}
+}
+
+func (m *Monitor) handleEvent(ev Event, now time.Time) {
+	switch e := ev.(type) {
+	case *EventStreamStatus:
+		if e.Streaming == false {
+			m.resetOutput(now)
+			return
+		}
+		m.addOutput(outputSample{
+			time:          now,
+			totalFrames:   e.NumTotalFrames,
+			droppedFrames: e.NumDroppedFrames,
+			kbitsPerSec:   float64(e.KBitsPerSec),
+			hasBitrate:    true,
+		})
+	case *EventStreamStopped:
+		m.resetOutput(now)
+	case *EventHeartbeat:
+		// the statistics are only sent with some heartbeats
+		if e.Stats.RenderTotalFrames > 0 {
+			m.addStats(statsSample{
+				time:         now,
+				renderTotal:  e.Stats.RenderTotalFrames,
+				renderMissed: e.Stats.RenderMissedFrames,
+				cpuUsage:     e.Stats.CPUUsage,
+			})
+		}
+	}
+}
+
+// poll requests the statistics, and the state of the stream output.
+// The errors are reported on the Errors channel of the Client.
+func (m *Monitor) poll() {
+	stats, err := m.client.GetStatsCtx(m.pollCtx)
+	if err != nil {
+		m.reportError(err)
+		return
+	}
+	now := time.Now()
+	m.addStats(statsSample{
+		time:         now,
+		renderTotal:  stats.Stats.RenderTotalFrames,
+		renderMissed: stats.Stats.RenderMissedFrames,
+		cpuUsage:     stats.Stats.CPUUsage,
+	})
+
+	if len(m.conf.StreamOutput) == 0 {
+		return
+	}
+	output, err := m.client.GetOutputInfoCtx(m.pollCtx, m.conf.StreamOutput)
+	if err != nil {
+		m.reportError(err)
+		return
+	}
+	m.addPolledOutput(output.OutputInfo, time.Now())
+}
+
+// reportError reports a failure of the poll, unless the monitor or
+// the client was closed.
+func (m *Monitor) reportError(err error) {
+	if _, ok := err.(ErrClosed); ok == true || m.pollCtx.Err() != nil {
+		return
+	}
+	m.client.reportError(err)
+}
+
+// addPolledOutput adds a sample of the output, whose bitrate is
+// computed from the previous poll.
+func (m *Monitor) addPolledOutput(info Output, now time.Time) {
+	if info.Active == false {
+		m.lastPolled = nil
+		m.resetOutput(now)
+		return
+	}
+	sample := outputSample{
+		time:          now,
+		totalFrames:   info.TotalFrames,
+		droppedFrames: info.DroppedFrames,
+		bytes:         info.TotalBytes,
+	}
+	if last := m.lastPolled; last != nil && sample.bytes >= last.bytes && now.After(last.time) {
+		seconds := now.Sub(last.time).Seconds()
+		sample.kbitsPerSec = float64(sample.bytes-last.bytes) * 8 / 1000 / seconds
+		sample.hasBitrate = true
+	}
+	m.lastPolled = &sample
+	m.addOutput(sample)
+}
+
+func (m *Monitor) addOutput(sample outputSample) {
+	m.lock.Lock()
+	if n := len(m.outputs); n > 0 && sample.totalFrames < m.outputs[n-1].totalFrames {
+		// a new stream started
+		m.outputs = nil
+	}
+	m.outputs = append(pruneOutputs(m.outputs, sample.time.Add(-m.conf.Window)), sample)
+	alerts := m.evaluate(sample.time)
+	m.lock.Unlock()
+	m.raise(alerts)
+}
+
+func (m *Monitor) addStats(sample statsSample) {
+	m.lock.Lock()
+	if n := len(m.stats); n > 0 && sample.renderTotal < m.stats[n-1].renderTotal {
+		// OBS restarted
+		m.stats = nil
+	}
+	m.stats = append(pruneStats(m.stats, sample.time.Add(-m.conf.Window)), sample)
+	alerts := m.evaluate(sample.time)
+	m.lock.Unlock()
+	m.raise(alerts)
+}
+
+// resetOutput forgets the samples of the stream output once it
+// stopped, and resolves their alerts.
+func (m *Monitor) resetOutput(now time.Time) {
+	m.lock.Lock()
+	m.outputs = nil
+	var alerts []Alert
+	for _, kind := range []AlertKind{AlertDroppedFrames, AlertBitrateCollapse} {
+		if m.active[kind] == true {
+			m.active[kind] = false
+			alerts = append(alerts, Alert{Kind: kind, Resolved: true, Time: now})
+		}
+	}
+	m.lock.Unlock()
+	m.raise(alerts)
+}
+
+func (m *Monitor) raise(alerts []Alert) {
+	for _, a := range alerts {
+		m.alert(a)
+	}
+}
+
+func pruneOutputs(samples []outputSample, since time.Time) []outputSample {
+	for len(samples) > 0 && samples[0].time.Before(since) {
+		samples = samples[1:]
+	}
+	return samples
+}
+
+func pruneStats(samples []statsSample, since time.Time) []statsSample {
+	for len(samples) > 0 && samples[0].time.Before(since) {
+		samples = samples[1:]
+	}
+	return samples
+}
+
+// health computes the measures over the window. m.lock must be held.
+func (m *Monitor) health() Health {
+	var h Health
+	if n := len(m.outputs); n > 0 {
+		h.Streaming = true
+		first, last := m.outputs[0], m.outputs[n-1]
+		h.DroppedFrames = percent(last.droppedFrames-first.droppedFrames, last.totalFrames-first.totalFrames)
+		count := 0
+		for _, s := range m.outputs {
+			if s.hasBitrate == true {
+				h.KBitsPerSec = s.kbitsPerSec
+				h.AverageKBitsPerSec += s.kbitsPerSec
+				count++
+			}
+		}
+		if count > 0 {
+			h.AverageKBitsPerSec /= float64(count)
+		}
+	}
+	if n := len(m.stats); n > 0 {
+		first, last := m.stats[0], m.stats[n-1]
+		h.RenderLag = percent(last.renderMissed-first.renderMissed, last.renderTotal-first.renderTotal)
+		for _, s := range m.stats {
+			h.CPUUsage += s.cpuUsage
+		}
+		h.CPUUsage /= float64(n)
+	}
+	return h
+}
+
+func percent(part, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return 100 * float64(part) / float64(total)
+}
+
+// evaluate returns the alerts raised or resolved by the current
+// measures. m.lock must be held.
+func (m *Monitor) evaluate(now time.Time) []Alert {
+	h := m.health()
+	t := m.conf.Thresholds
+	var alerts []Alert
+	check := func(kind AlertKind, known bool, value, threshold float64, crossed bool) {
+		if known == false || threshold < 0 || crossed == m.active[kind] {
+			return
+		}
+		m.active[kind] = crossed
+		alerts = append(alerts, Alert{
+			Kind:      kind,
+			Resolved:  crossed == false,
+			Value:     value,
+			Threshold: threshold,
+			Time:      now,
+		})
+	}
+
+	check(AlertDroppedFrames, len(m.outputs) > 1, h.DroppedFrames, t.DroppedFrames,
+		h.DroppedFrames > t.DroppedFrames)
+	if t.BitrateCollapse >= 0 {
+		floor := t.BitrateCollapse * h.AverageKBitsPerSec
+		check(AlertBitrateCollapse, h.AverageKBitsPerSec > 0, h.KBitsPerSec, floor,
+			h.KBitsPerSec < floor)
+	}
+	check(AlertRenderLag, len(m.stats) > 1, h.RenderLag, t.RenderLag, h.RenderLag > t.RenderLag)
+	check(AlertCPUUsage, len(m.stats) > 0, h.CPUUsage, t.CPUUsage, h.CPUUsage > t.CPUUsage)
+	return alerts
+}
+
+// Health returns the current measures.
+func (m *Monitor) Health() Health {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.health()
+}
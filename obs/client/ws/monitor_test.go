@@ -0,0 +1,185 @@
+package ws
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type MonitorSuite struct{}
+
+var _ = Suite(&MonitorSuite{})
+
+// newTestMonitor returns a Monitor which is not running, and the
+// alerts it raised so far.
+func newTestMonitor(conf MonitorConfig) (*Monitor, *[]Alert) {
+	var alerts []Alert
+	m := newMonitor(nil, conf, func(a Alert) {
+		alerts = append(alerts, a)
+	})
+	return m, &alerts
+}
+
+func (s *MonitorSuite) TestDroppedFrames(c *C) {
+	m, alerts := newTestMonitor(MonitorConfig{Window: 10 * time.Second})
+	start := time.Now()
+	status := func(at time.Duration, total, dropped, kbits int) {
+		m.handleEvent(&EventStreamStatus{
+			Streaming:        true,
+			KBitsPerSec:      kbits,
+			NumTotalFrames:   total,
+			NumDroppedFrames: dropped,
+		}, start.Add(at))
+	}
+
+	status(0, 1000, 0, 6000)
+	status(2*time.Second, 1120, 0, 6000)
+	c.Check(*alerts, HasLen, 0)
+	status(4*time.Second, 1240, 24, 6000)
+	c.Assert(*alerts, HasLen, 1)
+	c.Check((*alerts)[0].Kind, Equals, AlertDroppedFrames)
+	c.Check((*alerts)[0].Resolved, Equals, false)
+	c.Check((*alerts)[0].Value, Equals, 10.0)
+	c.Check((*alerts)[0].String(), Equals, "dropped frames: 10.0 (threshold 5.0)")
+
+	// the drops leave the window
+	status(13*time.Second, 1780, 24, 6000)
+	status(16*time.Second, 1960, 24, 6000)
+	c.Assert(*alerts, HasLen, 2)
+	c.Check((*alerts)[1].Kind, Equals, AlertDroppedFrames)
+	c.Check((*alerts)[1].Resolved, Equals, true)
+
+	health := m.Health()
+	c.Check(health.Streaming, Equals, true)
+	c.Check(health.DroppedFrames, Equals, 0.0)
+	c.Check(health.KBitsPerSec, Equals, 6000.0)
+}
+
+func (s *MonitorSuite) TestBitrateCollapse(c *C) {
+	m, alerts := newTestMonitor(MonitorConfig{})
+	start := time.Now()
+	for i, kbits := range []int{6000, 6000, 6000, 1000} {
+		m.handleEvent(&EventStreamStatus{
+			Streaming:      true,
+			KBitsPerSec:    kbits,
+			NumTotalFrames: 120 * i,
+		}, start.Add(time.Duration(i)*2*time.Second))
+	}
+	c.Assert(*alerts, HasLen, 1)
+	c.Check((*alerts)[0].Kind, Equals, AlertBitrateCollapse)
+	c.Check((*alerts)[0].Value, Equals, 1000.0)
+	c.Check((*alerts)[0].Threshold, Equals, 2375.0)
+
+	// stopping the stream resolves its alerts
+	m.handleEvent(&EventStreamStopped{}, start.Add(10*time.Second))
+	c.Assert(*alerts, HasLen, 2)
+	c.Check((*alerts)[1].Kind, Equals, AlertBitrateCollapse)
+	c.Check((*alerts)[1].Resolved, Equals, true)
+	c.Check(m.Health().Streaming, Equals, false)
+}
+
+func (s *MonitorSuite) TestPolledOutput(c *C) {
+	m, alerts := newTestMonitor(MonitorConfig{Thresholds: MonitorThresholds{DroppedFrames: -1}})
+	start := time.Now()
+	m.addPolledOutput(Output{Active: true, TotalFrames: 0, TotalBytes: 0}, start)
+	m.addPolledOutput(Output{Active: true, TotalFrames: 300, DroppedFrames: 100, TotalBytes: 3750000}, start.Add(5*time.Second))
+	health := m.Health()
+	c.Check(health.KBitsPerSec, Equals, 6000.0)
+	c.Check(health.DroppedFrames > 30, Equals, true)
+	// disabled
+	c.Check(*alerts, HasLen, 0)
+}
+
+func (s *MonitorSuite) TestStats(c *C) {
+	m, alerts := newTestMonitor(MonitorConfig{Thresholds: MonitorThresholds{CPUUsage: 80}})
+	start := time.Now()
+	m.handleEvent(&EventHeartbeat{Stats: OBSStats{RenderTotalFrames: 600, CPUUsage: 70}}, start)
+	m.handleEvent(&EventHeartbeat{Pulse: true}, start.Add(time.Second))
+	m.handleEvent(&EventHeartbeat{Stats: OBSStats{RenderTotalFrames: 720, RenderMissedFrames: 12, CPUUsage: 95}}, start.Add(2*time.Second))
+	c.Assert(*alerts, HasLen, 2)
+	c.Check((*alerts)[0].Kind, Equals, AlertRenderLag)
+	c.Check((*alerts)[0].Value, Equals, 10.0)
+	c.Check((*alerts)[1].Kind, Equals, AlertCPUUsage)
+	c.Check((*alerts)[1].Value, Equals, 82.5)
+}
+
+// newPolledServer returns a server answering the polled requests with
+// the responses, and the types of the requests answered.
+func newPolledServer(responses map[string]map[string]interface{}) (*wstest.Server, <-chan string) {
+	server := wstest.NewServer()
+	requests := make(chan string, 16)
+	for requestType, resp := range responses {
+		requestType, resp := requestType, resp
+		server.Handle(requestType, func(map[string]interface{}) (map[string]interface{}, error) {
+			select {
+			case requests <- requestType:
+			default:
+			}
+			return resp, nil
+		})
+	}
+	return server, requests
+}
+
+func (s *MonitorSuite) TestPoll(c *C) {
+	server, requests := newPolledServer(map[string]map[string]interface{}{
+		"GetStats": {"stats": map[string]interface{}{"fps": 60, "render-total-frames": 600, "cpu-usage": 99}},
+		"GetOutputInfo": {"outputInfo": map[string]interface{}{
+			"name": "simple_stream", "active": true, "totalFrames": 600, "droppedFrames": 0, "totalBytes": 1000,
+		}},
+	})
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	alerts := make(chan Alert, 8)
+	m := NewMonitor(client, MonitorConfig{
+		PollInterval: 20 * time.Millisecond,
+		StreamOutput: "simple_stream",
+	}, func(a Alert) {
+		alerts <- a
+	})
+
+	select {
+	case a := <-alerts:
+		c.Check(a.Kind, Equals, AlertCPUUsage)
+		c.Check(a.Value, Equals, 99.0)
+	case <-time.After(time.Second):
+		c.Fatal("no alert raised")
+	}
+	// the poll goes on after the alert, until the monitor is closed
+	for _, expected := range []string{"GetStats", "GetOutputInfo"} {
+		select {
+		case requestType := <-requests:
+			c.Check(requestType, Equals, expected)
+		case <-time.After(time.Second):
+			c.Fatalf("%s not requested", expected)
+		}
+	}
+	m.Close()
+}
+
+func (s *MonitorSuite) TestPollAfterClose(c *C) {
+	server, _ := newPolledServer(map[string]map[string]interface{}{
+		"GetStats": {"stats": map[string]interface{}{"fps": 60}},
+	})
+	defer server.Close()
+
+	client := newServerClient(c, server)
+	monitors := make([]*Monitor, 8)
+	for i := range monitors {
+		monitors[i] = NewMonitor(client, MonitorConfig{PollInterval: time.Microsecond}, func(Alert) {})
+	}
+	time.Sleep(20 * time.Millisecond)
+	// the polls in progress fail with ErrClosed, which is not
+	// reported on the closed Errors channel
+	client.Close()
+	for _, m := range monitors {
+		m.Close()
+	}
+	for err := range client.Errors() {
+		c.Check(err, Not(FitsTypeOf), ErrClosed{})
+	}
+}
@@ -0,0 +1,225 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type Profile struct {
+	Name string `json:"profile-name"`
+}
+
+type SceneCollection struct {
+	Name string `json:"sc-name"`
+}
+
+// ListItem is an element of the lists sent by the ProfileListChanged
+// and SceneCollectionListChanged events.
+type ListItem struct {
+	Name string `json:"name"`
+}
+
+// namesToListItems converts a 5.x list of names to ListItems.
+func namesToListItems(value json.RawMessage) (json.RawMessage, error) {
+	var names []string
+	if err := json.Unmarshal(value, &names); err != nil {
+		return nil, err
+	}
+	res := make([]ListItem, 0, len(names))
+	for _, name := range names {
+		res = append(res, ListItem{Name: name})
+	}
+	return json.Marshal(res)
+}
+
+type ListProfilesResponse struct {
+	Profiles []Profile `json:"profiles"`
+	responseBase
+}
+
+func (r *ListProfilesResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		Profiles []string `json:"profiles"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Profiles = make([]Profile, 0, len(aux.Profiles))
+	for _, name := range aux.Profiles {
+		r.Profiles = append(r.Profiles, Profile{Name: name})
+	}
+	return nil
+}
+
+type GetCurrentProfileResponse struct {
+	ProfileName string `json:"profile-name"`
+	responseBase
+}
+
+func (r *GetCurrentProfileResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		CurrentProfileName string `json:"currentProfileName"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.ProfileName = aux.CurrentProfileName
+	return nil
+}
+
+type ListSceneCollectionsResponse struct {
+	SceneCollections []SceneCollection `json:"scene-collections"`
+	responseBase
+}
+
+func (r *ListSceneCollectionsResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		SceneCollections []string `json:"sceneCollections"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.SceneCollections = make([]SceneCollection, 0, len(aux.SceneCollections))
+	for _, name := range aux.SceneCollections {
+		r.SceneCollections = append(r.SceneCollections, SceneCollection{Name: name})
+	}
+	return nil
+}
+
+type GetCurrentSceneCollectionResponse struct {
+	SCName string `json:"sc-name"`
+	responseBase
+}
+
+func (r *GetCurrentSceneCollectionResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		CurrentSceneCollectionName string `json:"currentSceneCollectionName"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.SCName = aux.CurrentSceneCollectionName
+	return nil
+}
+
+func forgeSetCurrentProfile(name string) request {
+	type setCurrentProfile struct {
+		requestBase
+		ProfileName string `json:"profile-name"`
+	}
+	return &setCurrentProfile{
+		requestBase: requestBase{
+			RequestType: "SetCurrentProfile",
+			rType:       &responseBase{},
+			v5Type:      "SetCurrentProfile",
+			v5Data:      map[string]interface{}{"profileName": name},
+		},
+		ProfileName: name,
+	}
+}
+
+func forgeSetCurrentSceneCollection(name string) request {
+	type setCurrentSceneCollection struct {
+		requestBase
+		SCName string `json:"sc-name"`
+	}
+	return &setCurrentSceneCollection{
+		requestBase: requestBase{
+			RequestType: "SetCurrentSceneCollection",
+			rType:       &responseBase{},
+			v5Type:      "SetCurrentSceneCollection",
+			v5Data:      map[string]interface{}{"sceneCollectionName": name},
+		},
+		SCName: name,
+	}
+}
+
+// ListProfiles returns the available profiles.
+func (c *Client) ListProfiles() (*ListProfilesResponse, error) {
+	return c.ListProfilesCtx(context.Background())
+}
+
+func (c *Client) ListProfilesCtx(ctx context.Context) (*ListProfilesResponse, error) {
+	resp := &ListProfilesResponse{}
+	r := forgeRequestWithExpectedResponse("ListProfiles", resp)
+	r.setV5Request("GetProfileList", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetCurrentProfile returns the name of the current profile.
+func (c *Client) GetCurrentProfile() (*GetCurrentProfileResponse, error) {
+	return c.GetCurrentProfileCtx(context.Background())
+}
+
+func (c *Client) GetCurrentProfileCtx(ctx context.Context) (*GetCurrentProfileResponse, error) {
+	resp := &GetCurrentProfileResponse{}
+	r := forgeRequestWithExpectedResponse("GetCurrentProfile", resp)
+	r.setV5Request("GetProfileList", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetCurrentProfile switches to the profile. It fails while
+// streaming or recording.
+func (c *Client) SetCurrentProfile(name string) error {
+	return c.SetCurrentProfileCtx(context.Background(), name)
+}
+
+func (c *Client) SetCurrentProfileCtx(ctx context.Context, name string) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetCurrentProfile(name))
+	return err
+}
+
+// ListSceneCollections returns the available scene collections.
+func (c *Client) ListSceneCollections() (*ListSceneCollectionsResponse, error) {
+	return c.ListSceneCollectionsCtx(context.Background())
+}
+
+func (c *Client) ListSceneCollectionsCtx(ctx context.Context) (*ListSceneCollectionsResponse, error) {
+	resp := &ListSceneCollectionsResponse{}
+	r := forgeRequestWithExpectedResponse("ListSceneCollections", resp)
+	r.setV5Request("GetSceneCollectionList", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetCurrentSceneCollection returns the name of the current scene
+// collection.
+func (c *Client) GetCurrentSceneCollection() (*GetCurrentSceneCollectionResponse, error) {
+	return c.GetCurrentSceneCollectionCtx(context.Background())
+}
+
+func (c *Client) GetCurrentSceneCollectionCtx(ctx context.Context) (*GetCurrentSceneCollectionResponse, error) {
+	resp := &GetCurrentSceneCollectionResponse{}
+	r := forgeRequestWithExpectedResponse("GetCurrentSceneCollection", resp)
+	r.setV5Request("GetSceneCollectionList", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetCurrentSceneCollection switches to the scene collection. The
+// scenes and sources of the previous collection are gone once it
+// returns.
+func (c *Client) SetCurrentSceneCollection(name string) error {
+	return c.SetCurrentSceneCollectionCtx(context.Background(), name)
+}
+
+func (c *Client) SetCurrentSceneCollectionCtx(ctx context.Context, name string) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetCurrentSceneCollection(name))
+	if err == nil {
+		// the sources of the new collection may reuse names
+		c.sourceTypesLock.Lock()
+		c.sourceTypes = make(map[string]string)
+		c.sourceTypesLock.Unlock()
+	}
+	return err
+}
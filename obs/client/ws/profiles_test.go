@@ -0,0 +1,126 @@
+package ws
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ProfileSuite struct{}
+
+var _ = Suite(&ProfileSuite{})
+
+func (s *ProfileSuite) TestProfileRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.ListProfiles()
+			},
+			request:  `{"request-type":"ListProfiles"}`,
+			response: `{"profiles":[{"profile-name":"Just Chatting"},{"profile-name":"Gaming"}]}`,
+			expected: &ListProfilesResponse{
+				Profiles:     []Profile{{Name: "Just Chatting"}, {Name: "Gaming"}},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetCurrentProfile()
+			},
+			request:  `{"request-type":"GetCurrentProfile"}`,
+			response: `{"profile-name":"Gaming"}`,
+			expected: &GetCurrentProfileResponse{
+				ProfileName:  "Gaming",
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetCurrentProfile("Just Chatting")
+			},
+			request:  `{"request-type":"SetCurrentProfile","profile-name":"Just Chatting"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.ListSceneCollections()
+			},
+			request:  `{"request-type":"ListSceneCollections"}`,
+			response: `{"scene-collections":[{"sc-name":"Default"},{"sc-name":"Speedrun"}]}`,
+			expected: &ListSceneCollectionsResponse{
+				SceneCollections: []SceneCollection{{Name: "Default"}, {Name: "Speedrun"}},
+				responseBase:     recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetCurrentSceneCollection()
+			},
+			request:  `{"request-type":"GetCurrentSceneCollection"}`,
+			response: `{"sc-name":"Speedrun"}`,
+			expected: &GetCurrentSceneCollectionResponse{
+				SCName:       "Speedrun",
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetCurrentSceneCollection("Default")
+			},
+			request:  `{"request-type":"SetCurrentSceneCollection","sc-name":"Default"}`,
+			response: `{}`,
+		},
+	})
+}
+
+func (s *ProfileSuite) TestProfileResponsesV5(c *C) {
+	data := []byte(`{"currentProfileName":"Gaming","profiles":["Just Chatting","Gaming"]}`)
+	list := &ListProfilesResponse{}
+	c.Assert(list.unmarshalV5(data), IsNil)
+	c.Check(list.Profiles, DeepEquals, []Profile{{Name: "Just Chatting"}, {Name: "Gaming"}})
+	current := &GetCurrentProfileResponse{}
+	c.Assert(current.unmarshalV5(data), IsNil)
+	c.Check(current.ProfileName, Equals, "Gaming")
+
+	data = []byte(`{"currentSceneCollectionName":"Speedrun","sceneCollections":["Default","Speedrun"]}`)
+	collections := &ListSceneCollectionsResponse{}
+	c.Assert(collections.unmarshalV5(data), IsNil)
+	c.Check(collections.SceneCollections, DeepEquals, []SceneCollection{{Name: "Default"}, {Name: "Speedrun"}})
+	currentCollection := &GetCurrentSceneCollectionResponse{}
+	c.Assert(currentCollection.unmarshalV5(data), IsNil)
+	c.Check(currentCollection.SCName, Equals, "Speedrun")
+}
+
+func (s *ProfileSuite) TestProfileEvents(c *C) {
+	v4 := map[string]Event{
+		`{"update-type":"ProfileChanged","profile":"Gaming"}`: &EventProfileChanged{
+			Profile:  "Gaming",
+			rawEvent: newRawEvent("ProfileChanged"),
+		},
+		`{"update-type":"SceneCollectionListChanged","sceneCollections":[{"name":"Default"}]}`: &EventSceneCollectionListChanged{
+			SceneCollections: []ListItem{{Name: "Default"}},
+			rawEvent:         newRawEvent("SceneCollectionListChanged"),
+		},
+	}
+	for data, expected := range v4 {
+		ev, err := UnmarshalEvent([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+
+	v5 := map[string]Event{
+		`{"eventType":"ProfileListChanged","eventIntent":2,"eventData":{"profiles":["Just Chatting","Gaming"]}}`: &EventProfileListChanged{
+			Profiles: []ListItem{{Name: "Just Chatting"}, {Name: "Gaming"}},
+			rawEvent: newRawEvent("ProfileListChanged"),
+		},
+		`{"eventType":"CurrentSceneCollectionChanged","eventIntent":2,"eventData":{"sceneCollectionName":"Speedrun"}}`: &EventSceneCollectionChanged{
+			SceneCollection: "Speedrun",
+			rawEvent:        newRawEvent("SceneCollectionChanged"),
+		},
+	}
+	for data, expected := range v5 {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+}
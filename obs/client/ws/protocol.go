@@ -0,0 +1,179 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Protocol identifies an obs-websocket protocol version.
+type Protocol int
+
+const (
+	// ProtocolAuto negotiates the protocol on connection: version 5
+	// if the server greets us with a Hello message, 4.x otherwise.
+	ProtocolAuto Protocol = iota
+	// ProtocolV4 is the legacy 4.x JSON protocol.
+	ProtocolV4
+	// ProtocolV5 is the op-code based protocol shipped with OBS 28+.
+	ProtocolV5
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolAuto:
+		return "auto"
+	case ProtocolV4:
+		return "4.x"
+	case ProtocolV5:
+		return "5.x"
+	}
+	return fmt.Sprintf("Protocol(%d)", int(p))
+}
+
+// MarshalText encodes the protocol as its version, e.g. "5.x".
+func (p Protocol) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a protocol encoded by MarshalText.
+func (p *Protocol) UnmarshalText(text []byte) error {
+	for _, proto := range []Protocol{ProtocolAuto, ProtocolV4, ProtocolV5} {
+		if proto.String() == string(text) {
+			*p = proto
+			return nil
+		}
+	}
+	return fmt.Errorf("obsws: unknown protocol '%s'", text)
+}
+
+// ErrUnsupportedRequest is returned when a request has no
+// equivalent in the protocol spoken with the server.
+type ErrUnsupportedRequest struct {
+	RequestType string
+	Protocol    Protocol
+}
+
+func (e ErrUnsupportedRequest) Error() string {
+	return fmt.Sprintf("obsws: request '%s' is not supported by protocol %s", e.RequestType, e.Protocol)
+}
+
+// protocol translates requests, responses and events between the
+// client and a given version of the wire format.
+type protocol interface {
+	version() Protocol
+	// marshalRequest returns the value to send as JSON for r.
+	marshalRequest(r request, messageID string) (interface{}, error)
+	// unmarshalEvent returns ErrNotEventMessage if frame is not an
+	// event.
+	unmarshalEvent(frame []byte) (Event, error)
+	// messageID returns the id of the request frame answers to.
+	messageID(frame []byte) (string, error)
+	unmarshalResponse(frame []byte, resp response) error
+}
+
+type protocolV4 struct{}
+
+func (p protocolV4) version() Protocol {
+	return ProtocolV4
+}
+
+func (p protocolV4) marshalRequest(r request, messageID string) (interface{}, error) {
+	r.setMessageID(messageID)
+	return r, nil
+}
+
+func (p protocolV4) unmarshalEvent(frame []byte) (Event, error) {
+	return UnmarshalEvent(frame)
+}
+
+func (p protocolV4) messageID(frame []byte) (string, error) {
+	var respBase responseBase
+	if err := json.Unmarshal(frame, &respBase); err != nil {
+		return "", err
+	}
+	return respBase.messageID(), nil
+}
+
+func (p protocolV4) unmarshalResponse(frame []byte, resp response) error {
+	return json.Unmarshal(frame, resp)
+}
+
+// negotiate finds out which protocol the server speaks, and performs
+// the obs-websocket 5 identification or the 4.x authentication if
+// needed. It returns the first frame of a 4.x server if it spoke
+// first, to be handled as the first frame of the connection.
+func negotiate(conn *websocket.Conn, conf config) (protocol, []byte, error) {
+	var early []byte
+	if conf.protocol != ProtocolV4 {
+		// A 5.x server greets us with Hello, a 4.x one sends
+		// nothing or pushes its events, such as StreamStatus, even
+		// before the authentication
+		conn.SetReadDeadline(time.Now().Add(conf.helloTimeout))
+		var frame []byte
+		err := websocket.Message.Receive(conn, &frame)
+		conn.SetReadDeadline(time.Time{})
+		switch {
+		case err == nil && (conf.protocol == ProtocolV5 || isHelloV5(frame) == true):
+			if err := identify(conn, frame, conf); err != nil {
+				return nil, nil, err
+			}
+			return protocolV5{}, nil, nil
+		case err == nil:
+			early = frame
+		default:
+			if nerr, ok := err.(net.Error); ok == false || nerr.Timeout() == false || conf.protocol == ProtocolV5 {
+				return nil, nil, fmt.Errorf("obsws: did not receive Hello message: %s", err)
+			}
+		}
+	}
+
+	if len(conf.password) > 0 {
+		if err := authenticateV4(conn, conf.password); err != nil {
+			return nil, nil, err
+		}
+	}
+	return protocolV4{}, early, nil
+}
+
+// authenticateV4 performs the 4.x authentication directly on conn,
+// before the client loop reads it. Events received meanwhile are
+// dropped.
+func authenticateV4(conn *websocket.Conn, password string) error {
+	authReq := &GetAuthRequiredResponse{}
+	if err := roundTripV4(conn, forgeRequestWithExpectedResponse("GetAuthRequired", authReq), "auth-required"); err != nil {
+		return err
+	}
+	if authReq.AuthRequired == false {
+		return nil
+	}
+	return roundTripV4(conn, forgeAuthenticate(authResponse(password, authReq.Salt, authReq.Challenge)), "authenticate")
+}
+
+func roundTripV4(conn *websocket.Conn, r request, messageID string) error {
+	r.setMessageID(messageID)
+	if err := websocket.JSON.Send(conn, r); err != nil {
+		return err
+	}
+	for {
+		var frame []byte
+		if err := websocket.Message.Receive(conn, &frame); err != nil {
+			return err
+		}
+		id, err := protocolV4{}.messageID(frame)
+		if err != nil {
+			return err
+		}
+		if id != messageID {
+			continue
+		}
+		resp := r.responseType()
+		if err := json.Unmarshal(frame, resp); err != nil {
+			return err
+		}
+		return resp.error()
+	}
+}
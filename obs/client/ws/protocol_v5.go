@@ -0,0 +1,496 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+type opCode int
+
+const (
+	opHello                opCode = 0
+	opIdentify             opCode = 1
+	opIdentified           opCode = 2
+	opReidentify           opCode = 3
+	opEvent                opCode = 5
+	opRequest              opCode = 6
+	opRequestResponse      opCode = 7
+	opRequestBatch         opCode = 8
+	opRequestBatchResponse opCode = 9
+)
+
+// rpcVersionV5 is the obs-websocket 5 RPC version we implement.
+const rpcVersionV5 = 1
+
+// EventSubscription is a bitmask of the event categories an
+// obs-websocket 5 server sends to the client.
+type EventSubscription uint32
+
+const (
+	EventSubscriptionNone        EventSubscription = 0
+	EventSubscriptionGeneral     EventSubscription = 1 << 0
+	EventSubscriptionConfig      EventSubscription = 1 << 1
+	EventSubscriptionScenes      EventSubscription = 1 << 2
+	EventSubscriptionInputs      EventSubscription = 1 << 3
+	EventSubscriptionTransitions EventSubscription = 1 << 4
+	EventSubscriptionFilters     EventSubscription = 1 << 5
+	EventSubscriptionOutputs     EventSubscription = 1 << 6
+	EventSubscriptionSceneItems  EventSubscription = 1 << 7
+	EventSubscriptionMediaInputs EventSubscription = 1 << 8
+	EventSubscriptionVendors     EventSubscription = 1 << 9
+	EventSubscriptionUI          EventSubscription = 1 << 10
+	// EventSubscriptionAll is every category but the high-volume
+	// ones below, which must be requested explicitly.
+	EventSubscriptionAll = EventSubscriptionGeneral | EventSubscriptionConfig |
+		EventSubscriptionScenes | EventSubscriptionInputs |
+		EventSubscriptionTransitions | EventSubscriptionFilters |
+		EventSubscriptionOutputs | EventSubscriptionSceneItems |
+		EventSubscriptionMediaInputs | EventSubscriptionVendors |
+		EventSubscriptionUI
+	EventSubscriptionInputVolumeMeters         EventSubscription = 1 << 16
+	EventSubscriptionInputActiveStateChanged   EventSubscription = 1 << 17
+	EventSubscriptionInputShowStateChanged     EventSubscription = 1 << 18
+	EventSubscriptionSceneItemTransformChanged EventSubscription = 1 << 19
+)
+
+type messageV5 struct {
+	Op opCode          `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+type outgoingMessageV5 struct {
+	Op opCode      `json:"op"`
+	D  interface{} `json:"d"`
+}
+
+type helloV5 struct {
+	ObsWebSocketVersion string `json:"obsWebSocketVersion"`
+	RPCVersion          int    `json:"rpcVersion"`
+	Authentication      *struct {
+		Challenge string `json:"challenge"`
+		Salt      string `json:"salt"`
+	} `json:"authentication"`
+}
+
+type identifyV5 struct {
+	RPCVersion         int               `json:"rpcVersion"`
+	Authentication     string            `json:"authentication,omitempty"`
+	EventSubscriptions EventSubscription `json:"eventSubscriptions"`
+}
+
+type requestV5 struct {
+	RequestType string      `json:"requestType"`
+	RequestID   string      `json:"requestId"`
+	RequestData interface{} `json:"requestData,omitempty"`
+}
+
+type responseV5 struct {
+	RequestType   string `json:"requestType"`
+	RequestID     string `json:"requestId"`
+	RequestStatus struct {
+		Result  bool   `json:"result"`
+		Code    int    `json:"code"`
+		Comment string `json:"comment"`
+	} `json:"requestStatus"`
+	ResponseData json.RawMessage `json:"responseData"`
+}
+
+// v5Response is implemented by responses whose obs-websocket 5
+// responseData does not share the 4.x field names.
+type v5Response interface {
+	unmarshalV5(data []byte) error
+}
+
+type requestBatchV5 struct {
+	RequestID     string      `json:"requestId"`
+	HaltOnFailure bool        `json:"haltOnFailure"`
+	Requests      []requestV5 `json:"requests"`
+}
+
+type requestBatchResponseV5 struct {
+	RequestID string       `json:"requestId"`
+	Results   []responseV5 `json:"results"`
+}
+
+// v5BatchRequest is implemented by the requests sent as an
+// obs-websocket 5 RequestBatch. v5Batch returns the requests of the
+// batch and if it halts on the first failure.
+type v5BatchRequest interface {
+	v5Batch() ([]requestV5, bool, error)
+}
+
+// v5BatchResponse is implemented by the responses to a v5BatchRequest.
+type v5BatchResponse interface {
+	unmarshalV5Batch(results []responseV5) error
+}
+
+// isHelloV5 tells if frame is an obs-websocket 5 Hello message, and
+// not a 4.x event which has no op code.
+func isHelloV5(frame []byte) bool {
+	var msg struct {
+		Op *opCode `json:"op"`
+		D  struct {
+			ObsWebSocketVersion string `json:"obsWebSocketVersion"`
+		} `json:"d"`
+	}
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		return false
+	}
+	return msg.Op != nil && *msg.Op == opHello && len(msg.D.ObsWebSocketVersion) > 0
+}
+
+func identify(conn *websocket.Conn, helloFrame []byte, conf config) error {
+	var msg messageV5
+	if err := json.Unmarshal(helloFrame, &msg); err != nil {
+		return fmt.Errorf("obsws: invalid Hello message: %s", err)
+	}
+	if msg.Op != opHello {
+		return fmt.Errorf("obsws: expected Hello message, got op code %d", msg.Op)
+	}
+	var hello helloV5
+	if err := json.Unmarshal(msg.D, &hello); err != nil {
+		return fmt.Errorf("obsws: invalid Hello message: %s", err)
+	}
+
+	id := identifyV5{
+		RPCVersion:         rpcVersionV5,
+		EventSubscriptions: conf.eventSubscriptions,
+	}
+	if hello.Authentication != nil {
+		if len(conf.password) == 0 {
+			return fmt.Errorf("obsws: server requires authentication but no password was given")
+		}
+		id.Authentication = authResponse(conf.password, hello.Authentication.Salt, hello.Authentication.Challenge)
+	}
+	if err := websocket.JSON.Send(conn, outgoingMessageV5{Op: opIdentify, D: id}); err != nil {
+		return err
+	}
+
+	// the server closes the connection if the authentication failed
+	if err := websocket.JSON.Receive(conn, &msg); err != nil {
+		return fmt.Errorf("obsws: identification failed: %s", err)
+	}
+	if msg.Op != opIdentified {
+		return fmt.Errorf("obsws: expected Identified message, got op code %d", msg.Op)
+	}
+	return nil
+}
+
+type protocolV5 struct{}
+
+func (p protocolV5) version() Protocol {
+	return ProtocolV5
+}
+
+func (p protocolV5) marshalRequest(r request, messageID string) (interface{}, error) {
+	if batch, ok := r.(v5BatchRequest); ok == true {
+		requests, haltOnFailure, err := batch.v5Batch()
+		if err != nil {
+			return nil, err
+		}
+		return outgoingMessageV5{
+			Op: opRequestBatch,
+			D: requestBatchV5{
+				RequestID:     messageID,
+				HaltOnFailure: haltOnFailure,
+				Requests:      requests,
+			},
+		}, nil
+	}
+	requestType, data := r.v5Request()
+	if len(requestType) == 0 {
+		return nil, ErrUnsupportedRequest{RequestType: r.requestType(), Protocol: ProtocolV5}
+	}
+	return outgoingMessageV5{
+		Op: opRequest,
+		D: requestV5{
+			RequestType: requestType,
+			RequestID:   messageID,
+			RequestData: data,
+		},
+	}, nil
+}
+
+func (p protocolV5) unmarshalEvent(frame []byte) (Event, error) {
+	var msg messageV5
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		return nil, err
+	}
+	if msg.Op != opEvent {
+		return nil, ErrNotEventMessage{}
+	}
+	return unmarshalEventV5(msg.D)
+}
+
+func (p protocolV5) messageID(frame []byte) (string, error) {
+	var msg messageV5
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		return "", err
+	}
+	if msg.Op != opRequestResponse && msg.Op != opRequestBatchResponse {
+		return "", fmt.Errorf("obsws: unexpected op code %d", msg.Op)
+	}
+	// both responses have the requestId at the same place
+	var resp responseV5
+	if err := json.Unmarshal(msg.D, &resp); err != nil {
+		return "", err
+	}
+	return resp.RequestID, nil
+}
+
+func (p protocolV5) unmarshalResponse(frame []byte, resp response) error {
+	var msg messageV5
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		return err
+	}
+	if msg.Op == opRequestBatchResponse {
+		var batch requestBatchResponseV5
+		if err := json.Unmarshal(msg.D, &batch); err != nil {
+			return err
+		}
+		r, ok := resp.(v5BatchResponse)
+		if ok == false {
+			return fmt.Errorf("obsws: unexpected batch response to request '%s'", batch.RequestID)
+		}
+		if err := setResponseStatus(resp, responseBase{MessageID: batch.RequestID, Status: "ok"}); err != nil {
+			return err
+		}
+		return r.unmarshalV5Batch(batch.Results)
+	}
+
+	var respV5 responseV5
+	if err := json.Unmarshal(msg.D, &respV5); err != nil {
+		return err
+	}
+	if err := setResponseStatus(resp, respV5.status()); err != nil {
+		return err
+	}
+
+	if len(respV5.ResponseData) == 0 {
+		return nil
+	}
+	if r, ok := resp.(v5Response); ok == true {
+		return r.unmarshalV5(respV5.ResponseData)
+	}
+	return json.Unmarshal(respV5.ResponseData, resp)
+}
+
+// status returns the 4.x status fields of the response.
+func (r responseV5) status() responseBase {
+	status := responseBase{
+		MessageID: r.RequestID,
+		Status:    "ok",
+	}
+	if r.RequestStatus.Result == false {
+		status.Status = "error"
+		status.Error = fmt.Sprintf("%s (code %d)", r.RequestStatus.Comment, r.RequestStatus.Code)
+	}
+	return status
+}
+
+// setResponseStatus fills the status fields of resp, wherever its
+// responseBase is embedded.
+func setResponseStatus(resp response, status responseBase) error {
+	statusData, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(statusData, resp)
+}
+
+// v5EventConversion describes how to turn an obs-websocket 5 event
+// into its 4.x counterpart.
+type v5EventConversion struct {
+	updateType string
+	// fields maps the 5.x eventData fields to 4.x event fields
+	fields map[string]string
+	// stateField is the 5.x eventData field telling the 4.x
+	// update-type, through states, instead of updateType. The 5.x
+	// protocol has one event for each output state change or media
+	// action, where 4.x has one event for each state.
+	stateField string
+	states     map[string]string
+	// values converts the 5.x eventData values which changed unit,
+	// by 5.x field
+	values map[string]v5ValueConversion
+}
+
+// v5ValueConversion converts a 5.x eventData value to its 4.x
+// counterpart.
+type v5ValueConversion func(value json.RawMessage) (json.RawMessage, error)
+
+// millisecondsToNanoseconds converts a 5.x duration in milliseconds
+// to the 4.x nanoseconds.
+func millisecondsToNanoseconds(value json.RawMessage) (json.RawMessage, error) {
+	var ms float64
+	if err := json.Unmarshal(value, &ms); err != nil {
+		return nil, err
+	}
+	return json.Marshal(int64(ms * float64(time.Millisecond)))
+}
+
+var (
+	streamOutputStates = map[string]string{
+		"OBS_WEBSOCKET_OUTPUT_STARTING": "StreamStarting",
+		"OBS_WEBSOCKET_OUTPUT_STARTED":  "StreamStarted",
+		"OBS_WEBSOCKET_OUTPUT_STOPPING": "StreamStopping",
+		"OBS_WEBSOCKET_OUTPUT_STOPPED":  "StreamStopped",
+	}
+	recordOutputStates = map[string]string{
+		"OBS_WEBSOCKET_OUTPUT_STARTING": "RecordingStarting",
+		"OBS_WEBSOCKET_OUTPUT_STARTED":  "RecordingStarted",
+		"OBS_WEBSOCKET_OUTPUT_STOPPING": "RecordingStopping",
+		"OBS_WEBSOCKET_OUTPUT_STOPPED":  "RecordingStopped",
+		"OBS_WEBSOCKET_OUTPUT_PAUSED":   "RecordingPaused",
+		"OBS_WEBSOCKET_OUTPUT_RESUMED":  "RecordingResumed",
+	}
+	replayOutputStates = map[string]string{
+		"OBS_WEBSOCKET_OUTPUT_STARTING": "ReplayStarting",
+		"OBS_WEBSOCKET_OUTPUT_STARTED":  "ReplayStarted",
+		"OBS_WEBSOCKET_OUTPUT_STOPPING": "ReplayStopping",
+		"OBS_WEBSOCKET_OUTPUT_STOPPED":  "ReplayStopped",
+	}
+	virtualCamOutputStates = map[string]string{
+		"OBS_WEBSOCKET_OUTPUT_STARTED": "VirtualCamStarted",
+		"OBS_WEBSOCKET_OUTPUT_STOPPED": "VirtualCamStopped",
+	}
+	mediaActions = map[string]string{
+		"OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PLAY":     "MediaPlaying",
+		"OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PAUSE":    "MediaPaused",
+		"OBS_WEBSOCKET_MEDIA_INPUT_ACTION_RESTART":  "MediaRestarted",
+		"OBS_WEBSOCKET_MEDIA_INPUT_ACTION_STOP":     "MediaStopped",
+		"OBS_WEBSOCKET_MEDIA_INPUT_ACTION_NEXT":     "MediaNext",
+		"OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PREVIOUS": "MediaPrevious",
+	}
+)
+
+var v5EventFactory = map[string]v5EventConversion{
+	"CurrentProgramSceneChanged": {updateType: "SwitchScenes", fields: map[string]string{"sceneName": "scene-name"}},
+	"SceneListChanged":           {updateType: "ScenesChanged"},
+	"SceneCreated":               {updateType: "ScenesChanged"},
+	"SceneRemoved":               {updateType: "ScenesChanged"},
+	"SceneNameChanged":           {updateType: "ScenesChanged"},
+	"SceneItemCreated":           {updateType: "SceneItemAdded", fields: map[string]string{"sceneName": "scene-name", "sourceName": "item-name", "sceneItemId": "item-id"}},
+	"SceneItemRemoved":           {updateType: "SceneItemRemoved", fields: map[string]string{"sceneName": "scene-name", "sourceName": "item-name", "sceneItemId": "item-id"}},
+	"SceneItemListReindexed": {
+		updateType: "SourceOrderChanged",
+		fields:     map[string]string{"sceneName": "scene-name", "sceneItems": "scene-items"},
+		values:     map[string]v5ValueConversion{"sceneItems": sceneItemOrderV5ToV4},
+	},
+	"StreamStateChanged":            {stateField: "outputState", states: streamOutputStates},
+	"RecordStateChanged":            {fields: map[string]string{"outputPath": "recordingFilename"}, stateField: "outputState", states: recordOutputStates},
+	"ReplayBufferStateChanged":      {stateField: "outputState", states: replayOutputStates},
+	"ReplayBufferSaved":             {updateType: "ReplayBufferSaved", fields: map[string]string{"savedReplayPath": "savedReplayPath"}},
+	"InputVolumeChanged":            {updateType: "SourceVolumeChanged", fields: map[string]string{"inputName": "sourceName", "inputVolumeMul": "volume", "inputVolumeDb": "volumeDb"}},
+	"InputMuteStateChanged":         {updateType: "SourceMuteStateChanged", fields: map[string]string{"inputName": "sourceName", "inputMuted": "muted"}},
+	"CurrentSceneTransitionChanged": {updateType: "SwitchTransition", fields: map[string]string{"transitionName": "transition-name"}},
+	"SceneTransitionStarted":        {updateType: "TransitionBegin", fields: map[string]string{"transitionName": "name"}},
+	"SceneTransitionEnded":          {updateType: "TransitionEnd", fields: map[string]string{"transitionName": "name"}},
+	"SceneTransitionVideoEnded":     {updateType: "TransitionVideoEnd", fields: map[string]string{"transitionName": "name"}},
+	"CurrentPreviewSceneChanged":    {updateType: "PreviewSceneChanged", fields: map[string]string{"sceneName": "scene-name"}},
+	"StudioModeStateChanged":        {updateType: "StudioModeSwitched", fields: map[string]string{"studioModeEnabled": "new-state"}},
+	"InputAudioSyncOffsetChanged": {
+		updateType: "SourceAudioSyncOffsetChanged",
+		fields:     map[string]string{"inputName": "sourceName", "inputAudioSyncOffset": "syncOffset"},
+		values:     map[string]v5ValueConversion{"inputAudioSyncOffset": millisecondsToNanoseconds},
+	},
+	"SourceFilterCreated": {
+		updateType: "SourceFilterAdded",
+		fields:     map[string]string{"sourceName": "sourceName", "filterName": "filterName", "filterKind": "filterType", "filterSettings": "filterSettings"},
+	},
+	"SourceFilterRemoved":            {updateType: "SourceFilterRemoved", fields: map[string]string{"sourceName": "sourceName", "filterName": "filterName"}},
+	"SourceFilterEnableStateChanged": {updateType: "SourceFilterVisibilityChanged", fields: map[string]string{"sourceName": "sourceName", "filterName": "filterName", "filterEnabled": "filterEnabled"}},
+	"SourceFilterListReindexed": {
+		updateType: "SourceFiltersReordered",
+		fields:     map[string]string{"sourceName": "sourceName", "filters": "filters"},
+		values:     map[string]v5ValueConversion{"filters": filtersV5ToV4},
+	},
+	"CurrentProfileChanged": {updateType: "ProfileChanged", fields: map[string]string{"profileName": "profile"}},
+	"ProfileListChanged": {
+		updateType: "ProfileListChanged",
+		fields:     map[string]string{"profiles": "profiles"},
+		values:     map[string]v5ValueConversion{"profiles": namesToListItems},
+	},
+	"CurrentSceneCollectionChanged": {updateType: "SceneCollectionChanged", fields: map[string]string{"sceneCollectionName": "sceneCollection"}},
+	"SceneCollectionListChanged": {
+		updateType: "SceneCollectionListChanged",
+		fields:     map[string]string{"sceneCollections": "sceneCollections"},
+		values:     map[string]v5ValueConversion{"sceneCollections": namesToListItems},
+	},
+	"ExitStarted":            {updateType: "Exiting"},
+	"CustomEvent":            {updateType: "BroadcastCustomMessage", fields: map[string]string{"realm": "realm", "data": "data"}},
+	"VirtualcamStateChanged": {stateField: "outputState", states: virtualCamOutputStates},
+	"InputCreated": {
+		updateType: "SourceCreated",
+		fields:     map[string]string{"inputName": "sourceName", "inputKind": "sourceKind", "inputSettings": "sourceSettings"},
+	},
+	"InputRemoved":     {updateType: "SourceDestroyed", fields: map[string]string{"inputName": "sourceName"}},
+	"InputNameChanged": {updateType: "SourceRenamed", fields: map[string]string{"oldInputName": "previousName", "inputName": "newName"}},
+	"SceneItemEnableStateChanged": {
+		updateType: "SceneItemVisibilityChanged",
+		fields:     map[string]string{"sceneName": "scene-name", "sceneItemId": "item-id", "sceneItemEnabled": "item-visible"},
+	},
+	"SceneItemLockStateChanged": {
+		updateType: "SceneItemLockChanged",
+		fields:     map[string]string{"sceneName": "scene-name", "sceneItemId": "item-id", "sceneItemLocked": "item-locked"},
+	},
+	"SceneItemSelected":         {updateType: "SceneItemSelected", fields: map[string]string{"sceneName": "scene-name", "sceneItemId": "item-id"}},
+	"MediaInputPlaybackStarted": {updateType: "MediaStarted", fields: map[string]string{"inputName": "sourceName"}},
+	"MediaInputPlaybackEnded":   {updateType: "MediaEnded", fields: map[string]string{"inputName": "sourceName"}},
+	"MediaInputActionTriggered": {fields: map[string]string{"inputName": "sourceName"}, stateField: "mediaAction", states: mediaActions},
+}
+
+// unmarshalEventV5 converts the data of an obs-websocket 5 Event
+// message to the corresponding 4.x Event.
+func unmarshalEventV5(data []byte) (Event, error) {
+	aux := struct {
+		EventType string                     `json:"eventType"`
+		EventData map[string]json.RawMessage `json:"eventData"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return nil, err
+	}
+
+	conv, ok := v5EventFactory[aux.EventType]
+	if ok == false {
+		return nil, ErrUnknownEventType{Type: aux.EventType, Data: data}
+	}
+	if conv.stateField != "" {
+		var state string
+		if err := json.Unmarshal(aux.EventData[conv.stateField], &state); err != nil {
+			return nil, err
+		}
+		if conv.updateType, ok = conv.states[state]; ok == false {
+			return nil, ErrUnknownEventType{Type: aux.EventType + "/" + state, Data: data}
+		}
+	}
+
+	v4Data := map[string]json.RawMessage{}
+	for v5Field, v4Field := range conv.fields {
+		value, ok := aux.EventData[v5Field]
+		if ok == false {
+			continue
+		}
+		if convert, ok := conv.values[v5Field]; ok == true {
+			var err error
+			if value, err = convert(value); err != nil {
+				return nil, err
+			}
+		}
+		v4Data[v4Field] = value
+	}
+	updateType, err := json.Marshal(conv.updateType)
+	if err != nil {
+		return nil, err
+	}
+	v4Data["update-type"] = updateType
+
+	v4Frame, err := json.Marshal(v4Data)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalEvent(v4Frame)
+}
@@ -0,0 +1,135 @@
+package ws
+
+import (
+	"fmt"
+	"time"
+)
+
+// Backoff configures the delays between reconnection attempts. The
+// delay starts at Initial and is multiplied by Multiplier after each
+// failed attempt, up to Max. Zero fields take their default value.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	// MaxAttempts is the number of attempts before giving up, 0
+	// means forever.
+	MaxAttempts int
+}
+
+// DefaultBackoff is used for the zero fields of the Backoff given to
+// WithReconnect.
+var DefaultBackoff = Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+}
+
+func (b Backoff) withDefaults() Backoff {
+	if b.Initial <= 0 {
+		b.Initial = DefaultBackoff.Initial
+	}
+	if b.Max <= 0 {
+		b.Max = DefaultBackoff.Max
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = DefaultBackoff.Multiplier
+	}
+	return b
+}
+
+// delay returns the delay to wait before the given attempt, starting
+// at 1.
+func (b Backoff) delay(attempt int) time.Duration {
+	d := float64(b.Initial)
+	for i := 1; i < attempt && d < float64(b.Max); i++ {
+		d *= b.Multiplier
+	}
+	if d > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(d)
+}
+
+// InFlightPolicy tells what happens to the requests waiting for an
+// answer when the connection drops.
+type InFlightPolicy int
+
+const (
+	// InFlightFail fails the requests with ErrDisconnected, and the
+	// requests made until the client reconnected.
+	InFlightFail InFlightPolicy = iota
+	// InFlightRetry sends the requests again once reconnected. They
+	// fail with ErrDisconnected if the client gives up reconnecting.
+	InFlightRetry
+)
+
+// ErrDisconnected is returned by requests that could not be answered
+// because the connection to the instance was lost.
+type ErrDisconnected struct {
+	Err error
+}
+
+func (e ErrDisconnected) Error() string {
+	return fmt.Sprintf("obsws: disconnected from instance: %s", e.Err)
+}
+
+// EventConnected is sent by the client on EventChannel once it
+// reconnected to the instance.
+type EventConnected struct {
+	rawEvent
+}
+
+// EventDisconnected is sent by the client on EventChannel when the
+// connection to the instance drops.
+type EventDisconnected struct {
+	Err error
+	rawEvent
+}
+
+// EventReconnecting is sent by the client on EventChannel before each
+// reconnection attempt.
+type EventReconnecting struct {
+	Attempt int
+	Delay   time.Duration
+	rawEvent
+}
+
+func newRawEvent(eventType string) rawEvent {
+	return rawEvent{eventType: eventType, streamTC: -1, recTC: -1}
+}
+
+// reconnect tries to connect again to the instance according to the
+// configured Backoff. It returns false if the client gave up or was
+// closed meanwhile.
+func (c *Client) reconnect() bool {
+	if c.conf.reconnect == false {
+		return false
+	}
+	backoff := c.conf.backoff
+	for attempt := 1; backoff.MaxAttempts == 0 || attempt <= backoff.MaxAttempts; attempt++ {
+		delay := backoff.delay(attempt)
+		c.dispatchEvent(&EventReconnecting{
+			Attempt:  attempt,
+			Delay:    delay,
+			rawEvent: newRawEvent("Reconnecting"),
+		})
+		select {
+		case <-time.After(delay):
+		case <-c.closing:
+			return false
+		}
+
+		conn, proto, early, err := connect(c.address, c.port, c.conf, c.closing)
+		if err != nil {
+			continue
+		}
+		c.setConnection(conn, proto)
+		c.dispatchEvent(&EventConnected{rawEvent: newRawEvent("Connected")})
+		if early != nil {
+			c.receive(proto, early)
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type GetReplayBufferStatusResponse struct {
+	IsReplayBufferActive bool `json:"isReplayBufferActive"`
+	responseBase
+}
+
+func (r *GetReplayBufferStatusResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		OutputActive bool `json:"outputActive"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.IsReplayBufferActive = aux.OutputActive
+	return nil
+}
+
+// StartReplayBuffer starts the replay buffer.
+func (c *Client) StartReplayBuffer() error {
+	return c.StartReplayBufferCtx(context.Background())
+}
+
+func (c *Client) StartReplayBufferCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("StartReplayBuffer", "StartReplayBuffer"))
+	return err
+}
+
+// StopReplayBuffer stops the replay buffer.
+func (c *Client) StopReplayBuffer() error {
+	return c.StopReplayBufferCtx(context.Background())
+}
+
+func (c *Client) StopReplayBufferCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("StopReplayBuffer", "StopReplayBuffer"))
+	return err
+}
+
+// SaveReplayBuffer writes the content of the replay buffer to
+// disk. It fails if the replay buffer is not active.
+func (c *Client) SaveReplayBuffer() error {
+	return c.SaveReplayBufferCtx(context.Background())
+}
+
+func (c *Client) SaveReplayBufferCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("SaveReplayBuffer", "SaveReplayBuffer"))
+	return err
+}
+
+// GetReplayBufferStatus tells if the replay buffer is active.
+func (c *Client) GetReplayBufferStatus() (*GetReplayBufferStatusResponse, error) {
+	return c.GetReplayBufferStatusCtx(context.Background())
+}
+
+func (c *Client) GetReplayBufferStatusCtx(ctx context.Context) (*GetReplayBufferStatusResponse, error) {
+	resp := &GetReplayBufferStatusResponse{}
+	r := forgeRequestWithExpectedResponse("GetReplayBufferStatus", resp)
+	r.setV5Request("GetReplayBufferStatus", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Clip saves the replay buffer and waits for the file to be written,
+// then returns its path. Only obs-websocket 5 notifies the saved
+// file, so with the 4.x protocol Clip fails with
+// ErrUnsupportedRequest without saving anything. The calls are
+// serialized, as the notification does not tell which save it
+// answers.
+func (c *Client) Clip() (string, error) {
+	return c.ClipCtx(context.Background())
+}
+
+func (c *Client) ClipCtx(ctx context.Context) (string, error) {
+	if proto := c.Protocol(); proto != ProtocolV5 {
+		return "", ErrUnsupportedRequest{RequestType: "ReplayBufferSaved", Protocol: proto}
+	}
+	if c.conf.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.conf.requestTimeout)
+		defer cancel()
+	}
+
+	select {
+	case c.clipping <- struct{}{}:
+		defer func() { <-c.clipping }()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	// watch before saving to not miss the notification
+	events, stop := c.Subscribe("ReplayBufferSaved")
+	defer stop()
+	if err := c.SaveReplayBufferCtx(ctx); err != nil {
+		return "", err
+	}
+	select {
+	case ev, ok := <-events:
+		if ok == false {
+			return "", ErrClosed{}
+		}
+		return ev.(*EventReplayBufferSaved).SavedReplayPath, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
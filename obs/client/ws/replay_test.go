@@ -0,0 +1,128 @@
+package ws
+
+import (
+	"fmt"
+	"sync"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type ReplaySuite struct{}
+
+var _ = Suite(&ReplaySuite{})
+
+func (s *ReplaySuite) TestReplayRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.StartReplayBuffer()
+			},
+			request:  `{"request-type":"StartReplayBuffer"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.StopReplayBuffer()
+			},
+			request:  `{"request-type":"StopReplayBuffer"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SaveReplayBuffer()
+			},
+			request:  `{"request-type":"SaveReplayBuffer"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetReplayBufferStatus()
+			},
+			request:  `{"request-type":"GetReplayBufferStatus"}`,
+			response: `{"isReplayBufferActive":true}`,
+			expected: &GetReplayBufferStatusResponse{
+				IsReplayBufferActive: true,
+				responseBase:         recordedOK,
+			},
+		},
+	})
+}
+
+func (s *ReplaySuite) TestReplayEventsV5(c *C) {
+	tdata := map[string]Event{
+		`{"eventType":"ReplayBufferStateChanged","eventIntent":64,"eventData":{"outputActive":true,"outputState":"OBS_WEBSOCKET_OUTPUT_STARTED"}}`: &EventReplayStarted{
+			rawEvent: newRawEvent("ReplayStarted"),
+		},
+		`{"eventType":"ReplayBufferStateChanged","eventIntent":64,"eventData":{"outputActive":false,"outputState":"OBS_WEBSOCKET_OUTPUT_STOPPING"}}`: &EventReplayStopping{
+			rawEvent: newRawEvent("ReplayStopping"),
+		},
+		`{"eventType":"ReplayBufferSaved","eventIntent":64,"eventData":{"savedReplayPath":"/vods/replay.mkv"}}`: &EventReplayBufferSaved{
+			SavedReplayPath: "/vods/replay.mkv",
+			rawEvent:        newRawEvent("ReplayBufferSaved"),
+		},
+	}
+	for data, expected := range tdata {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+}
+
+func (s *ReplaySuite) TestClip(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	_, err := client.Clip()
+	c.Check(err, ErrorMatches, ".*replay buffer not active.*")
+	c.Assert(client.StartReplayBuffer(), IsNil)
+	path, err := client.Clip()
+	c.Check(err, IsNil)
+	c.Check(path, Equals, "/tmp/replay.mkv")
+}
+
+func (s *ReplaySuite) TestClipOverlap(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	saved := 0
+	server.Handle("SaveReplayBuffer", func(map[string]interface{}) (map[string]interface{}, error) {
+		saved++
+		server.Emit("ReplayBufferSaved", map[string]interface{}{
+			"savedReplayPath": fmt.Sprintf("/tmp/replay-%d.mkv", saved),
+		})
+		return nil, nil
+	})
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	paths := make([]string, 2)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path, err := client.Clip()
+			c.Check(err, IsNil)
+			paths[i] = path
+		}(i)
+	}
+	wg.Wait()
+	c.Check(paths[0], Not(Equals), paths[1])
+}
+
+func (s *ReplaySuite) TestClipV4(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	_, err := client.Clip()
+	c.Check(err, ErrorMatches, "obsws: request 'ReplayBufferSaved' is not supported by protocol 4.x")
+	for _, req := range server.Requests() {
+		c.Errorf("unexpected request %v", req)
+	}
+}
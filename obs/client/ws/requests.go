@@ -1,12 +1,20 @@
 package ws
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type request interface {
 	setMessageID(uid string)
+	requestType() string
 	getResponseChannel() chan response
 	setResponseChannel(rchan chan response)
 	responseType() response
+	// v5Request returns the obs-websocket 5 request type and
+	// requestData, or an empty type if there is no equivalent.
+	v5Request() (string, interface{})
+	setV5Request(requestType string, data interface{})
 }
 
 type requestBase struct {
@@ -14,12 +22,18 @@ type requestBase struct {
 	RequestType string `json:"request-type"`
 	response    chan response
 	rType       response
+	v5Type      string
+	v5Data      interface{}
 }
 
 func (r *requestBase) setMessageID(ID string) {
 	r.MessageID = ID
 }
 
+func (r *requestBase) requestType() string {
+	return r.RequestType
+}
+
 func (r *requestBase) getResponseChannel() chan response {
 	return r.response
 }
@@ -32,6 +46,15 @@ func (r *requestBase) responseType() response {
 	return r.rType
 }
 
+func (r *requestBase) v5Request() (string, interface{}) {
+	return r.v5Type, r.v5Data
+}
+
+func (r *requestBase) setV5Request(requestType string, data interface{}) {
+	r.v5Type = requestType
+	r.v5Data = data
+}
+
 func forgeRequest(name string) request {
 	return &requestBase{
 		RequestType: name,
@@ -55,28 +78,77 @@ func forgeSetCurrentScene(name string) request {
 		requestBase: requestBase{
 			RequestType: "SetCurrentScene",
 			rType:       &responseBase{},
+			v5Type:      "SetCurrentProgramScene",
+			v5Data:      map[string]interface{}{"sceneName": name},
 		},
 		SceneName: name,
 	}
 }
 
+func forgeAuthenticate(auth string) request {
+	type authenticate struct {
+		requestBase
+		Auth string `json:"auth"`
+	}
+	return &authenticate{
+		requestBase: requestBase{
+			RequestType: "Authenticate",
+			rType:       &responseBase{},
+		},
+		Auth: auth,
+	}
+}
+
 func (c *Client) submitRequest(r request) (response, error) {
-	rchan := make(chan response)
-	r.setResponseChannel(rchan)
-	c.requests <- r
-	resp, ok := <-rchan
-	if ok == false {
-		return nil, fmt.Errorf("obsws: internal error, response channel closed without response")
+	return c.submitRequestCtx(context.Background(), r)
+}
+
+// submitRequestCtx sends r and waits for its response, until ctx or
+// the client default request timeout expires.
+func (c *Client) submitRequestCtx(ctx context.Context, r request) (response, error) {
+	if c.conf.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.conf.requestTimeout)
+		defer cancel()
 	}
 
-	if err := resp.error(); err != nil {
+	// buffered so the client never waits for us to read the response
+	rchan := make(chan response, 1)
+	r.setResponseChannel(rchan)
+	messageID, err := c.addRequest(r)
+	if err != nil {
 		return nil, err
 	}
-	return resp, nil
+
+	select {
+	case c.outgoing <- messageID:
+	case <-c.closing:
+		// Close answers the pending requests
+	case <-ctx.Done():
+		c.removeRequest(messageID)
+		return nil, ctx.Err()
+	}
+
+	select {
+	case resp := <-rchan:
+		if err := resp.error(); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	case <-ctx.Done():
+		c.removeRequest(messageID)
+		return nil, ctx.Err()
+	}
 }
 
 func (c *Client) GetSceneList() (*GetSceneListResponse, error) {
-	resp, err := c.submitRequest(forgeRequestWithExpectedResponse("GetSceneList", &GetSceneListResponse{}))
+	return c.GetSceneListCtx(context.Background())
+}
+
+func (c *Client) GetSceneListCtx(ctx context.Context) (*GetSceneListResponse, error) {
+	r := forgeRequestWithExpectedResponse("GetSceneList", &GetSceneListResponse{})
+	r.setV5Request("GetSceneList", nil)
+	resp, err := c.submitRequestCtx(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +160,46 @@ func (c *Client) GetSceneList() (*GetSceneListResponse, error) {
 }
 
 func (c *Client) SetCurrentScene(name string) error {
-	_, err := c.submitRequest(forgeSetCurrentScene(name))
+	return c.SetCurrentSceneCtx(context.Background(), name)
+}
+
+func (c *Client) SetCurrentSceneCtx(ctx context.Context, name string) error {
+	c.sceneSwitchLock.Lock()
+	defer c.sceneSwitchLock.Unlock()
+	_, err := c.submitRequestCtx(ctx, forgeSetCurrentScene(name))
 	return err
 }
+
+// GetVersion returns the versions of OBS and of obs-websocket, and
+// the requests they support.
+func (c *Client) GetVersion() (*GetVersionResponse, error) {
+	return c.GetVersionCtx(context.Background())
+}
+
+func (c *Client) GetVersionCtx(ctx context.Context) (*GetVersionResponse, error) {
+	resp := &GetVersionResponse{}
+	r := forgeRequestWithExpectedResponse("GetVersion", resp)
+	r.setV5Request("GetVersion", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetAuthRequired tells if authentication is required on this
+// instance, and if so, returns the challenge and salt to use.
+func (c *Client) GetAuthRequired() (*GetAuthRequiredResponse, error) {
+	return c.GetAuthRequiredCtx(context.Background())
+}
+
+func (c *Client) GetAuthRequiredCtx(ctx context.Context) (*GetAuthRequiredResponse, error) {
+	resp, err := c.submitRequestCtx(ctx, forgeRequestWithExpectedResponse("GetAuthRequired", &GetAuthRequiredResponse{}))
+	if err != nil {
+		return nil, err
+	}
+	respCorrect, ok := resp.(*GetAuthRequiredResponse)
+	if ok == false {
+		return nil, fmt.Errorf("obsws: unexpected response from server: %#v", resp)
+	}
+	return respCorrect, nil
+}
@@ -0,0 +1,52 @@
+package ws
+
+import (
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+// recordedOK is the status of the first response to a client.
+var recordedOK = responseBase{MessageID: "1", Status: "ok"}
+
+// requestCase checks a request against a wstest.Server replaying a
+// recorded 4.x response.
+type requestCase struct {
+	call func(client *Client) (interface{}, error)
+	// request is the expected 4.x request, without message-id
+	request string
+	// response is the recorded 4.x response, without message-id
+	response string
+	// expected is the value returned by call, if any
+	expected interface{}
+}
+
+func checkRequests(c *C, cases []requestCase) {
+	for _, tc := range cases {
+		var expectedReq map[string]interface{}
+		c.Assert(json.Unmarshal([]byte(tc.request), &expectedReq), IsNil, Commentf(tc.request))
+		requestType := expectedReq["request-type"].(string)
+		delete(expectedReq, "request-type")
+
+		server := wstest.NewServer()
+		response := tc.response
+		server.Handle(requestType, func(map[string]interface{}) (map[string]interface{}, error) {
+			var fields map[string]interface{}
+			err := json.Unmarshal([]byte(response), &fields)
+			return fields, err
+		})
+		client := newServerClient(c, server)
+
+		res, err := tc.call(client)
+		if c.Check(err, IsNil, Commentf(tc.request)) == true && tc.expected != nil {
+			c.Check(res, DeepEquals, tc.expected, Commentf(tc.request))
+		}
+		c.Check(server.Requests(), DeepEquals, []wstest.Request{{Type: requestType, Fields: expectedReq}},
+			Commentf(tc.request))
+
+		client.Close()
+		server.Close()
+	}
+}
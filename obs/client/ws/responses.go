@@ -1,7 +1,9 @@
 package ws
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -27,9 +29,35 @@ func (r responseBase) messageID() string {
 	return r.MessageID
 }
 
+// errorResponse is handed to the caller of a request that failed
+// before the server could answer it.
+type errorResponse struct {
+	err error
+}
+
+func (r errorResponse) error() error {
+	return r.err
+}
+
+func (r errorResponse) messageID() string {
+	return ""
+}
+
 type Source struct {
-	Type   string  `json:"type"`
-	Volume float64 `json:"volume"`
+	Name      string  `json:"name"`
+	ID        int     `json:"id"`
+	Type      string  `json:"type"`
+	Volume    float64 `json:"volume"`
+	Render    bool    `json:"render"`
+	Muted     bool    `json:"muted"`
+	Locked    bool    `json:"locked"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Cx        float64 `json:"cx"`
+	Cy        float64 `json:"cy"`
+	SourceCx  int     `json:"source_cx"`
+	SourceCy  int     `json:"source_cy"`
+	Alignment int     `json:"alignment"`
 }
 
 type Scene struct {
@@ -40,10 +68,168 @@ type Scene struct {
 type GetSceneListResponse struct {
 	CurrentScene string  `json:"current-scene"`
 	Scenes       []Scene `json:"scenes"`
-	*responseBase
+	responseBase
+}
+
+func (r *GetSceneListResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		CurrentProgramSceneName string `json:"currentProgramSceneName"`
+		Scenes                  []struct {
+			SceneIndex int    `json:"sceneIndex"`
+			SceneName  string `json:"sceneName"`
+		} `json:"scenes"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	// 5.x indexes scenes from the bottom of the list
+	sort.SliceStable(aux.Scenes, func(i, j int) bool {
+		return aux.Scenes[i].SceneIndex > aux.Scenes[j].SceneIndex
+	})
+	r.CurrentScene = aux.CurrentProgramSceneName
+	r.Scenes = make([]Scene, 0, len(aux.Scenes))
+	for _, s := range aux.Scenes {
+		r.Scenes = append(r.Scenes, Scene{Name: s.SceneName})
+	}
+	return nil
 }
 
-type GetCurrentScene struct {
+type GetCurrentSceneResponse struct {
 	Scene
-	*responseBase
+	responseBase
+}
+
+// GetCurrentScene is the former name of GetCurrentSceneResponse.
+//
+// Deprecated: use GetCurrentSceneResponse.
+type GetCurrentScene = GetCurrentSceneResponse
+
+func (r *GetCurrentSceneResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		CurrentProgramSceneName string `json:"currentProgramSceneName"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Name = aux.CurrentProgramSceneName
+	return nil
+}
+
+// sceneItemOrderV5ToV4 converts the item list of a 5.x
+// SceneItemListReindexed event, indexed from the bottom of the scene,
+// to the 4.x scene-items from top to bottom.
+func sceneItemOrderV5ToV4(value json.RawMessage) (json.RawMessage, error) {
+	var items []struct {
+		SceneItemID    int `json:"sceneItemId"`
+		SceneItemIndex int `json:"sceneItemIndex"`
+	}
+	if err := json.Unmarshal(value, &items); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].SceneItemIndex > items[j].SceneItemIndex
+	})
+	res := make([]SceneItemOrder, 0, len(items))
+	for _, item := range items {
+		res = append(res, SceneItemOrder{ItemID: item.SceneItemID})
+	}
+	return json.Marshal(res)
+}
+
+// sceneItemListV5 is the response to the 5.x GetSceneItemList, with
+// the items as 4.x sources from top to bottom.
+type sceneItemListV5 struct {
+	Sources []Source
+	responseBase
+}
+
+func (r *sceneItemListV5) unmarshalV5(data []byte) error {
+	aux := struct {
+		SceneItems []struct {
+			SceneItemID        int                  `json:"sceneItemId"`
+			SceneItemIndex     int                  `json:"sceneItemIndex"`
+			SceneItemEnabled   bool                 `json:"sceneItemEnabled"`
+			SceneItemLocked    bool                 `json:"sceneItemLocked"`
+			SceneItemTransform sceneItemTransformV5 `json:"sceneItemTransform"`
+			SourceName         string               `json:"sourceName"`
+			SourceType         string               `json:"sourceType"`
+			InputKind          string               `json:"inputKind"`
+		} `json:"sceneItems"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	// 5.x indexes items from the bottom of the scene
+	sort.SliceStable(aux.SceneItems, func(i, j int) bool {
+		return aux.SceneItems[i].SceneItemIndex > aux.SceneItems[j].SceneItemIndex
+	})
+	r.Sources = make([]Source, 0, len(aux.SceneItems))
+	for _, item := range aux.SceneItems {
+		t := item.SceneItemTransform
+		source := Source{
+			Name:      item.SourceName,
+			ID:        item.SceneItemID,
+			Type:      item.InputKind,
+			Render:    item.SceneItemEnabled,
+			Locked:    item.SceneItemLocked,
+			X:         t.PositionX,
+			Y:         t.PositionY,
+			Cx:        t.Width,
+			Cy:        t.Height,
+			SourceCx:  int(t.SourceWidth),
+			SourceCy:  int(t.SourceHeight),
+			Alignment: t.Alignment,
+		}
+		if item.SourceType == "OBS_SOURCE_TYPE_SCENE" {
+			source.Type = "scene"
+		}
+		r.Sources = append(r.Sources, source)
+	}
+	return nil
+}
+
+type GetVersionResponse struct {
+	// Version is the obs-websocket API version, always 1.1
+	Version             float64 `json:"version"`
+	OBSWebsocketVersion string  `json:"obs-websocket-version"`
+	OBSStudioVersion    string  `json:"obs-studio-version"`
+	// AvailableRequests is a comma separated list of the request
+	// types
+	AvailableRequests           string `json:"available-requests"`
+	SupportedImageExportFormats string `json:"supported-image-export-formats"`
+	responseBase
+}
+
+func (r *GetVersionResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		OBSVersion            string   `json:"obsVersion"`
+		OBSWebSocketVersion   string   `json:"obsWebSocketVersion"`
+		AvailableRequests     []string `json:"availableRequests"`
+		SupportedImageFormats []string `json:"supportedImageFormats"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.OBSStudioVersion = aux.OBSVersion
+	r.OBSWebsocketVersion = aux.OBSWebSocketVersion
+	r.AvailableRequests = strings.Join(aux.AvailableRequests, ",")
+	r.SupportedImageExportFormats = strings.Join(aux.SupportedImageFormats, ",")
+	return nil
+}
+
+// HasRequest tells if the instance supports the request type.
+func (r *GetVersionResponse) HasRequest(requestType string) bool {
+	for _, available := range strings.Split(r.AvailableRequests, ",") {
+		if available == requestType {
+			return true
+		}
+	}
+	return false
+}
+
+type GetAuthRequiredResponse struct {
+	AuthRequired bool   `json:"authRequired"`
+	Challenge    string `json:"challenge"`
+	Salt         string `json:"salt"`
+	responseBase
 }
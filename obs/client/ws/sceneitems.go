@@ -0,0 +1,640 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Position of a scene item, in pixels from the top left corner of
+// the scene. Alignment is a bitmask of the OBS_ALIGN_* flags.
+type Position struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Alignment int     `json:"alignment"`
+}
+
+// Scale of a scene item. Filter is the scale filter, like
+// "OBS_SCALE_BILINEAR".
+type Scale struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Filter string  `json:"filter,omitempty"`
+}
+
+// Crop of a scene item, in pixels.
+type Crop struct {
+	Top    int `json:"top"`
+	Right  int `json:"right"`
+	Bottom int `json:"bottom"`
+	Left   int `json:"left"`
+}
+
+// Bounds of a scene item. Type is the bounding box type, like
+// "OBS_BOUNDS_STRETCH" or "OBS_BOUNDS_NONE".
+type Bounds struct {
+	Type      string  `json:"type"`
+	Alignment int     `json:"alignment"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+}
+
+// SceneItemRef identifies a scene item by name, id or both.
+type SceneItemRef struct {
+	Name string `json:"name,omitempty"`
+	ID   int    `json:"id,omitempty"`
+}
+
+type SceneItemProperties struct {
+	Name         string   `json:"name"`
+	ItemID       int      `json:"itemId"`
+	Position     Position `json:"position"`
+	Rotation     float64  `json:"rotation"`
+	Scale        Scale    `json:"scale"`
+	Crop         Crop     `json:"crop"`
+	Visible      bool     `json:"visible"`
+	Muted        bool     `json:"muted"`
+	Locked       bool     `json:"locked"`
+	Bounds       Bounds   `json:"bounds"`
+	SourceWidth  int      `json:"sourceWidth"`
+	SourceHeight int      `json:"sourceHeight"`
+	Width        float64  `json:"width"`
+	Height       float64  `json:"height"`
+}
+
+// SceneItemPropertiesUpdate holds the properties to change with
+// SetSceneItemProperties. Nil fields are left unchanged.
+type SceneItemPropertiesUpdate struct {
+	Position *Position `json:"position,omitempty"`
+	Rotation *float64  `json:"rotation,omitempty"`
+	Scale    *Scale    `json:"scale,omitempty"`
+	Crop     *Crop     `json:"crop,omitempty"`
+	Visible  *bool     `json:"visible,omitempty"`
+	Locked   *bool     `json:"locked,omitempty"`
+	Bounds   *Bounds   `json:"bounds,omitempty"`
+}
+
+type GetSceneItemPropertiesResponse struct {
+	SceneItemProperties
+	responseBase
+}
+
+// sceneItemTransformV5 is the transform of a scene item in the 5.x
+// protocol.
+type sceneItemTransformV5 struct {
+	PositionX       float64 `json:"positionX"`
+	PositionY       float64 `json:"positionY"`
+	Alignment       int     `json:"alignment"`
+	Rotation        float64 `json:"rotation"`
+	ScaleX          float64 `json:"scaleX"`
+	ScaleY          float64 `json:"scaleY"`
+	CropTop         int     `json:"cropTop"`
+	CropRight       int     `json:"cropRight"`
+	CropBottom      int     `json:"cropBottom"`
+	CropLeft        int     `json:"cropLeft"`
+	BoundsType      string  `json:"boundsType"`
+	BoundsAlignment int     `json:"boundsAlignment"`
+	BoundsWidth     float64 `json:"boundsWidth"`
+	BoundsHeight    float64 `json:"boundsHeight"`
+	SourceWidth     float64 `json:"sourceWidth"`
+	SourceHeight    float64 `json:"sourceHeight"`
+	Width           float64 `json:"width"`
+	Height          float64 `json:"height"`
+}
+
+// unmarshalV5 decodes the transform of the item. The name, id,
+// visibility and lock are set by GetSceneItemProperties.
+func (r *GetSceneItemPropertiesResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		SceneItemTransform sceneItemTransformV5 `json:"sceneItemTransform"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	t := aux.SceneItemTransform
+	r.Position = Position{X: t.PositionX, Y: t.PositionY, Alignment: t.Alignment}
+	r.Rotation = t.Rotation
+	r.Scale = Scale{X: t.ScaleX, Y: t.ScaleY}
+	r.Crop = Crop{Top: t.CropTop, Right: t.CropRight, Bottom: t.CropBottom, Left: t.CropLeft}
+	r.Bounds = Bounds{Type: t.BoundsType, Alignment: t.BoundsAlignment, X: t.BoundsWidth, Y: t.BoundsHeight}
+	r.SourceWidth = int(t.SourceWidth)
+	r.SourceHeight = int(t.SourceHeight)
+	r.Width = t.Width
+	r.Height = t.Height
+	return nil
+}
+
+// transformV5 returns the 5.x sceneItemTransform of the non-nil
+// properties. The visibility and lock are separate requests in 5.x.
+func (u SceneItemPropertiesUpdate) transformV5() map[string]interface{} {
+	t := map[string]interface{}{}
+	if u.Position != nil {
+		t["positionX"] = u.Position.X
+		t["positionY"] = u.Position.Y
+		t["alignment"] = u.Position.Alignment
+	}
+	if u.Rotation != nil {
+		t["rotation"] = *u.Rotation
+	}
+	if u.Scale != nil {
+		t["scaleX"] = u.Scale.X
+		t["scaleY"] = u.Scale.Y
+	}
+	if u.Crop != nil {
+		t["cropTop"] = u.Crop.Top
+		t["cropRight"] = u.Crop.Right
+		t["cropBottom"] = u.Crop.Bottom
+		t["cropLeft"] = u.Crop.Left
+	}
+	if u.Bounds != nil {
+		t["boundsType"] = u.Bounds.Type
+		t["boundsAlignment"] = u.Bounds.Alignment
+		t["boundsWidth"] = u.Bounds.X
+		t["boundsHeight"] = u.Bounds.Y
+	}
+	return t
+}
+
+// requestsV5 returns the 5.x requests changing the non-nil
+// properties of the item of the scene. An update without any
+// property is an empty transform change.
+func (u SceneItemPropertiesUpdate) requestsV5(sceneName string, id int) []requestV5 {
+	var requests []requestV5
+	if transform := u.transformV5(); len(transform) > 0 || (u.Visible == nil && u.Locked == nil) {
+		requests = append(requests, requestV5{
+			RequestType: "SetSceneItemTransform",
+			RequestData: map[string]interface{}{
+				"sceneName":          sceneName,
+				"sceneItemId":        id,
+				"sceneItemTransform": transform,
+			},
+		})
+	}
+	if u.Visible != nil {
+		requests = append(requests, setSceneItemEnabledV5(sceneName, id, *u.Visible))
+	}
+	if u.Locked != nil {
+		requests = append(requests, requestV5{
+			RequestType: "SetSceneItemLocked",
+			RequestData: map[string]interface{}{
+				"sceneName":       sceneName,
+				"sceneItemId":     id,
+				"sceneItemLocked": *u.Locked,
+			},
+		})
+	}
+	return requests
+}
+
+// setSceneItemEnabledV5 returns the 5.x request showing or hiding the
+// item of the scene.
+func setSceneItemEnabledV5(sceneName string, id int, enabled bool) requestV5 {
+	return requestV5{
+		RequestType: "SetSceneItemEnabled",
+		RequestData: map[string]interface{}{
+			"sceneName":        sceneName,
+			"sceneItemId":      id,
+			"sceneItemEnabled": enabled,
+		},
+	}
+}
+
+type DuplicateSceneItemResponse struct {
+	Scene string       `json:"scene"`
+	Item  SceneItemRef `json:"item"`
+	responseBase
+}
+
+type AddSceneItemResponse struct {
+	ItemID int `json:"itemId"`
+	responseBase
+}
+
+func (r *AddSceneItemResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		SceneItemID int `json:"sceneItemId"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.ItemID = aux.SceneItemID
+	return nil
+}
+
+// unmarshalV5 decodes the id of the copy. The scene and the name of
+// the item are set by DuplicateSceneItem.
+func (r *DuplicateSceneItemResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		SceneItemID int `json:"sceneItemId"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Item.ID = aux.SceneItemID
+	return nil
+}
+
+// sceneItemStateV5 is the response to the 5.x requests on the state
+// of a scene item, such as GetSceneItemEnabled.
+type sceneItemStateV5 struct {
+	SceneItemID      int  `json:"sceneItemId"`
+	SceneItemEnabled bool `json:"sceneItemEnabled"`
+	SceneItemLocked  bool `json:"sceneItemLocked"`
+	responseBase
+}
+
+func forgeGetSceneItemProperties(sceneName, item string) request {
+	type getSceneItemProperties struct {
+		requestBase
+		SceneName string `json:"scene-name,omitempty"`
+		Item      string `json:"item"`
+	}
+	return &getSceneItemProperties{
+		requestBase: requestBase{
+			RequestType: "GetSceneItemProperties",
+			rType:       &GetSceneItemPropertiesResponse{},
+		},
+		SceneName: sceneName,
+		Item:      item,
+	}
+}
+
+func forgeSetSceneItemProperties(sceneName, item string, props SceneItemPropertiesUpdate) request {
+	type setSceneItemProperties struct {
+		requestBase
+		SceneName string `json:"scene-name,omitempty"`
+		Item      string `json:"item"`
+		SceneItemPropertiesUpdate
+	}
+	return &setSceneItemProperties{
+		requestBase: requestBase{
+			RequestType: "SetSceneItemProperties",
+			rType:       &responseBase{},
+		},
+		SceneName:                 sceneName,
+		Item:                      item,
+		SceneItemPropertiesUpdate: props,
+	}
+}
+
+func forgeSetSceneItemRender(sceneName, source string, render bool) request {
+	type setSceneItemRender struct {
+		requestBase
+		SceneName string `json:"scene-name,omitempty"`
+		Source    string `json:"source"`
+		Render    bool   `json:"render"`
+	}
+	return &setSceneItemRender{
+		requestBase: requestBase{
+			RequestType: "SetSceneItemRender",
+			rType:       &responseBase{},
+		},
+		SceneName: sceneName,
+		Source:    source,
+		Render:    render,
+	}
+}
+
+func forgeReorderSceneItems(sceneName string, items []SceneItemRef) request {
+	type reorderSceneItems struct {
+		requestBase
+		Scene string         `json:"scene,omitempty"`
+		Items []SceneItemRef `json:"items"`
+	}
+	return &reorderSceneItems{
+		requestBase: requestBase{
+			RequestType: "ReorderSceneItems",
+			rType:       &responseBase{},
+		},
+		Scene: sceneName,
+		Items: items,
+	}
+}
+
+func forgeDuplicateSceneItem(fromScene, toScene string, item SceneItemRef) request {
+	type duplicateSceneItem struct {
+		requestBase
+		FromScene string       `json:"fromScene,omitempty"`
+		ToScene   string       `json:"toScene,omitempty"`
+		Item      SceneItemRef `json:"item"`
+	}
+	return &duplicateSceneItem{
+		requestBase: requestBase{
+			RequestType: "DuplicateSceneItem",
+			rType:       &DuplicateSceneItemResponse{},
+		},
+		FromScene: fromScene,
+		ToScene:   toScene,
+		Item:      item,
+	}
+}
+
+func forgeDeleteSceneItem(sceneName string, item SceneItemRef) request {
+	type deleteSceneItem struct {
+		requestBase
+		Scene string       `json:"scene,omitempty"`
+		Item  SceneItemRef `json:"item"`
+	}
+	return &deleteSceneItem{
+		requestBase: requestBase{
+			RequestType: "DeleteSceneItem",
+			rType:       &responseBase{},
+		},
+		Scene: sceneName,
+		Item:  item,
+	}
+}
+
+func forgeAddSceneItem(sceneName, sourceName string, visible bool) request {
+	type addSceneItem struct {
+		requestBase
+		SceneName  string `json:"sceneName"`
+		SourceName string `json:"sourceName"`
+		SetVisible bool   `json:"setVisible"`
+	}
+	return &addSceneItem{
+		requestBase: requestBase{
+			RequestType: "AddSceneItem",
+			rType:       &AddSceneItemResponse{},
+			v5Type:      "CreateSceneItem",
+			v5Data: map[string]interface{}{
+				"sceneName":        sceneName,
+				"sourceName":       sourceName,
+				"sceneItemEnabled": visible,
+			},
+		},
+		SceneName:  sceneName,
+		SourceName: sourceName,
+		SetVisible: visible,
+	}
+}
+
+// submitV5Ctx sends a 5.x request which has no 4.x equivalent, used
+// to implement the 4.x scene item requests.
+func (c *Client) submitV5Ctx(ctx context.Context, requestType string, data interface{}, resp response) error {
+	r := forgeRequestWithExpectedResponse(requestType, resp)
+	r.setV5Request(requestType, data)
+	_, err := c.submitRequestCtx(ctx, r)
+	return err
+}
+
+// sceneItemV5 returns the scene and the id of the item for the 5.x
+// requests, which address the items by id in a named scene. An empty
+// sceneName is the current program scene, and an item without id is
+// looked up by name.
+func (c *Client) sceneItemV5(ctx context.Context, sceneName string, item SceneItemRef) (string, int, error) {
+	if len(sceneName) == 0 {
+		var err error
+		if sceneName, err = c.currentSceneV5(ctx); err != nil {
+			return "", 0, err
+		}
+	}
+	if item.ID != 0 {
+		return sceneName, item.ID, nil
+	}
+	resp := &sceneItemStateV5{}
+	err := c.submitV5Ctx(ctx, "GetSceneItemId", map[string]interface{}{
+		"sceneName":  sceneName,
+		"sourceName": item.Name,
+	}, resp)
+	if err != nil {
+		return "", 0, err
+	}
+	return sceneName, resp.SceneItemID, nil
+}
+
+// currentSceneV5 returns the name of the current program scene,
+// without its items.
+func (c *Client) currentSceneV5(ctx context.Context) (string, error) {
+	resp := &GetCurrentSceneResponse{}
+	if err := c.submitV5Ctx(ctx, "GetCurrentProgramScene", nil, resp); err != nil {
+		return "", err
+	}
+	return resp.Name, nil
+}
+
+// sceneSourcesV5 returns the items of the scene, from top to bottom.
+func (c *Client) sceneSourcesV5(ctx context.Context, sceneName string) ([]Source, error) {
+	resp := &sceneItemListV5{}
+	err := c.submitV5Ctx(ctx, "GetSceneItemList", map[string]interface{}{
+		"sceneName": sceneName,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Sources, nil
+}
+
+// GetCurrentScene returns the current scene and its items. With
+// obs-websocket 5 the items are listed by a second request, and
+// neither their Volume nor Muted is reported.
+func (c *Client) GetCurrentScene() (*GetCurrentSceneResponse, error) {
+	return c.GetCurrentSceneCtx(context.Background())
+}
+
+func (c *Client) GetCurrentSceneCtx(ctx context.Context) (*GetCurrentSceneResponse, error) {
+	resp := &GetCurrentSceneResponse{}
+	r := forgeRequestWithExpectedResponse("GetCurrentScene", resp)
+	r.setV5Request("GetCurrentProgramScene", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	if c.Protocol() == ProtocolV5 {
+		sources, err := c.sceneSourcesV5(ctx, resp.Name)
+		if err != nil {
+			return nil, err
+		}
+		resp.Sources = sources
+	}
+	return resp, nil
+}
+
+// GetSceneItemProperties returns the properties of the item in the
+// scene. An empty sceneName means the current scene. obs-websocket 5
+// reports neither Muted nor the scale filter.
+func (c *Client) GetSceneItemProperties(sceneName, item string) (*GetSceneItemPropertiesResponse, error) {
+	return c.GetSceneItemPropertiesCtx(context.Background(), sceneName, item)
+}
+
+func (c *Client) GetSceneItemPropertiesCtx(ctx context.Context, sceneName, item string) (*GetSceneItemPropertiesResponse, error) {
+	r := forgeGetSceneItemProperties(sceneName, item)
+	if c.Protocol() != ProtocolV5 {
+		if _, err := c.submitRequestCtx(ctx, r); err != nil {
+			return nil, err
+		}
+		return r.responseType().(*GetSceneItemPropertiesResponse), nil
+	}
+
+	scene, id, err := c.sceneItemV5(ctx, sceneName, SceneItemRef{Name: item})
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{"sceneName": scene, "sceneItemId": id}
+	r.setV5Request("GetSceneItemTransform", data)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	enabled, locked := &sceneItemStateV5{}, &sceneItemStateV5{}
+	if err := c.submitV5Ctx(ctx, "GetSceneItemEnabled", data, enabled); err != nil {
+		return nil, err
+	}
+	if err := c.submitV5Ctx(ctx, "GetSceneItemLocked", data, locked); err != nil {
+		return nil, err
+	}
+	resp := r.responseType().(*GetSceneItemPropertiesResponse)
+	resp.Name = item
+	resp.ItemID = id
+	resp.Visible = enabled.SceneItemEnabled
+	resp.Locked = locked.SceneItemLocked
+	return resp, nil
+}
+
+// SetSceneItemProperties changes the non-nil properties of the item
+// in the scene. An empty sceneName means the current scene. With
+// obs-websocket 5 the transform, visibility and lock are changed by
+// separate requests, and the scale filter is ignored.
+func (c *Client) SetSceneItemProperties(sceneName, item string, props SceneItemPropertiesUpdate) error {
+	return c.SetSceneItemPropertiesCtx(context.Background(), sceneName, item, props)
+}
+
+func (c *Client) SetSceneItemPropertiesCtx(ctx context.Context, sceneName, item string, props SceneItemPropertiesUpdate) error {
+	if c.Protocol() != ProtocolV5 {
+		_, err := c.submitRequestCtx(ctx, forgeSetSceneItemProperties(sceneName, item, props))
+		return err
+	}
+
+	scene, id, err := c.sceneItemV5(ctx, sceneName, SceneItemRef{Name: item})
+	if err != nil {
+		return err
+	}
+	for _, r := range props.requestsV5(scene, id) {
+		if err := c.submitV5Ctx(ctx, r.RequestType, r.RequestData, &responseBase{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetSceneItemRender shows or hides the source in the scene. An empty
+// sceneName means the current scene.
+func (c *Client) SetSceneItemRender(sceneName, source string, render bool) error {
+	return c.SetSceneItemRenderCtx(context.Background(), sceneName, source, render)
+}
+
+func (c *Client) SetSceneItemRenderCtx(ctx context.Context, sceneName, source string, render bool) error {
+	r := forgeSetSceneItemRender(sceneName, source, render)
+	if c.Protocol() == ProtocolV5 {
+		scene, id, err := c.sceneItemV5(ctx, sceneName, SceneItemRef{Name: source})
+		if err != nil {
+			return err
+		}
+		enabled := setSceneItemEnabledV5(scene, id, render)
+		r.setV5Request(enabled.RequestType, enabled.RequestData)
+	}
+	_, err := c.submitRequestCtx(ctx, r)
+	return err
+}
+
+// ReorderSceneItems changes the order of the items in the scene, from
+// top to bottom. An empty sceneName means the current scene. With
+// obs-websocket 5 the items are moved one request at a time, so items
+// should list every item of the scene.
+func (c *Client) ReorderSceneItems(sceneName string, items []SceneItemRef) error {
+	return c.ReorderSceneItemsCtx(context.Background(), sceneName, items)
+}
+
+func (c *Client) ReorderSceneItemsCtx(ctx context.Context, sceneName string, items []SceneItemRef) error {
+	if c.Protocol() != ProtocolV5 {
+		_, err := c.submitRequestCtx(ctx, forgeReorderSceneItems(sceneName, items))
+		return err
+	}
+
+	// 5.x moves the items one at a time, by index from the bottom:
+	// placing them from the bottom up leaves the placed ones in place
+	for i := len(items) - 1; i >= 0; i-- {
+		scene, id, err := c.sceneItemV5(ctx, sceneName, items[i])
+		if err != nil {
+			return err
+		}
+		sceneName = scene
+		err = c.submitV5Ctx(ctx, "SetSceneItemIndex", map[string]interface{}{
+			"sceneName":      scene,
+			"sceneItemId":    id,
+			"sceneItemIndex": len(items) - 1 - i,
+		}, &responseBase{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DuplicateSceneItem copies the item of fromScene into toScene. Empty
+// scene names mean the current scene.
+func (c *Client) DuplicateSceneItem(fromScene, toScene string, item SceneItemRef) (*DuplicateSceneItemResponse, error) {
+	return c.DuplicateSceneItemCtx(context.Background(), fromScene, toScene, item)
+}
+
+func (c *Client) DuplicateSceneItemCtx(ctx context.Context, fromScene, toScene string, item SceneItemRef) (*DuplicateSceneItemResponse, error) {
+	r := forgeDuplicateSceneItem(fromScene, toScene, item)
+	if c.Protocol() == ProtocolV5 {
+		scene, id, err := c.sceneItemV5(ctx, fromScene, item)
+		if err != nil {
+			return nil, err
+		}
+		if len(toScene) == 0 {
+			if toScene, err = c.currentSceneV5(ctx); err != nil {
+				return nil, err
+			}
+		}
+		r.setV5Request("DuplicateSceneItem", map[string]interface{}{
+			"sceneName":            scene,
+			"sceneItemId":          id,
+			"destinationSceneName": toScene,
+		})
+	}
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	resp := r.responseType().(*DuplicateSceneItemResponse)
+	if c.Protocol() == ProtocolV5 {
+		resp.Scene = toScene
+		resp.Item.Name = item.Name
+	}
+	return resp, nil
+}
+
+// DeleteSceneItem removes the item from the scene. An empty sceneName
+// means the current scene.
+func (c *Client) DeleteSceneItem(sceneName string, item SceneItemRef) error {
+	return c.DeleteSceneItemCtx(context.Background(), sceneName, item)
+}
+
+func (c *Client) DeleteSceneItemCtx(ctx context.Context, sceneName string, item SceneItemRef) error {
+	r := forgeDeleteSceneItem(sceneName, item)
+	if c.Protocol() == ProtocolV5 {
+		scene, id, err := c.sceneItemV5(ctx, sceneName, item)
+		if err != nil {
+			return err
+		}
+		r.setV5Request("RemoveSceneItem", map[string]interface{}{
+			"sceneName":   scene,
+			"sceneItemId": id,
+		})
+	}
+	_, err := c.submitRequestCtx(ctx, r)
+	return err
+}
+
+// AddSceneItem adds the existing source to the scene, and returns
+// the id of the new item.
+func (c *Client) AddSceneItem(sceneName, sourceName string, visible bool) (*AddSceneItemResponse, error) {
+	return c.AddSceneItemCtx(context.Background(), sceneName, sourceName, visible)
+}
+
+func (c *Client) AddSceneItemCtx(ctx context.Context, sceneName, sourceName string, visible bool) (*AddSceneItemResponse, error) {
+	r := forgeAddSceneItem(sceneName, sourceName, visible)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.responseType().(*AddSceneItemResponse), nil
+}
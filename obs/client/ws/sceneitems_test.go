@@ -0,0 +1,240 @@
+package ws
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type SceneItemSuite struct{}
+
+var _ = Suite(&SceneItemSuite{})
+
+func (s *SceneItemSuite) TestSceneItemRequests(c *C) {
+	visible := false
+	rotation := 90.0
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetCurrentScene()
+			},
+			request:  `{"request-type":"GetCurrentScene"}`,
+			response: `{"name":"Live","sources":[{"alignment":5,"cx":1920.0,"cy":1080.0,"id":3,"locked":false,"muted":false,"name":"Cam","render":true,"source_cx":1920,"source_cy":1080,"type":"dshow_input","volume":1.0,"x":0.0,"y":0.0}]}`,
+			expected: &GetCurrentSceneResponse{
+				Scene: Scene{
+					Name: "Live",
+					Sources: []Source{{
+						Name:      "Cam",
+						ID:        3,
+						Type:      "dshow_input",
+						Volume:    1,
+						Render:    true,
+						Cx:        1920,
+						Cy:        1080,
+						SourceCx:  1920,
+						SourceCy:  1080,
+						Alignment: 5,
+					}},
+				},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetSceneItemProperties("Live", "Cam")
+			},
+			request:  `{"request-type":"GetSceneItemProperties","scene-name":"Live","item":"Cam"}`,
+			response: `{"name":"Cam","itemId":3,"position":{"x":1280.0,"y":720.0,"alignment":5},"rotation":0.0,"scale":{"x":0.333,"y":0.333,"filter":"OBS_SCALE_DISABLE"},"crop":{"top":0,"right":0,"bottom":10,"left":0},"visible":true,"muted":false,"locked":true,"bounds":{"type":"OBS_BOUNDS_NONE","alignment":0,"x":0.0,"y":0.0},"sourceWidth":1920,"sourceHeight":1080,"width":639.36,"height":359.64}`,
+			expected: &GetSceneItemPropertiesResponse{
+				SceneItemProperties: SceneItemProperties{
+					Name:         "Cam",
+					ItemID:       3,
+					Position:     Position{X: 1280, Y: 720, Alignment: 5},
+					Scale:        Scale{X: 0.333, Y: 0.333, Filter: "OBS_SCALE_DISABLE"},
+					Crop:         Crop{Bottom: 10},
+					Visible:      true,
+					Locked:       true,
+					Bounds:       Bounds{Type: "OBS_BOUNDS_NONE"},
+					SourceWidth:  1920,
+					SourceHeight: 1080,
+					Width:        639.36,
+					Height:       359.64,
+				},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetSceneItemProperties("", "Cam", SceneItemPropertiesUpdate{
+					Position: &Position{X: 10, Y: 20},
+					Rotation: &rotation,
+					Visible:  &visible,
+				})
+			},
+			request:  `{"request-type":"SetSceneItemProperties","item":"Cam","position":{"x":10,"y":20,"alignment":0},"rotation":90,"visible":false}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetSceneItemRender("Live", "Cam", false)
+			},
+			request:  `{"request-type":"SetSceneItemRender","scene-name":"Live","source":"Cam","render":false}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.ReorderSceneItems("Live", []SceneItemRef{{Name: "Cam"}, {ID: 1}})
+			},
+			request:  `{"request-type":"ReorderSceneItems","scene":"Live","items":[{"name":"Cam"},{"id":1}]}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.DuplicateSceneItem("Live", "BRB", SceneItemRef{Name: "Cam", ID: 3})
+			},
+			request:  `{"request-type":"DuplicateSceneItem","fromScene":"Live","toScene":"BRB","item":{"name":"Cam","id":3}}`,
+			response: `{"scene":"BRB","item":{"id":7,"name":"Cam"}}`,
+			expected: &DuplicateSceneItemResponse{
+				Scene:        "BRB",
+				Item:         SceneItemRef{Name: "Cam", ID: 7},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.DeleteSceneItem("BRB", SceneItemRef{ID: 7})
+			},
+			request:  `{"request-type":"DeleteSceneItem","scene":"BRB","item":{"id":7}}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.AddSceneItem("BRB", "Timer", true)
+			},
+			request:  `{"request-type":"AddSceneItem","sceneName":"BRB","sourceName":"Timer","setVisible":true}`,
+			response: `{"itemId":8}`,
+			expected: &AddSceneItemResponse{
+				ItemID:       8,
+				responseBase: recordedOK,
+			},
+		},
+	})
+}
+
+func (s *SceneItemSuite) TestSceneItemRequestsV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	answers := map[string]map[string]interface{}{
+		"GetSceneItemTransform": {"sceneItemTransform": map[string]interface{}{
+			"positionX": 10, "positionY": 20, "alignment": 5, "rotation": 90,
+			"scaleX": 0.5, "scaleY": 0.5, "cropLeft": 4, "boundsType": "OBS_BOUNDS_NONE",
+			"sourceWidth": 1920, "sourceHeight": 1080, "width": 960, "height": 540,
+		}},
+		"GetSceneItemEnabled":   {"sceneItemEnabled": true},
+		"GetSceneItemLocked":    {"sceneItemLocked": true},
+		"SetSceneItemTransform": nil,
+		"SetSceneItemLocked":    nil,
+		"SetSceneItemIndex":     nil,
+		"DuplicateSceneItem":    {"sceneItemId": 7},
+		"RemoveSceneItem":       nil,
+		"CreateSceneItem":       {"sceneItemId": 8},
+	}
+	for requestType, answer := range answers {
+		answer := answer
+		server.Handle(requestType, func(map[string]interface{}) (map[string]interface{}, error) {
+			return answer, nil
+		})
+	}
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	props, err := client.GetSceneItemProperties("", "Game")
+	c.Assert(err, IsNil)
+	c.Check(props.SceneItemProperties, DeepEquals, SceneItemProperties{
+		Name:         "Game",
+		ItemID:       2,
+		Position:     Position{X: 10, Y: 20, Alignment: 5},
+		Rotation:     90,
+		Scale:        Scale{X: 0.5, Y: 0.5},
+		Crop:         Crop{Left: 4},
+		Visible:      true,
+		Locked:       true,
+		Bounds:       Bounds{Type: "OBS_BOUNDS_NONE"},
+		SourceWidth:  1920,
+		SourceHeight: 1080,
+		Width:        960,
+		Height:       540,
+	})
+
+	rotation, locked := 45.0, false
+	c.Check(client.SetSceneItemProperties("Live", "Cam", SceneItemPropertiesUpdate{
+		Rotation: &rotation,
+		Locked:   &locked,
+	}), IsNil)
+	c.Check(client.SetSceneItemRender("Live", "Cam", false), IsNil)
+	c.Check(client.ReorderSceneItems("Live", []SceneItemRef{{Name: "Game"}, {ID: 1}}), IsNil)
+	dup, err := client.DuplicateSceneItem("Live", "BRB", SceneItemRef{Name: "Cam"})
+	c.Assert(err, IsNil)
+	c.Check(dup.Scene, Equals, "BRB")
+	c.Check(dup.Item, Equals, SceneItemRef{Name: "Cam", ID: 7})
+	c.Check(client.DeleteSceneItem("BRB", SceneItemRef{ID: 7}), IsNil)
+	added, err := client.AddSceneItem("BRB", "Cam", true)
+	c.Assert(err, IsNil)
+	c.Check(added.ItemID, Equals, 8)
+
+	item := func(scene string, id float64) map[string]interface{} {
+		return map[string]interface{}{"sceneName": scene, "sceneItemId": id}
+	}
+	with := func(fields map[string]interface{}, name string, value interface{}) map[string]interface{} {
+		fields[name] = value
+		return fields
+	}
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetCurrentProgramScene", Fields: nil},
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "Live", "sourceName": "Game"}},
+		{Type: "GetSceneItemTransform", Fields: item("Live", 2)},
+		{Type: "GetSceneItemEnabled", Fields: item("Live", 2)},
+		{Type: "GetSceneItemLocked", Fields: item("Live", 2)},
+
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "Live", "sourceName": "Cam"}},
+		{Type: "SetSceneItemTransform", Fields: with(item("Live", 1), "sceneItemTransform", map[string]interface{}{"rotation": 45.0})},
+		{Type: "SetSceneItemLocked", Fields: with(item("Live", 1), "sceneItemLocked", false)},
+
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "Live", "sourceName": "Cam"}},
+		{Type: "SetSceneItemEnabled", Fields: with(item("Live", 1), "sceneItemEnabled", false)},
+
+		{Type: "SetSceneItemIndex", Fields: with(item("Live", 1), "sceneItemIndex", 0.0)},
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "Live", "sourceName": "Game"}},
+		{Type: "SetSceneItemIndex", Fields: with(item("Live", 2), "sceneItemIndex", 1.0)},
+
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "Live", "sourceName": "Cam"}},
+		{Type: "DuplicateSceneItem", Fields: with(item("Live", 1), "destinationSceneName", "BRB")},
+		{Type: "RemoveSceneItem", Fields: item("BRB", 7)},
+		{Type: "CreateSceneItem", Fields: map[string]interface{}{"sceneName": "BRB", "sourceName": "Cam", "sceneItemEnabled": true}},
+	})
+}
+
+func (s *SceneItemSuite) TestGetCurrentSceneV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	server.SetScenes(wstest.Scene{Name: "Live", Items: []wstest.SceneItem{
+		{ID: 1, Name: "Cam", Visible: false},
+		{ID: 2, Name: "Game", Visible: true},
+	}})
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	scene, err := client.GetCurrentScene()
+	c.Assert(err, IsNil)
+	c.Check(scene.Scene, DeepEquals, Scene{
+		Name: "Live",
+		Sources: []Source{
+			{Name: "Cam", ID: 1, Render: false},
+			{Name: "Game", ID: 2, Render: true},
+		},
+	})
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetCurrentProgramScene", Fields: nil},
+		{Type: "GetSceneItemList", Fields: map[string]interface{}{"sceneName": "Live"}},
+	})
+}
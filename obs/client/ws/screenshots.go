@@ -0,0 +1,223 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	// formats returned by obs-websocket
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ScreenshotOptions configure TakeSourceScreenshot.
+type ScreenshotOptions struct {
+	// Format is the image format, like "png" or "jpg". It defaults
+	// to "png", or to the extension of SaveToFilePath.
+	Format string
+	// Width and Height scale the screenshot. If only one is set, the
+	// aspect ratio is kept.
+	Width  int
+	Height int
+	// CompressionQuality is between 1 and 100, zero keeps the
+	// default of the format.
+	CompressionQuality int
+	// SaveToFilePath saves the screenshot to this path on the host of
+	// the OBS instance instead of embedding it in the response.
+	SaveToFilePath string
+}
+
+// format returns the image format to request.
+func (o ScreenshotOptions) format() string {
+	if len(o.Format) > 0 {
+		return o.Format
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(o.SaveToFilePath), "."); len(ext) > 0 {
+		return ext
+	}
+	return "png"
+}
+
+// ErrNoEmbeddedImage is returned when decoding a screenshot which was
+// saved to a file.
+type ErrNoEmbeddedImage struct{}
+
+func (e ErrNoEmbeddedImage) Error() string {
+	return "obsws: screenshot has no embedded image"
+}
+
+type TakeSourceScreenshotResponse struct {
+	SourceName string `json:"sourceName"`
+	// Img is the embedded image as a data URI
+	Img string `json:"img"`
+	// ImageFile is the path of the saved image on the host of the OBS
+	// instance
+	ImageFile string `json:"imageFile"`
+	responseBase
+}
+
+func (r *TakeSourceScreenshotResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		ImageData string `json:"imageData"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Img = aux.ImageData
+	return nil
+}
+
+// Data decodes the embedded image.
+func (r *TakeSourceScreenshotResponse) Data() ([]byte, error) {
+	if len(r.Img) == 0 {
+		return nil, ErrNoEmbeddedImage{}
+	}
+	// data:image/png;base64,iVBORw0...
+	idx := strings.Index(r.Img, ";base64,")
+	if strings.HasPrefix(r.Img, "data:") == false || idx < 0 {
+		return nil, fmt.Errorf("obsws: invalid image data URI")
+	}
+	return base64.StdEncoding.DecodeString(r.Img[idx+len(";base64,"):])
+}
+
+// Image decodes the embedded image, if it is a PNG or a JPEG.
+func (r *TakeSourceScreenshotResponse) Image() (image.Image, error) {
+	data, err := r.Data()
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+func forgeTakeSourceScreenshot(sourceName string, opts ScreenshotOptions) request {
+	type takeSourceScreenshot struct {
+		requestBase
+		SourceName         string `json:"sourceName,omitempty"`
+		EmbedPictureFormat string `json:"embedPictureFormat,omitempty"`
+		SaveToFilePath     string `json:"saveToFilePath,omitempty"`
+		FileFormat         string `json:"fileFormat,omitempty"`
+		CompressionQuality int    `json:"compressionQuality,omitempty"`
+		Width              int    `json:"width,omitempty"`
+		Height             int    `json:"height,omitempty"`
+	}
+	r := &takeSourceScreenshot{
+		requestBase: requestBase{
+			RequestType: "TakeSourceScreenshot",
+			rType:       &TakeSourceScreenshotResponse{},
+		},
+		SourceName:         sourceName,
+		SaveToFilePath:     opts.SaveToFilePath,
+		CompressionQuality: opts.CompressionQuality,
+		Width:              opts.Width,
+		Height:             opts.Height,
+	}
+
+	v5Data := map[string]interface{}{
+		"sourceName":  sourceName,
+		"imageFormat": opts.format(),
+	}
+	if opts.Width > 0 {
+		v5Data["imageWidth"] = opts.Width
+	}
+	if opts.Height > 0 {
+		v5Data["imageHeight"] = opts.Height
+	}
+	if opts.CompressionQuality != 0 {
+		v5Data["imageCompressionQuality"] = opts.CompressionQuality
+	}
+
+	if len(opts.SaveToFilePath) > 0 {
+		r.FileFormat = opts.Format
+		v5Data["imageFilePath"] = opts.SaveToFilePath
+		r.setV5Request("SaveSourceScreenshot", v5Data)
+	} else {
+		r.EmbedPictureFormat = opts.format()
+		r.setV5Request("GetSourceScreenshot", v5Data)
+	}
+	// obs-websocket 5 has no default source, the Client looks up the
+	// current scene instead
+	if len(sourceName) == 0 {
+		r.setV5Request("", nil)
+	}
+	return r
+}
+
+// TakeSourceScreenshot takes a screenshot of the source, or of the
+// current scene if sourceName is empty. The image is embedded in the
+// response, unless opts.SaveToFilePath is set. With obs-websocket 5
+// the current scene is looked up by a first request.
+func (c *Client) TakeSourceScreenshot(sourceName string, opts ScreenshotOptions) (*TakeSourceScreenshotResponse, error) {
+	return c.TakeSourceScreenshotCtx(context.Background(), sourceName, opts)
+}
+
+func (c *Client) TakeSourceScreenshotCtx(ctx context.Context, sourceName string, opts ScreenshotOptions) (*TakeSourceScreenshotResponse, error) {
+	v5 := c.Protocol() == ProtocolV5
+	if v5 == true && len(sourceName) == 0 {
+		var err error
+		if sourceName, err = c.currentSceneV5(ctx); err != nil {
+			return nil, err
+		}
+	}
+	r := forgeTakeSourceScreenshot(sourceName, opts)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	resp := r.responseType().(*TakeSourceScreenshotResponse)
+	if v5 == true {
+		resp.SourceName = sourceName
+		resp.ImageFile = opts.SaveToFilePath
+	}
+	return resp, nil
+}
+
+// snapshotFileName returns the name of a snapshot of sourceName taken
+// at t.
+func snapshotFileName(sourceName, format string, t time.Time) string {
+	if len(sourceName) == 0 {
+		sourceName = "program"
+	}
+	sourceName = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == filepath.Separator {
+			return '_'
+		}
+		return r
+	}, sourceName)
+	return fmt.Sprintf("%s-%s.%s", sourceName, t.Format("20060102-150405.000"), format)
+}
+
+// SnapshotEvery takes a screenshot of the source every interval and
+// writes it into dir on this host, until ctx is done or a screenshot
+// fails. Files are named after the source and the time they were
+// taken. opts.SaveToFilePath is ignored.
+func (c *Client) SnapshotEvery(ctx context.Context, sourceName, dir string, interval time.Duration, opts ScreenshotOptions) error {
+	opts.SaveToFilePath = ""
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		resp, err := c.TakeSourceScreenshotCtx(ctx, sourceName, opts)
+		if err != nil {
+			return err
+		}
+		data, err := resp.Data()
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, snapshotFileName(sourceName, opts.format(), time.Now()))
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
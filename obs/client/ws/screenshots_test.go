@@ -0,0 +1,146 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type ScreenshotSuite struct{}
+
+var _ = Suite(&ScreenshotSuite{})
+
+// testImage returns a 2x1 PNG as a data URI.
+func testImage(c *C) string {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 0x64, G: 0x41, B: 0xa5, A: 0xff})
+	var buf bytes.Buffer
+	c.Assert(png.Encode(&buf, img), IsNil)
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func (s *ScreenshotSuite) TestScreenshotRequests(c *C) {
+	img := testImage(c)
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.TakeSourceScreenshot("Cam", ScreenshotOptions{Width: 320})
+			},
+			request:  `{"request-type":"TakeSourceScreenshot","sourceName":"Cam","embedPictureFormat":"png","width":320}`,
+			response: `{"sourceName":"Cam","img":"` + img + `"}`,
+			expected: &TakeSourceScreenshotResponse{
+				SourceName:   "Cam",
+				Img:          img,
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.TakeSourceScreenshot("", ScreenshotOptions{
+					SaveToFilePath:     "C:\\thumbs\\live.jpg",
+					CompressionQuality: 80,
+				})
+			},
+			request:  `{"request-type":"TakeSourceScreenshot","saveToFilePath":"C:\\thumbs\\live.jpg","compressionQuality":80}`,
+			response: `{"sourceName":"Live","imageFile":"C:\\thumbs\\live.jpg"}`,
+			expected: &TakeSourceScreenshotResponse{
+				SourceName:   "Live",
+				ImageFile:    "C:\\thumbs\\live.jpg",
+				responseBase: recordedOK,
+			},
+		},
+	})
+}
+
+func (s *ScreenshotSuite) TestScreenshotRequestsV5(c *C) {
+	msg, err := protocolV5{}.marshalRequest(forgeTakeSourceScreenshot("Cam", ScreenshotOptions{SaveToFilePath: "/tmp/cam.jpg"}), "1")
+	c.Assert(err, IsNil)
+	c.Check(msg, DeepEquals, outgoingMessageV5{
+		Op: opRequest,
+		D: requestV5{
+			RequestType: "SaveSourceScreenshot",
+			RequestID:   "1",
+			RequestData: map[string]interface{}{
+				"sourceName":    "Cam",
+				"imageFormat":   "jpg",
+				"imageFilePath": "/tmp/cam.jpg",
+			},
+		},
+	})
+
+	_, err = protocolV5{}.marshalRequest(forgeTakeSourceScreenshot("", ScreenshotOptions{}), "2")
+	c.Check(err, FitsTypeOf, ErrUnsupportedRequest{})
+}
+
+func (s *ScreenshotSuite) TestProgramScreenshotV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	resp, err := client.TakeSourceScreenshot("", ScreenshotOptions{Width: 64})
+	c.Assert(err, IsNil)
+	c.Check(resp.SourceName, Equals, "Live")
+	img, err := resp.Image()
+	c.Assert(err, IsNil)
+	c.Check(img.Bounds().Dx(), Equals, 64)
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetCurrentProgramScene", Fields: nil},
+		{Type: "GetSourceScreenshot", Fields: map[string]interface{}{
+			"sourceName":  "Live",
+			"imageFormat": "png",
+			"imageWidth":  64.0,
+		}},
+	})
+}
+
+func (s *ScreenshotSuite) TestScreenshotImage(c *C) {
+	resp := &TakeSourceScreenshotResponse{Img: testImage(c)}
+	img, err := resp.Image()
+	c.Assert(err, IsNil)
+	c.Check(img.Bounds(), Equals, image.Rect(0, 0, 2, 1))
+	r, g, b, _ := img.At(0, 0).RGBA()
+	c.Check([]uint32{r >> 8, g >> 8, b >> 8}, DeepEquals, []uint32{0x64, 0x41, 0xa5})
+
+	_, err = (&TakeSourceScreenshotResponse{ImageFile: "/tmp/cam.png"}).Data()
+	c.Check(err, Equals, ErrNoEmbeddedImage{})
+	_, err = (&TakeSourceScreenshotResponse{Img: "iVBORw0KGgo="}).Data()
+	c.Check(err, ErrorMatches, "obsws: invalid image data URI")
+}
+
+func (s *ScreenshotSuite) TestSnapshotFileName(c *C) {
+	t := time.Date(2021, 3, 4, 20, 15, 0, 0, time.UTC)
+	c.Check(snapshotFileName("Cam", "png", t), Equals, "Cam-20210304-201500.000.png")
+	c.Check(snapshotFileName("", "jpg", t), Equals, "program-20210304-201500.000.jpg")
+	c.Check(snapshotFileName("AC/DC", "png", t), Equals, "AC_DC-20210304-201500.000.png")
+}
+
+func (s *ScreenshotSuite) TestSnapshotEvery(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	server.AddInput(wstest.Input{Name: "Cam", Kind: "dshow_input"})
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	dir := c.MkDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	err := client.SnapshotEvery(ctx, "Cam", dir, 100*time.Millisecond, ScreenshotOptions{})
+	c.Check(err, Equals, context.DeadlineExceeded)
+
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Check(len(files) >= 2, Equals, true, Commentf("%d snapshots", len(files)))
+	for _, file := range files {
+		c.Check(file.Name(), Matches, `Cam-\d{8}-\d{6}\.\d{3}\.png`)
+	}
+}
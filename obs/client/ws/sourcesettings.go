@@ -0,0 +1,496 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SourceSettings are the settings of a source, which depend on its
+// type.
+type SourceSettings map[string]interface{}
+
+type Font struct {
+	Face  string `json:"face"`
+	Flags int    `json:"flags"`
+	Size  int    `json:"size"`
+	Style string `json:"style"`
+}
+
+// TextGDIPlusProperties are the properties of a "Text (GDI+)"
+// source. Colors are encoded as 0xAABBGGRR.
+type TextGDIPlusProperties struct {
+	Align           string  `json:"align"`
+	BkColor         int     `json:"bk_color"`
+	BkOpacity       int     `json:"bk_opacity"`
+	Chatlog         bool    `json:"chatlog"`
+	ChatlogLines    int     `json:"chatlog_lines"`
+	Color           int     `json:"color"`
+	Extents         bool    `json:"extents"`
+	ExtentsCx       int     `json:"extents_cx"`
+	ExtentsCy       int     `json:"extents_cy"`
+	File            string  `json:"file"`
+	ReadFromFile    bool    `json:"read_from_file"`
+	Font            Font    `json:"font"`
+	Gradient        bool    `json:"gradient"`
+	GradientColor   int     `json:"gradient_color"`
+	GradientDir     float64 `json:"gradient_dir"`
+	GradientOpacity int     `json:"gradient_opacity"`
+	Outline         bool    `json:"outline"`
+	OutlineColor    int     `json:"outline_color"`
+	OutlineSize     int     `json:"outline_size"`
+	OutlineOpacity  int     `json:"outline_opacity"`
+	Text            string  `json:"text"`
+	Valign          string  `json:"valign"`
+	Vertical        bool    `json:"vertical"`
+}
+
+// TextGDIPlusPropertiesUpdate holds the properties to change with
+// SetTextGDIPlusProperties. Nil fields are left unchanged.
+type TextGDIPlusPropertiesUpdate struct {
+	Align           *string  `json:"align,omitempty"`
+	BkColor         *int     `json:"bk_color,omitempty"`
+	BkOpacity       *int     `json:"bk_opacity,omitempty"`
+	Chatlog         *bool    `json:"chatlog,omitempty"`
+	ChatlogLines    *int     `json:"chatlog_lines,omitempty"`
+	Color           *int     `json:"color,omitempty"`
+	Extents         *bool    `json:"extents,omitempty"`
+	ExtentsCx       *int     `json:"extents_cx,omitempty"`
+	ExtentsCy       *int     `json:"extents_cy,omitempty"`
+	File            *string  `json:"file,omitempty"`
+	ReadFromFile    *bool    `json:"read_from_file,omitempty"`
+	Font            *Font    `json:"font,omitempty"`
+	Gradient        *bool    `json:"gradient,omitempty"`
+	GradientColor   *int     `json:"gradient_color,omitempty"`
+	GradientDir     *float64 `json:"gradient_dir,omitempty"`
+	GradientOpacity *int     `json:"gradient_opacity,omitempty"`
+	Outline         *bool    `json:"outline,omitempty"`
+	OutlineColor    *int     `json:"outline_color,omitempty"`
+	OutlineSize     *int     `json:"outline_size,omitempty"`
+	OutlineOpacity  *int     `json:"outline_opacity,omitempty"`
+	Text            *string  `json:"text,omitempty"`
+	Valign          *string  `json:"valign,omitempty"`
+	Vertical        *bool    `json:"vertical,omitempty"`
+}
+
+// TextFreetype2Properties are the properties of a "Text (FreeType
+// 2)" source. Colors are encoded as 0xAABBGGRR.
+type TextFreetype2Properties struct {
+	Color1      int    `json:"color1"`
+	Color2      int    `json:"color2"`
+	CustomWidth int    `json:"custom_width"`
+	DropShadow  bool   `json:"drop_shadow"`
+	Font        Font   `json:"font"`
+	FromFile    bool   `json:"from_file"`
+	LogMode     bool   `json:"log_mode"`
+	Outline     bool   `json:"outline"`
+	Text        string `json:"text"`
+	TextFile    string `json:"text_file"`
+	WordWrap    bool   `json:"word_wrap"`
+}
+
+// TextFreetype2PropertiesUpdate holds the properties to change with
+// SetTextFreetype2Properties. Nil fields are left unchanged.
+type TextFreetype2PropertiesUpdate struct {
+	Color1      *int    `json:"color1,omitempty"`
+	Color2      *int    `json:"color2,omitempty"`
+	CustomWidth *int    `json:"custom_width,omitempty"`
+	DropShadow  *bool   `json:"drop_shadow,omitempty"`
+	Font        *Font   `json:"font,omitempty"`
+	FromFile    *bool   `json:"from_file,omitempty"`
+	LogMode     *bool   `json:"log_mode,omitempty"`
+	Outline     *bool   `json:"outline,omitempty"`
+	Text        *string `json:"text,omitempty"`
+	TextFile    *string `json:"text_file,omitempty"`
+	WordWrap    *bool   `json:"word_wrap,omitempty"`
+}
+
+// BrowserSourceProperties are the properties of a browser source.
+type BrowserSourceProperties struct {
+	IsLocalFile bool   `json:"is_local_file"`
+	LocalFile   string `json:"local_file"`
+	URL         string `json:"url"`
+	CSS         string `json:"css"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	FPS         int    `json:"fps"`
+	Shutdown    bool   `json:"shutdown"`
+	Render      bool   `json:"render"`
+}
+
+// BrowserSourcePropertiesUpdate holds the properties to change with
+// SetBrowserSourceProperties. Nil fields are left unchanged.
+type BrowserSourcePropertiesUpdate struct {
+	IsLocalFile *bool   `json:"is_local_file,omitempty"`
+	LocalFile   *string `json:"local_file,omitempty"`
+	URL         *string `json:"url,omitempty"`
+	CSS         *string `json:"css,omitempty"`
+	Width       *int    `json:"width,omitempty"`
+	Height      *int    `json:"height,omitempty"`
+	FPS         *int    `json:"fps,omitempty"`
+	Shutdown    *bool   `json:"shutdown,omitempty"`
+	Render      *bool   `json:"render,omitempty"`
+}
+
+// unmarshalInputSettings decodes the inputSettings of a 5.x
+// GetInputSettings response into v. The 5.x protocol only reports
+// the settings which differ from their default.
+func unmarshalInputSettings(data []byte, v interface{}) error {
+	aux := struct {
+		InputSettings json.RawMessage `json:"inputSettings"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.InputSettings) == 0 {
+		return nil
+	}
+	return json.Unmarshal(aux.InputSettings, v)
+}
+
+type GetTextGDIPlusPropertiesResponse struct {
+	Source string `json:"source"`
+	TextGDIPlusProperties
+	responseBase
+}
+
+func (r *GetTextGDIPlusPropertiesResponse) unmarshalV5(data []byte) error {
+	return unmarshalInputSettings(data, &r.TextGDIPlusProperties)
+}
+
+type GetTextFreetype2PropertiesResponse struct {
+	Source string `json:"source"`
+	TextFreetype2Properties
+	responseBase
+}
+
+func (r *GetTextFreetype2PropertiesResponse) unmarshalV5(data []byte) error {
+	return unmarshalInputSettings(data, &r.TextFreetype2Properties)
+}
+
+type GetBrowserSourcePropertiesResponse struct {
+	Source string `json:"source"`
+	BrowserSourceProperties
+	responseBase
+}
+
+func (r *GetBrowserSourcePropertiesResponse) unmarshalV5(data []byte) error {
+	return unmarshalInputSettings(data, &r.BrowserSourceProperties)
+}
+
+type GetSourceSettingsResponse struct {
+	SourceName     string         `json:"sourceName"`
+	SourceType     string         `json:"sourceType"`
+	SourceSettings SourceSettings `json:"sourceSettings"`
+	responseBase
+}
+
+func (r *GetSourceSettingsResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		InputKind     string         `json:"inputKind"`
+		InputSettings SourceSettings `json:"inputSettings"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.SourceType = aux.InputKind
+	r.SourceSettings = aux.InputSettings
+	return nil
+}
+
+func newSetPropertiesBase(name, source string, props interface{}) requestBase {
+	return requestBase{
+		RequestType: name,
+		rType:       &responseBase{},
+		v5Type:      "SetInputSettings",
+		v5Data: map[string]interface{}{
+			"inputName":     source,
+			"inputSettings": props,
+		},
+	}
+}
+
+func forgeGetProperties(name, source string, resp response) request {
+	type getProperties struct {
+		requestBase
+		Source string `json:"source"`
+	}
+	return &getProperties{
+		requestBase: requestBase{
+			RequestType: name,
+			rType:       resp,
+			v5Type:      "GetInputSettings",
+			v5Data:      map[string]interface{}{"inputName": source},
+		},
+		Source: source,
+	}
+}
+
+func forgeSetTextGDIPlusProperties(source string, props TextGDIPlusPropertiesUpdate) request {
+	type setTextGDIPlusProperties struct {
+		requestBase
+		Source string `json:"source"`
+		TextGDIPlusPropertiesUpdate
+	}
+	return &setTextGDIPlusProperties{
+		requestBase:                 newSetPropertiesBase("SetTextGDIPlusProperties", source, props),
+		Source:                      source,
+		TextGDIPlusPropertiesUpdate: props,
+	}
+}
+
+func forgeSetTextFreetype2Properties(source string, props TextFreetype2PropertiesUpdate) request {
+	type setTextFreetype2Properties struct {
+		requestBase
+		Source string `json:"source"`
+		TextFreetype2PropertiesUpdate
+	}
+	return &setTextFreetype2Properties{
+		requestBase:                   newSetPropertiesBase("SetTextFreetype2Properties", source, props),
+		Source:                        source,
+		TextFreetype2PropertiesUpdate: props,
+	}
+}
+
+// settingsV5 returns the input settings of the update for
+// obs-websocket 5, where render is not a setting but the visibility of
+// the item, see SetBrowserSourcePropertiesCtx.
+func (u BrowserSourcePropertiesUpdate) settingsV5() BrowserSourcePropertiesUpdate {
+	u.Render = nil
+	return u
+}
+
+func forgeSetBrowserSourceProperties(source string, props BrowserSourcePropertiesUpdate) request {
+	type setBrowserSourceProperties struct {
+		requestBase
+		Source string `json:"source"`
+		BrowserSourcePropertiesUpdate
+	}
+	return &setBrowserSourceProperties{
+		requestBase:                   newSetPropertiesBase("SetBrowserSourceProperties", source, props.settingsV5()),
+		Source:                        source,
+		BrowserSourcePropertiesUpdate: props,
+	}
+}
+
+// forgeSetText forges the Set*Properties request name changing only
+// the text of the source.
+func forgeSetText(name, source, text string) request {
+	type setText struct {
+		requestBase
+		Source string `json:"source"`
+		Text   string `json:"text"`
+	}
+	return &setText{
+		requestBase: newSetPropertiesBase(name, source, map[string]interface{}{"text": text}),
+		Source:      source,
+		Text:        text,
+	}
+}
+
+func forgeGetSourceSettings(sourceName string) request {
+	type getSourceSettings struct {
+		requestBase
+		SourceName string `json:"sourceName"`
+	}
+	return &getSourceSettings{
+		requestBase: requestBase{
+			RequestType: "GetSourceSettings",
+			rType:       &GetSourceSettingsResponse{SourceName: sourceName},
+			v5Type:      "GetInputSettings",
+			v5Data:      map[string]interface{}{"inputName": sourceName},
+		},
+		SourceName: sourceName,
+	}
+}
+
+func forgeSetSourceSettings(sourceName string, settings SourceSettings) request {
+	type setSourceSettings struct {
+		requestBase
+		SourceName     string         `json:"sourceName"`
+		SourceSettings SourceSettings `json:"sourceSettings"`
+	}
+	return &setSourceSettings{
+		requestBase: requestBase{
+			RequestType: "SetSourceSettings",
+			rType:       &responseBase{},
+			v5Type:      "SetInputSettings",
+			v5Data: map[string]interface{}{
+				"inputName":     sourceName,
+				"inputSettings": settings,
+			},
+		},
+		SourceName:     sourceName,
+		SourceSettings: settings,
+	}
+}
+
+// GetTextGDIPlusProperties returns the properties of the "Text
+// (GDI+)" source. The 5.x protocol leaves the properties with their
+// default value unset.
+func (c *Client) GetTextGDIPlusProperties(source string) (*GetTextGDIPlusPropertiesResponse, error) {
+	return c.GetTextGDIPlusPropertiesCtx(context.Background(), source)
+}
+
+func (c *Client) GetTextGDIPlusPropertiesCtx(ctx context.Context, source string) (*GetTextGDIPlusPropertiesResponse, error) {
+	resp := &GetTextGDIPlusPropertiesResponse{Source: source}
+	if _, err := c.submitRequestCtx(ctx, forgeGetProperties("GetTextGDIPlusProperties", source, resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetTextGDIPlusProperties changes the non-nil properties of the
+// "Text (GDI+)" source. Use SetText to only change the text.
+func (c *Client) SetTextGDIPlusProperties(source string, props TextGDIPlusPropertiesUpdate) error {
+	return c.SetTextGDIPlusPropertiesCtx(context.Background(), source, props)
+}
+
+func (c *Client) SetTextGDIPlusPropertiesCtx(ctx context.Context, source string, props TextGDIPlusPropertiesUpdate) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetTextGDIPlusProperties(source, props))
+	return err
+}
+
+// GetTextFreetype2Properties returns the properties of the "Text
+// (FreeType 2)" source. The 5.x protocol leaves the properties with
+// their default value unset.
+func (c *Client) GetTextFreetype2Properties(source string) (*GetTextFreetype2PropertiesResponse, error) {
+	return c.GetTextFreetype2PropertiesCtx(context.Background(), source)
+}
+
+func (c *Client) GetTextFreetype2PropertiesCtx(ctx context.Context, source string) (*GetTextFreetype2PropertiesResponse, error) {
+	resp := &GetTextFreetype2PropertiesResponse{Source: source}
+	if _, err := c.submitRequestCtx(ctx, forgeGetProperties("GetTextFreetype2Properties", source, resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetTextFreetype2Properties changes the non-nil properties of the
+// "Text (FreeType 2)" source. Use SetText to only change the text.
+func (c *Client) SetTextFreetype2Properties(source string, props TextFreetype2PropertiesUpdate) error {
+	return c.SetTextFreetype2PropertiesCtx(context.Background(), source, props)
+}
+
+func (c *Client) SetTextFreetype2PropertiesCtx(ctx context.Context, source string, props TextFreetype2PropertiesUpdate) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetTextFreetype2Properties(source, props))
+	return err
+}
+
+// GetBrowserSourceProperties returns the properties of the browser
+// source. The 5.x protocol leaves the properties with their default
+// value unset.
+func (c *Client) GetBrowserSourceProperties(source string) (*GetBrowserSourcePropertiesResponse, error) {
+	return c.GetBrowserSourcePropertiesCtx(context.Background(), source)
+}
+
+func (c *Client) GetBrowserSourcePropertiesCtx(ctx context.Context, source string) (*GetBrowserSourcePropertiesResponse, error) {
+	resp := &GetBrowserSourcePropertiesResponse{Source: source}
+	if _, err := c.submitRequestCtx(ctx, forgeGetProperties("GetBrowserSourceProperties", source, resp)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetBrowserSourceProperties changes the non-nil properties of the
+// browser source. Render shows or hides the source in the current
+// scene; with obs-websocket 5 it is sent as a request of its own.
+func (c *Client) SetBrowserSourceProperties(source string, props BrowserSourcePropertiesUpdate) error {
+	return c.SetBrowserSourcePropertiesCtx(context.Background(), source, props)
+}
+
+func (c *Client) SetBrowserSourcePropertiesCtx(ctx context.Context, source string, props BrowserSourcePropertiesUpdate) error {
+	if c.Protocol() != ProtocolV5 || props.Render == nil {
+		_, err := c.submitRequestCtx(ctx, forgeSetBrowserSourceProperties(source, props))
+		return err
+	}
+	if props.settingsV5() != (BrowserSourcePropertiesUpdate{}) {
+		if _, err := c.submitRequestCtx(ctx, forgeSetBrowserSourceProperties(source, props)); err != nil {
+			return err
+		}
+	}
+	return c.SetSceneItemRenderCtx(ctx, "", source, *props.Render)
+}
+
+// GetSourceSettings returns the type and settings of any source.
+func (c *Client) GetSourceSettings(sourceName string) (*GetSourceSettingsResponse, error) {
+	return c.GetSourceSettingsCtx(context.Background(), sourceName)
+}
+
+func (c *Client) GetSourceSettingsCtx(ctx context.Context, sourceName string) (*GetSourceSettingsResponse, error) {
+	r := forgeGetSourceSettings(sourceName)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.responseType().(*GetSourceSettingsResponse), nil
+}
+
+// SetSourceSettings merges settings into the settings of any source.
+func (c *Client) SetSourceSettings(sourceName string, settings SourceSettings) error {
+	return c.SetSourceSettingsCtx(context.Background(), sourceName, settings)
+}
+
+func (c *Client) SetSourceSettingsCtx(ctx context.Context, sourceName string, settings SourceSettings) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetSourceSettings(sourceName, settings))
+	return err
+}
+
+// ErrNotTextSource is returned by SetText for sources which are not
+// text sources.
+type ErrNotTextSource struct {
+	SourceName string
+	SourceType string
+}
+
+func (e ErrNotTextSource) Error() string {
+	return fmt.Sprintf("obsws: source '%s' of type '%s' is not a text source", e.SourceName, e.SourceType)
+}
+
+// textRequestType returns the request changing the text of a source
+// of type sourceType.
+func textRequestType(sourceType string) (string, bool) {
+	switch {
+	case strings.HasPrefix(sourceType, "text_gdiplus"):
+		return "SetTextGDIPlusProperties", true
+	case strings.HasPrefix(sourceType, "text_ft2_source"):
+		return "SetTextFreetype2Properties", true
+	}
+	return "", false
+}
+
+// SetText changes the text of a GDI+ or FreeType 2 text source. The
+// type of each source is looked up once and then cached.
+func (c *Client) SetText(sourceName, text string) error {
+	return c.SetTextCtx(context.Background(), sourceName, text)
+}
+
+func (c *Client) SetTextCtx(ctx context.Context, sourceName, text string) error {
+	c.sourceTypesLock.Lock()
+	sourceType, ok := c.sourceTypes[sourceName]
+	c.sourceTypesLock.Unlock()
+	if ok == false {
+		settings, err := c.GetSourceSettingsCtx(ctx, sourceName)
+		if err != nil {
+			return err
+		}
+		sourceType = settings.SourceType
+	}
+
+	name, ok := textRequestType(sourceType)
+	if ok == false {
+		return ErrNotTextSource{SourceName: sourceName, SourceType: sourceType}
+	}
+	if _, err := c.submitRequestCtx(ctx, forgeSetText(name, sourceName, text)); err != nil {
+		// the source may have been replaced by one of another type
+		c.sourceTypesLock.Lock()
+		delete(c.sourceTypes, sourceName)
+		c.sourceTypesLock.Unlock()
+		return err
+	}
+
+	c.sourceTypesLock.Lock()
+	c.sourceTypes[sourceName] = sourceType
+	c.sourceTypesLock.Unlock()
+	return nil
+}
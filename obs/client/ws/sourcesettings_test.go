@@ -0,0 +1,188 @@
+package ws
+
+import (
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type SourceSettingsSuite struct{}
+
+var _ = Suite(&SourceSettingsSuite{})
+
+func (s *SourceSettingsSuite) TestSourceSettingsRequests(c *C) {
+	white, nowPlaying, wordWrap := 4294967295, "Daft Punk - Veridis Quo", true
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetTextGDIPlusProperties("Follower")
+			},
+			request:  `{"request-type":"GetTextGDIPlusProperties","source":"Follower"}`,
+			response: `{"source":"Follower","align":"left","color":4294967295,"font":{"face":"Arial","flags":0,"size":48,"style":"Regular"},"text":"pixelpanda","valign":"top"}`,
+			expected: &GetTextGDIPlusPropertiesResponse{
+				Source: "Follower",
+				TextGDIPlusProperties: TextGDIPlusProperties{
+					Align:  "left",
+					Color:  4294967295,
+					Font:   Font{Face: "Arial", Size: 48, Style: "Regular"},
+					Text:   "pixelpanda",
+					Valign: "top",
+				},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetTextFreetype2Properties("NowPlaying", TextFreetype2PropertiesUpdate{
+					Color1:   &white,
+					Font:     &Font{Face: "Sans", Size: 32},
+					Text:     &nowPlaying,
+					WordWrap: &wordWrap,
+				})
+			},
+			request:  `{"request-type":"SetTextFreetype2Properties","source":"NowPlaying","color1":4294967295,"font":{"face":"Sans","flags":0,"size":32,"style":""},"text":"Daft Punk - Veridis Quo","word_wrap":true}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetBrowserSourceProperties("Alerts")
+			},
+			request:  `{"request-type":"GetBrowserSourceProperties","source":"Alerts"}`,
+			response: `{"source":"Alerts","is_local_file":false,"url":"https://example.com/alerts","css":"","width":800,"height":600,"fps":30,"shutdown":true}`,
+			expected: &GetBrowserSourcePropertiesResponse{
+				Source: "Alerts",
+				BrowserSourceProperties: BrowserSourceProperties{
+					URL:      "https://example.com/alerts",
+					Width:    800,
+					Height:   600,
+					FPS:      30,
+					Shutdown: true,
+				},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetSourceSettings("Cam")
+			},
+			request:  `{"request-type":"GetSourceSettings","sourceName":"Cam"}`,
+			response: `{"sourceName":"Cam","sourceType":"dshow_input","sourceSettings":{"video_device_id":"cam:0"}}`,
+			expected: &GetSourceSettingsResponse{
+				SourceName:     "Cam",
+				SourceType:     "dshow_input",
+				SourceSettings: SourceSettings{"video_device_id": "cam:0"},
+				responseBase:   recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetSourceSettings("Cam", SourceSettings{"active": true})
+			},
+			request:  `{"request-type":"SetSourceSettings","sourceName":"Cam","sourceSettings":{"active":true}}`,
+			response: `{}`,
+		},
+	})
+}
+
+func (s *SourceSettingsSuite) TestSetText(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	server.AddInput(wstest.Input{Name: "Follower", Kind: "text_gdiplus_v2"})
+	server.AddInput(wstest.Input{Name: "Cam", Kind: "dshow_input"})
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	c.Check(client.SetText("Follower", "pixelpanda"), IsNil)
+	c.Check(client.SetText("Follower", "thecodingcat"), IsNil)
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetSourceSettings", Fields: map[string]interface{}{"sourceName": "Follower"}},
+		{Type: "SetTextGDIPlusProperties", Fields: map[string]interface{}{"source": "Follower", "text": "pixelpanda"}},
+		// the type of the source is cached
+		{Type: "SetTextGDIPlusProperties", Fields: map[string]interface{}{"source": "Follower", "text": "thecodingcat"}},
+	})
+	in, _ := server.Input("Follower")
+	c.Check(in.Settings["text"], Equals, "thecodingcat")
+
+	err := client.SetText("Cam", "hello")
+	c.Check(err, FitsTypeOf, ErrNotTextSource{})
+	c.Check(err, ErrorMatches, "obsws: source 'Cam' of type 'dshow_input' is not a text source")
+}
+
+func (s *SourceSettingsSuite) TestSourceSettingsV5(c *C) {
+	msg, err := protocolV5{}.marshalRequest(forgeSetText("SetTextFreetype2Properties", "NowPlaying", "Justice - D.A.N.C.E."), "1")
+	c.Assert(err, IsNil)
+	c.Check(msg, DeepEquals, outgoingMessageV5{
+		Op: opRequest,
+		D: requestV5{
+			RequestType: "SetInputSettings",
+			RequestID:   "1",
+			RequestData: map[string]interface{}{
+				"inputName":     "NowPlaying",
+				"inputSettings": map[string]interface{}{"text": "Justice - D.A.N.C.E."},
+			},
+		},
+	})
+
+	// the properties left nil keep their value
+	url := "https://streamlabs.com/alert-box"
+	msg, err = protocolV5{}.marshalRequest(forgeSetBrowserSourceProperties("Alerts", BrowserSourcePropertiesUpdate{URL: &url}), "2")
+	c.Assert(err, IsNil)
+	data, err := json.Marshal(msg.(outgoingMessageV5).D.(requestV5).RequestData)
+	c.Assert(err, IsNil)
+	c.Check(string(data), Equals, `{"inputName":"Alerts","inputSettings":{"url":"https://streamlabs.com/alert-box"}}`)
+
+	props := &GetTextGDIPlusPropertiesResponse{Source: "Follower"}
+	c.Assert(props.unmarshalV5([]byte(`{"inputSettings":{"text":"pixelpanda","font":{"face":"Arial","size":48}}}`)), IsNil)
+	c.Check(props.Source, Equals, "Follower")
+	c.Check(props.Text, Equals, "pixelpanda")
+	c.Check(props.Font, Equals, Font{Face: "Arial", Size: 48})
+
+	settings := &GetSourceSettingsResponse{SourceName: "Cam"}
+	c.Assert(settings.unmarshalV5([]byte(`{"inputKind":"v4l2_input","inputSettings":{"device_id":"/dev/video0"}}`)), IsNil)
+	c.Check(settings, DeepEquals, &GetSourceSettingsResponse{
+		SourceName:     "Cam",
+		SourceType:     "v4l2_input",
+		SourceSettings: SourceSettings{"device_id": "/dev/video0"},
+	})
+}
+
+func (s *SourceSettingsSuite) TestBrowserRenderV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	server.SetScenes(wstest.Scene{Name: "Live", Items: []wstest.SceneItem{
+		{ID: 4, Name: "Alerts", Visible: true},
+	}})
+	server.AddInput(wstest.Input{Name: "Alerts", Kind: "browser_source", Settings: map[string]interface{}{}})
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	url, render := "https://streamlabs.com/alert-box", false
+	c.Check(client.SetBrowserSourceProperties("Alerts", BrowserSourcePropertiesUpdate{
+		URL:    &url,
+		Render: &render,
+	}), IsNil)
+	render = true
+	c.Check(client.SetBrowserSourceProperties("Alerts", BrowserSourcePropertiesUpdate{Render: &render}), IsNil)
+
+	item := func(enabled bool) map[string]interface{} {
+		return map[string]interface{}{"sceneName": "Live", "sceneItemId": 4.0, "sceneItemEnabled": enabled}
+	}
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "SetInputSettings", Fields: map[string]interface{}{
+			"inputName":     "Alerts",
+			"inputSettings": map[string]interface{}{"url": url},
+		}},
+		{Type: "GetCurrentProgramScene", Fields: nil},
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "Live", "sourceName": "Alerts"}},
+		{Type: "SetSceneItemEnabled", Fields: item(false)},
+		// only the visibility changes
+		{Type: "GetCurrentProgramScene", Fields: nil},
+		{Type: "GetSceneItemId", Fields: map[string]interface{}{"sceneName": "Live", "sourceName": "Alerts"}},
+		{Type: "SetSceneItemEnabled", Fields: item(true)},
+	})
+	in, _ := server.Input("Alerts")
+	c.Check(in.Settings, DeepEquals, map[string]interface{}{"url": url})
+	c.Check(server.Scenes()[0].Items[0].Visible, Equals, true)
+}
@@ -0,0 +1,437 @@
+package ws
+
+import (
+	"context"
+	"sync"
+)
+
+// State mirrors an OBS instance: its scenes and their items, the
+// program and preview scenes, and the outputs. It is loaded with the
+// requests of the Client when created, then kept current from its
+// events, and reloaded after a reconnection. A State is safe for
+// concurrent use.
+//
+// The 5.x scene list has no items, so with obs-websocket 5 the items
+// of each scene are loaded by a request of their own. The items of a
+// scene are also loaded again when one is added.
+type State struct {
+	client      *Client
+	subscribers *subscriberSet
+	// cancel stops the subscription to the events of client
+	cancel func()
+	done   chan struct{}
+
+	// guarded by lock
+	lock            sync.RWMutex
+	currentScene    string
+	previewScene    string
+	scenes          []Scene
+	studioMode      bool
+	streaming       bool
+	recording       bool
+	recordingPaused bool
+	streamStatus    *EventStreamStatus
+}
+
+// NewState loads the state of the instance of client, and keeps it
+// current until Close is called or client is closed.
+func NewState(client *Client) (*State, error) {
+	return NewStateCtx(context.Background(), client)
+}
+
+func NewStateCtx(ctx context.Context, client *Client) (*State, error) {
+	s := &State{
+		client:      client,
+		subscribers: newSubscriberSet(),
+		done:        make(chan struct{}),
+	}
+	// subscribe before loading to not miss a change, the events
+	// received meanwhile are applied after
+	var events <-chan Event
+	events, s.cancel = client.SubscribeWithPolicy(DeliveryBlock, subscriberBufferSize)
+	if err := s.load(ctx); err != nil {
+		s.cancel()
+		return nil, err
+	}
+	go s.run(events)
+	return s, nil
+}
+
+// Close stops following the events of the Client.
+func (s *State) Close() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *State) run(events <-chan Event) {
+	defer close(s.done)
+	defer s.subscribers.close()
+	for ev := range events {
+		if s.apply(ev) == true {
+			if s.subscribers.deliver(ev, s.client.closing, s.client.conf.deliveryTimeout) == false {
+				s.client.reportError(ErrEventDropped{UpdateType: ev.UpdateType()})
+			}
+		}
+	}
+}
+
+// load fetches the whole state.
+func (s *State) load(ctx context.Context) error {
+	if err := s.loadScenes(ctx); err != nil {
+		return err
+	}
+	status, err := s.client.GetStreamingStatusCtx(ctx)
+	if err != nil {
+		return err
+	}
+	recording, recordingPaused := status.Recording, status.RecordingPaused
+	if s.client.Protocol() == ProtocolV5 {
+		// the 5.x stream status has no recording part
+		record, err := s.client.GetRecordingStatusCtx(ctx)
+		if err != nil {
+			return err
+		}
+		recording, recordingPaused = record.IsRecording, record.IsRecordingPaused
+	}
+	studio, err := s.client.GetStudioModeStatusCtx(ctx)
+	if err != nil {
+		return err
+	}
+	var previewScene string
+	if studio.StudioMode == true {
+		preview, err := s.client.GetPreviewSceneCtx(ctx)
+		if err != nil {
+			return err
+		}
+		previewScene = preview.Name
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.streaming = status.Streaming
+	s.recording = recording
+	s.recordingPaused = recordingPaused
+	s.studioMode = studio.StudioMode
+	s.previewScene = previewScene
+	return nil
+}
+
+// loadScenes fetches the scene list and the items of the scenes.
+func (s *State) loadScenes(ctx context.Context) error {
+	scenes, err := s.client.GetSceneListCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if s.client.Protocol() == ProtocolV5 {
+		for i := range scenes.Scenes {
+			sources, err := s.client.sceneSourcesV5(ctx, scenes.Scenes[i].Name)
+			if err != nil {
+				return err
+			}
+			scenes.Scenes[i].Sources = sources
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.currentScene = scenes.CurrentScene
+	s.scenes = scenes.Scenes
+	return nil
+}
+
+// loadSceneItems fetches the items of one scene. The 4.x protocol
+// only reports the visibility of the items with the scene list.
+func (s *State) loadSceneItems(ctx context.Context, sceneName string) error {
+	var sources []Source
+	if s.client.Protocol() == ProtocolV5 {
+		var err error
+		if sources, err = s.client.sceneSourcesV5(ctx, sceneName); err != nil {
+			return err
+		}
+	} else {
+		scenes, err := s.client.GetSceneListCtx(ctx)
+		if err != nil {
+			return err
+		}
+		for _, scene := range scenes.Scenes {
+			if scene.Name == sceneName {
+				sources = scene.Sources
+			}
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if scene := s.scene(sceneName); scene != nil {
+		scene.Sources = sources
+	}
+	return nil
+}
+
+// apply updates the state from ev, and tells if ev is about the
+// state.
+func (s *State) apply(ev Event) bool {
+	switch e := ev.(type) {
+	case *EventConnected:
+		return s.reload(s.load)
+	case *EventScenesChanged, *EventSceneCollectionChanged:
+		return s.reload(s.loadScenes)
+	case *EventSceneItemAdded:
+		// the event tells neither the visibility of the item nor
+		// where it was added
+		return s.reload(func(ctx context.Context) error {
+			return s.loadSceneItems(ctx, e.SceneName)
+		})
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch e := ev.(type) {
+	case *EventSwitchScenes:
+		s.currentScene = e.SceneName
+		if e.Sources != nil {
+			if scene := s.scene(e.SceneName); scene != nil {
+				scene.Sources = e.Sources
+			}
+		}
+	case *EventSceneItemRemoved:
+		if scene := s.scene(e.SceneName); scene != nil {
+			for i := range scene.Sources {
+				if matchItem(&scene.Sources[i], e.ItemName, e.ItemID) == true {
+					scene.Sources = append(scene.Sources[:i], scene.Sources[i+1:]...)
+					break
+				}
+			}
+		}
+	case *EventSceneItemVisibilityChanged:
+		s.updateItems(e.SceneName, e.ItemName, e.ItemID, func(item *Source) {
+			item.Render = e.ItemVisible
+		})
+	case *EventSceneItemLockChanged:
+		s.updateItems(e.SceneName, e.ItemName, e.ItemID, func(item *Source) {
+			item.Locked = e.ItemLocked
+		})
+	case *EventSourceOrderChanged:
+		scene := s.scene(e.SceneName)
+		if scene == nil || len(e.SceneItems) == 0 {
+			break
+		}
+		items := make([]Source, 0, len(scene.Sources))
+		for _, order := range e.SceneItems {
+			for _, item := range scene.Sources {
+				if matchItem(&item, order.SourceName, order.ItemID) == true {
+					items = append(items, item)
+					break
+				}
+			}
+		}
+		scene.Sources = items
+	case *EventSourceRenamed:
+		s.updateItems("", e.PreviousName, 0, func(item *Source) {
+			item.Name = e.NewName
+		})
+		for i := range s.scenes {
+			if s.scenes[i].Name == e.PreviousName {
+				s.scenes[i].Name = e.NewName
+			}
+		}
+		if s.currentScene == e.PreviousName {
+			s.currentScene = e.NewName
+		}
+		if s.previewScene == e.PreviousName {
+			s.previewScene = e.NewName
+		}
+	case *EventSourceVolumeChanged:
+		s.updateItems("", e.SourceName, 0, func(item *Source) {
+			item.Volume = e.Volume
+		})
+	case *EventSourceMuteStateChanged:
+		s.updateItems("", e.SourceName, 0, func(item *Source) {
+			item.Muted = e.Muted
+		})
+	case *EventStreamStarted:
+		s.streaming = true
+	case *EventStreamStopped:
+		s.streaming = false
+		s.streamStatus = nil
+	case *EventStreamStatus:
+		s.streaming = e.Streaming
+		s.recording = e.Recording
+		s.streamStatus = e
+	case *EventRecordingStarted:
+		s.recording = true
+		s.recordingPaused = false
+	case *EventRecordingStopped:
+		s.recording = false
+		s.recordingPaused = false
+	case *EventRecordingPaused:
+		s.recordingPaused = true
+	case *EventRecordingResumed:
+		s.recordingPaused = false
+	case *EventStudioModeSwitched:
+		s.studioMode = e.NewState
+		if e.NewState == false {
+			s.previewScene = ""
+		}
+	case *EventPreviewSceneChanged:
+		s.previewScene = e.SceneName
+	default:
+		return false
+	}
+	return true
+}
+
+// reload calls load, and reports its error on the Errors channel of
+// the Client, unless the Client was closed.
+func (s *State) reload(load func(ctx context.Context) error) bool {
+	if err := load(context.Background()); err != nil {
+		if _, ok := err.(ErrClosed); ok == false {
+			s.client.reportError(err)
+		}
+		return false
+	}
+	return true
+}
+
+// scene returns the scene named name, or nil. s.lock must be held.
+func (s *State) scene(name string) *Scene {
+	for i := range s.scenes {
+		if s.scenes[i].Name == name {
+			return &s.scenes[i]
+		}
+	}
+	return nil
+}
+
+// updateItems calls update on the items matching name and id in the
+// scene, or in every scene if sceneName is empty. s.lock must be
+// held.
+func (s *State) updateItems(sceneName, name string, id int, update func(item *Source)) {
+	for i := range s.scenes {
+		if sceneName != "" && s.scenes[i].Name != sceneName {
+			continue
+		}
+		for j := range s.scenes[i].Sources {
+			if matchItem(&s.scenes[i].Sources[j], name, id) == true {
+				update(&s.scenes[i].Sources[j])
+			}
+		}
+	}
+}
+
+// matchItem tells if item has the id, or the name if id is 0.
+func matchItem(item *Source, name string, id int) bool {
+	if id != 0 {
+		return item.ID == id
+	}
+	return item.Name == name
+}
+
+// Subscribe returns a channel receiving the events of the given
+// types, or of every type if none is given, once they are applied to
+// the state. Events which do not change the state are not sent. The
+// channel behaves like the one of Client.Subscribe, and is also closed
+// when the State is closed.
+func (s *State) Subscribe(types ...string) (<-chan Event, func()) {
+	sub := newSubscriber(DeliveryDrop, subscriberBufferSize, types)
+	return sub.events, s.subscribers.add(sub)
+}
+
+// CurrentScene returns the name of the program scene.
+func (s *State) CurrentScene() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.currentScene
+}
+
+// PreviewScene returns the name of the preview scene, empty when
+// studio mode is disabled.
+func (s *State) PreviewScene() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.previewScene
+}
+
+// StudioMode tells if studio mode is enabled.
+func (s *State) StudioMode() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.studioMode
+}
+
+// Scenes returns a copy of the scenes and their items.
+func (s *State) Scenes() []Scene {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	res := make([]Scene, 0, len(s.scenes))
+	for _, scene := range s.scenes {
+		res = append(res, copyScene(scene))
+	}
+	return res
+}
+
+// Scene returns a copy of the scene named name.
+func (s *State) Scene(name string) (Scene, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	scene := s.scene(name)
+	if scene == nil {
+		return Scene{}, false
+	}
+	return copyScene(*scene), true
+}
+
+// SceneItem returns the item named itemName in the scene.
+func (s *State) SceneItem(sceneName, itemName string) (Source, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	scene := s.scene(sceneName)
+	if scene == nil {
+		return Source{}, false
+	}
+	for _, item := range scene.Sources {
+		if item.Name == itemName {
+			return item, true
+		}
+	}
+	return Source{}, false
+}
+
+// Streaming tells if the stream is started.
+func (s *State) Streaming() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.streaming
+}
+
+// Recording tells if the recording is started, even if paused.
+func (s *State) Recording() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.recording
+}
+
+// RecordingPaused tells if the recording is paused.
+func (s *State) RecordingPaused() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.recordingPaused
+}
+
+// StreamStatus returns the last EventStreamStatus received while
+// streaming.
+func (s *State) StreamStatus() (EventStreamStatus, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.streamStatus == nil {
+		return EventStreamStatus{}, false
+	}
+	return *s.streamStatus, true
+}
+
+func copyScene(scene Scene) Scene {
+	if scene.Sources != nil {
+		sources := make([]Source, len(scene.Sources))
+		copy(sources, scene.Sources)
+		scene.Sources = sources
+	}
+	return scene
+}
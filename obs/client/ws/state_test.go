@@ -0,0 +1,234 @@
+package ws
+
+import (
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type StateSuite struct{}
+
+var _ = Suite(&StateSuite{})
+
+// newTestState returns a State of a 4.x server streaming, in studio
+// mode with BRB in preview.
+func newTestState(c *C) (*wstest.Server, *Client, *State) {
+	server := wstest.NewServer()
+	server.SetScenes(wstest.Scene{Name: "Live"}, wstest.Scene{Name: "BRB"})
+	client := newServerClient(c, server)
+	events, cancel := client.Subscribe()
+	c.Assert(client.StartStreaming(), IsNil)
+	c.Assert(client.EnableStudioMode(), IsNil)
+	c.Assert(client.SetPreviewScene("BRB"), IsNil)
+	// the events of the setup do not reach the State
+	for _, expected := range []string{"StreamStarted", "StudioModeSwitched", "PreviewSceneChanged"} {
+		c.Assert(nextEvent(c, events).UpdateType(), Equals, expected)
+	}
+	cancel()
+
+	state, err := NewState(client)
+	c.Assert(err, IsNil)
+	return server, client, state
+}
+
+// emitFrames sends the 4.x events to the clients of the server.
+func emitFrames(c *C, server *wstest.Server, frames ...string) {
+	for _, frame := range frames {
+		var fields map[string]interface{}
+		c.Assert(json.Unmarshal([]byte(frame), &fields), IsNil)
+		updateType := fields["update-type"].(string)
+		delete(fields, "update-type")
+		server.Emit(updateType, fields)
+	}
+}
+
+func (s *StateSuite) TestLoad(c *C) {
+	server, client, state := newTestState(c)
+	defer server.Close()
+	defer client.Close()
+	defer state.Close()
+
+	c.Check(state.CurrentScene(), Equals, "Live")
+	c.Check(state.PreviewScene(), Equals, "BRB")
+	c.Check(state.StudioMode(), Equals, true)
+	c.Check(state.Streaming(), Equals, true)
+	c.Check(state.Recording(), Equals, false)
+	c.Check(state.Scenes(), DeepEquals, []Scene{
+		{Name: "Live", Sources: []Source{}},
+		{Name: "BRB", Sources: []Source{}},
+	})
+	_, ok := state.StreamStatus()
+	c.Check(ok, Equals, false)
+}
+
+func (s *StateSuite) TestEvents(c *C) {
+	server, client, state := newTestState(c)
+	defer server.Close()
+	defer client.Close()
+	defer state.Close()
+
+	changes, cancel := state.Subscribe()
+	defer cancel()
+	// the items of a scene are loaded again when one is added
+	server.SetScenes(wstest.Scene{Name: "Live"}, wstest.Scene{Name: "BRB", Items: []wstest.SceneItem{
+		{ID: 2, Name: "Cam", Visible: true},
+		{ID: 1, Name: "Logo", Visible: true},
+	}})
+	frames := []string{
+		`{"update-type":"SwitchScenes","scene-name":"BRB","sources":[{"name":"Logo","id":1,"render":true}]}`,
+		// not about the state
+		`{"update-type":"Heartbeat","pulse":true}`,
+		`{"update-type":"SceneItemAdded","scene-name":"BRB","item-name":"Cam","item-id":2}`,
+		`{"update-type":"SceneItemVisibilityChanged","scene-name":"BRB","item-name":"Logo","item-id":1,"item-visible":false}`,
+		`{"update-type":"SourceOrderChanged","scene-name":"BRB","scene-items":[{"source-name":"Cam","item-id":2},{"source-name":"Logo","item-id":1}]}`,
+		`{"update-type":"SourceRenamed","previousName":"Cam","newName":"Webcam","sourceType":"input"}`,
+		`{"update-type":"RecordingStarted"}`,
+		`{"update-type":"RecordingPaused"}`,
+		`{"update-type":"StreamStatus","streaming":true,"recording":true,"kbits-per-sec":6000}`,
+		`{"update-type":"StudioModeSwitched","new-state":false}`,
+	}
+	emitFrames(c, server, frames...)
+	for _, expected := range []string{
+		"SwitchScenes", "SceneItemAdded", "SceneItemVisibilityChanged",
+		"SourceOrderChanged", "SourceRenamed", "RecordingStarted",
+		"RecordingPaused", "StreamStatus", "StudioModeSwitched",
+	} {
+		c.Check(nextEvent(c, changes).UpdateType(), Equals, expected)
+	}
+
+	c.Check(state.CurrentScene(), Equals, "BRB")
+	scene, ok := state.Scene("BRB")
+	c.Check(ok, Equals, true)
+	c.Check(scene.Sources, DeepEquals, []Source{
+		{Name: "Webcam", ID: 2, Type: "input", Render: true},
+		{Name: "Logo", ID: 1, Type: "input", Render: false},
+	})
+	item, ok := state.SceneItem("BRB", "Logo")
+	c.Check(ok, Equals, true)
+	c.Check(item.Render, Equals, false)
+	_, ok = state.SceneItem("Live", "Logo")
+	c.Check(ok, Equals, false)
+
+	c.Check(state.Recording(), Equals, true)
+	c.Check(state.RecordingPaused(), Equals, true)
+	status, ok := state.StreamStatus()
+	c.Check(ok, Equals, true)
+	c.Check(status.KBitsPerSec, Equals, 6000)
+	c.Check(state.StudioMode(), Equals, false)
+	c.Check(state.PreviewScene(), Equals, "")
+
+	// the getters return copies
+	scene.Sources[0].Name = "Changed"
+	item, _ = state.SceneItem("BRB", "Webcam")
+	c.Check(item.Name, Equals, "Webcam")
+}
+
+func (s *StateSuite) TestReload(c *C) {
+	server, client, state := newTestState(c)
+	defer server.Close()
+	defer client.Close()
+
+	changes, _ := state.Subscribe("ScenesChanged")
+	emitFrames(c, server,
+		`{"update-type":"SwitchScenes","scene-name":"BRB"}`,
+		`{"update-type":"ScenesChanged"}`,
+	)
+	nextEvent(c, changes)
+	// the scene list of the instance was loaded again
+	c.Check(state.CurrentScene(), Equals, "Live")
+
+	state.Close()
+	_, ok := <-changes
+	c.Check(ok, Equals, false)
+}
+
+func (s *StateSuite) TestLoadV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+	c.Assert(client.StartRecording(), IsNil)
+	c.Assert(client.PauseRecording(), IsNil)
+
+	state, err := NewState(client)
+	c.Assert(err, IsNil)
+	defer state.Close()
+	// the recording is not part of the 5.x stream status
+	c.Check(state.Recording(), Equals, true)
+	c.Check(state.RecordingPaused(), Equals, true)
+	c.Check(state.Streaming(), Equals, false)
+}
+
+func (s *StateSuite) TestSceneItemsV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	state, err := NewState(client)
+	c.Assert(err, IsNil)
+	defer state.Close()
+	c.Check(state.Scenes(), DeepEquals, []Scene{
+		{Name: "Live", Sources: []Source{
+			{Name: "Cam", ID: 1, Render: true},
+			{Name: "Game", ID: 2, Render: true},
+		}},
+		{Name: "BRB", Sources: []Source{
+			{Name: "BRB Screen", ID: 3, Render: true},
+		}},
+	})
+
+	changes, cancel := state.Subscribe("SceneItemVisibilityChanged")
+	defer cancel()
+	c.Assert(client.SetSceneItemRender("Live", "Cam", false), IsNil)
+	nextEvent(c, changes)
+	item, ok := state.SceneItem("Live", "Cam")
+	c.Check(ok, Equals, true)
+	c.Check(item.Render, Equals, false)
+	item, _ = state.SceneItem("Live", "Game")
+	c.Check(item.Render, Equals, true)
+
+	// OBS adds the items on top, here hidden
+	server.SetScenes(wstest.Scene{Name: "Live", Items: []wstest.SceneItem{
+		{ID: 4, Name: "Timer", Visible: false},
+		{ID: 1, Name: "Cam", Visible: false},
+		{ID: 2, Name: "Game", Visible: true},
+	}}, wstest.Scene{Name: "BRB"})
+	changes, cancel = state.Subscribe("SceneItemAdded", "SourceOrderChanged")
+	defer cancel()
+	server.Emit("SceneItemCreated", map[string]interface{}{
+		"sceneName": "Live", "sourceName": "Timer", "sceneItemId": 4, "sceneItemIndex": 2,
+	})
+	nextEvent(c, changes)
+	// Cam is moved to the top, 5.x indexes the items from the bottom
+	server.Emit("SceneItemListReindexed", map[string]interface{}{
+		"sceneName": "Live",
+		"sceneItems": []interface{}{
+			map[string]interface{}{"sceneItemId": 2, "sceneItemIndex": 0},
+			map[string]interface{}{"sceneItemId": 4, "sceneItemIndex": 1},
+			map[string]interface{}{"sceneItemId": 1, "sceneItemIndex": 2},
+		},
+	})
+	nextEvent(c, changes)
+	scene, _ := state.Scene("Live")
+	c.Check(scene.Sources, DeepEquals, []Source{
+		{Name: "Cam", ID: 1, Render: false},
+		{Name: "Timer", ID: 4, Render: false},
+		{Name: "Game", ID: 2, Render: true},
+	})
+}
+
+func (s *StateSuite) TestReloadAfterClose(c *C) {
+	server, client, state := newTestState(c)
+	defer server.Close()
+	defer state.Close()
+
+	client.Close()
+	// the reload fails with ErrClosed, which is not reported
+	c.Check(state.reload(state.load), Equals, false)
+	for err := range client.Errors() {
+		c.Check(err, Not(FitsTypeOf), ErrClosed{})
+	}
+}
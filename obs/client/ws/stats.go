@@ -0,0 +1,153 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type GetStatsResponse struct {
+	Stats OBSStats `json:"stats"`
+	responseBase
+}
+
+func (r *GetStatsResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		CPUUsage               float64 `json:"cpuUsage"`
+		MemoryUsage            float64 `json:"memoryUsage"`
+		AvailableDiskSpace     float64 `json:"availableDiskSpace"`
+		ActiveFps              float64 `json:"activeFps"`
+		AverageFrameRenderTime float64 `json:"averageFrameRenderTime"`
+		RenderSkippedFrames    int     `json:"renderSkippedFrames"`
+		RenderTotalFrames      int     `json:"renderTotalFrames"`
+		OutputSkippedFrames    int     `json:"outputSkippedFrames"`
+		OutputTotalFrames      int     `json:"outputTotalFrames"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Stats = OBSStats{
+		FPS:                 aux.ActiveFps,
+		RenderTotalFrames:   aux.RenderTotalFrames,
+		RenderMissedFrames:  aux.RenderSkippedFrames,
+		OutputTotalFrames:   aux.OutputTotalFrames,
+		OutputSkippedFrames: aux.OutputSkippedFrames,
+		AverageFrameTime:    aux.AverageFrameRenderTime,
+		CPUUsage:            aux.CPUUsage,
+		MemoryUsage:         aux.MemoryUsage,
+		FreeDiskSpace:       aux.AvailableDiskSpace,
+	}
+	return nil
+}
+
+// Output is the state of an output, such as the stream or the
+// recording. obs-websocket 5 only reports the fields from Active on.
+type Output struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Active bool   `json:"active"`
+	// Reconnecting is set while the stream output reconnects to the
+	// service
+	Reconnecting bool `json:"reconnecting"`
+	// Congestion is between 0 and 1
+	Congestion    float64 `json:"congestion"`
+	TotalFrames   int     `json:"totalFrames"`
+	DroppedFrames int     `json:"droppedFrames"`
+	TotalBytes    int64   `json:"totalBytes"`
+}
+
+type GetOutputInfoResponse struct {
+	OutputInfo Output `json:"outputInfo"`
+	responseBase
+}
+
+func (r *GetOutputInfoResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		OutputActive        bool    `json:"outputActive"`
+		OutputReconnecting  bool    `json:"outputReconnecting"`
+		OutputCongestion    float64 `json:"outputCongestion"`
+		OutputBytes         int64   `json:"outputBytes"`
+		OutputSkippedFrames int     `json:"outputSkippedFrames"`
+		OutputTotalFrames   int     `json:"outputTotalFrames"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	// the name is set by GetOutputInfo
+	r.OutputInfo.Active = aux.OutputActive
+	r.OutputInfo.Reconnecting = aux.OutputReconnecting
+	r.OutputInfo.Congestion = aux.OutputCongestion
+	r.OutputInfo.TotalBytes = aux.OutputBytes
+	r.OutputInfo.DroppedFrames = aux.OutputSkippedFrames
+	r.OutputInfo.TotalFrames = aux.OutputTotalFrames
+	return nil
+}
+
+// GetStats returns the performance statistics of OBS.
+func (c *Client) GetStats() (*GetStatsResponse, error) {
+	return c.GetStatsCtx(context.Background())
+}
+
+func (c *Client) GetStatsCtx(ctx context.Context) (*GetStatsResponse, error) {
+	resp := &GetStatsResponse{}
+	r := forgeRequestWithExpectedResponse("GetStats", resp)
+	r.setV5Request("GetStats", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetOutputInfo returns the state of the output. The stream output is
+// named "simple_stream" or "adv_stream", depending on the output mode
+// of OBS.
+func (c *Client) GetOutputInfo(outputName string) (*GetOutputInfoResponse, error) {
+	return c.GetOutputInfoCtx(context.Background(), outputName)
+}
+
+func (c *Client) GetOutputInfoCtx(ctx context.Context, outputName string) (*GetOutputInfoResponse, error) {
+	type getOutputInfo struct {
+		requestBase
+		OutputName string `json:"outputName"`
+	}
+	resp := &GetOutputInfoResponse{}
+	r := &getOutputInfo{
+		requestBase: requestBase{
+			RequestType: "GetOutputInfo",
+			rType:       resp,
+			v5Type:      "GetOutputStatus",
+			v5Data:      map[string]interface{}{"outputName": outputName},
+		},
+		OutputName: outputName,
+	}
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	if len(resp.OutputInfo.Name) == 0 {
+		resp.OutputInfo.Name = outputName
+	}
+	return resp, nil
+}
+
+// SetHeartbeat enables or disables the Heartbeat events, sent every 2
+// seconds with the statistics of OBS. obs-websocket 5 has no
+// heartbeat, it fails with ErrUnsupportedRequest.
+func (c *Client) SetHeartbeat(enable bool) error {
+	return c.SetHeartbeatCtx(context.Background(), enable)
+}
+
+func (c *Client) SetHeartbeatCtx(ctx context.Context, enable bool) error {
+	type setHeartbeat struct {
+		requestBase
+		Enable bool `json:"enable"`
+	}
+	_, err := c.submitRequestCtx(ctx, &setHeartbeat{
+		requestBase: requestBase{
+			RequestType: "SetHeartbeat",
+			rType:       &responseBase{},
+		},
+		Enable: enable,
+	})
+	return err
+}
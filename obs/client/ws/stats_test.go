@@ -0,0 +1,84 @@
+package ws
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type StatsSuite struct{}
+
+var _ = Suite(&StatsSuite{})
+
+func (s *StatsSuite) TestStatsRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetStats()
+			},
+			request:  `{"request-type":"GetStats"}`,
+			response: `{"stats":{"fps":60,"render-total-frames":1200,"render-missed-frames":3,"cpu-usage":12.5}}`,
+			expected: &GetStatsResponse{
+				Stats: OBSStats{
+					FPS:                60,
+					RenderTotalFrames:  1200,
+					RenderMissedFrames: 3,
+					CPUUsage:           12.5,
+				},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetOutputInfo("simple_stream")
+			},
+			request:  `{"request-type":"GetOutputInfo","outputName":"simple_stream"}`,
+			response: `{"outputInfo":{"name":"simple_stream","type":"rtmp_output","width":1920,"height":1080,"active":true,"reconnecting":false,"congestion":0.1,"totalFrames":3600,"droppedFrames":12,"totalBytes":45000000}}`,
+			expected: &GetOutputInfoResponse{
+				OutputInfo: Output{
+					Name:          "simple_stream",
+					Type:          "rtmp_output",
+					Width:         1920,
+					Height:        1080,
+					Active:        true,
+					Congestion:    0.1,
+					TotalFrames:   3600,
+					DroppedFrames: 12,
+					TotalBytes:    45000000,
+				},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetHeartbeat(true)
+			},
+			request:  `{"request-type":"SetHeartbeat","enable":true}`,
+			response: `{}`,
+		},
+	})
+}
+
+func (s *StatsSuite) TestStatsResponsesV5(c *C) {
+	stats := &GetStatsResponse{}
+	c.Assert(stats.unmarshalV5([]byte(`{"cpuUsage":12.5,"memoryUsage":512,"availableDiskSpace":100000,"activeFps":60,"averageFrameRenderTime":1.5,"renderSkippedFrames":3,"renderTotalFrames":1200,"outputSkippedFrames":1,"outputTotalFrames":1190,"webSocketSessionIncomingMessages":10}`)), IsNil)
+	c.Check(stats.Stats, Equals, OBSStats{
+		FPS:                 60,
+		RenderTotalFrames:   1200,
+		RenderMissedFrames:  3,
+		OutputTotalFrames:   1190,
+		OutputSkippedFrames: 1,
+		AverageFrameTime:    1.5,
+		CPUUsage:            12.5,
+		MemoryUsage:         512,
+		FreeDiskSpace:       100000,
+	})
+
+	output := &GetOutputInfoResponse{}
+	c.Assert(output.unmarshalV5([]byte(`{"outputActive":true,"outputReconnecting":false,"outputTimecode":"00:01:00.000","outputDuration":60000,"outputCongestion":0.1,"outputBytes":45000000,"outputSkippedFrames":12,"outputTotalFrames":3600}`)), IsNil)
+	c.Check(output.OutputInfo, Equals, Output{
+		Active:        true,
+		Congestion:    0.1,
+		TotalFrames:   3600,
+		DroppedFrames: 12,
+		TotalBytes:    45000000,
+	})
+}
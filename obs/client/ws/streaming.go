@@ -0,0 +1,300 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// StreamSettings are the settings of the streaming service. The zero
+// fields are left unchanged by SetStreamSettings.
+type StreamSettings struct {
+	Server   string `json:"server,omitempty"`
+	Key      string `json:"key,omitempty"`
+	UseAuth  *bool  `json:"use_auth,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type GetStreamingStatusResponse struct {
+	Streaming       bool   `json:"streaming"`
+	Recording       bool   `json:"recording"`
+	RecordingPaused bool   `json:"recording-paused"`
+	PreviewOnly     bool   `json:"preview-only"`
+	StreamTimecode  string `json:"stream-timecode"`
+	RecTimecode     string `json:"rec-timecode"`
+	responseBase
+}
+
+func (r *GetStreamingStatusResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		OutputActive   bool   `json:"outputActive"`
+		OutputTimecode string `json:"outputTimecode"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Streaming = aux.OutputActive
+	r.StreamTimecode = aux.OutputTimecode
+	return nil
+}
+
+type GetRecordingStatusResponse struct {
+	IsRecording       bool   `json:"isRecording"`
+	IsRecordingPaused bool   `json:"isRecordingPaused"`
+	RecordTimecode    string `json:"recordTimecode"`
+	RecordingFilename string `json:"recordingFilename"`
+	responseBase
+}
+
+func (r *GetRecordingStatusResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		OutputActive   bool   `json:"outputActive"`
+		OutputPaused   bool   `json:"outputPaused"`
+		OutputTimecode string `json:"outputTimecode"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.IsRecording = aux.OutputActive
+	r.IsRecordingPaused = aux.OutputPaused
+	r.RecordTimecode = aux.OutputTimecode
+	return nil
+}
+
+type GetRecordingFolderResponse struct {
+	RecFolder string `json:"rec-folder"`
+	responseBase
+}
+
+func (r *GetRecordingFolderResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		RecordDirectory string `json:"recordDirectory"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.RecFolder = aux.RecordDirectory
+	return nil
+}
+
+type GetStreamSettingsResponse struct {
+	// Type is either "rtmp_custom" or "rtmp_common"
+	Type     string         `json:"type"`
+	Settings StreamSettings `json:"settings"`
+	responseBase
+}
+
+func (r *GetStreamSettingsResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		StreamServiceType     string         `json:"streamServiceType"`
+		StreamServiceSettings StreamSettings `json:"streamServiceSettings"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Type = aux.StreamServiceType
+	r.Settings = aux.StreamServiceSettings
+	return nil
+}
+
+// forgeOutputRequest forges a request without parameters nor
+// response data, named v5Name in obs-websocket 5.
+func forgeOutputRequest(name, v5Name string) request {
+	r := forgeRequest(name)
+	r.setV5Request(v5Name, nil)
+	return r
+}
+
+func forgeSetRecordingFolder(folder string) request {
+	type setRecordingFolder struct {
+		requestBase
+		RecFolder string `json:"rec-folder"`
+	}
+	return &setRecordingFolder{
+		requestBase: requestBase{
+			RequestType: "SetRecordingFolder",
+			rType:       &responseBase{},
+			v5Type:      "SetRecordDirectory",
+			v5Data:      map[string]interface{}{"recordDirectory": folder},
+		},
+		RecFolder: folder,
+	}
+}
+
+func forgeSetStreamSettings(streamType string, settings StreamSettings, save bool) request {
+	type setStreamSettings struct {
+		requestBase
+		Type     string         `json:"type"`
+		Settings StreamSettings `json:"settings"`
+		Save     bool           `json:"save"`
+	}
+	return &setStreamSettings{
+		requestBase: requestBase{
+			RequestType: "SetStreamSettings",
+			rType:       &responseBase{},
+			v5Type:      "SetStreamServiceSettings",
+			v5Data: map[string]interface{}{
+				"streamServiceType":     streamType,
+				"streamServiceSettings": settings,
+			},
+		},
+		Type:     streamType,
+		Settings: settings,
+		Save:     save,
+	}
+}
+
+// StartStreaming starts the stream with the current settings.
+func (c *Client) StartStreaming() error {
+	return c.StartStreamingCtx(context.Background())
+}
+
+func (c *Client) StartStreamingCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("StartStreaming", "StartStream"))
+	return err
+}
+
+// StopStreaming stops the stream.
+func (c *Client) StopStreaming() error {
+	return c.StopStreamingCtx(context.Background())
+}
+
+func (c *Client) StopStreamingCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("StopStreaming", "StopStream"))
+	return err
+}
+
+// StartStopStreaming toggles the stream.
+func (c *Client) StartStopStreaming() error {
+	return c.StartStopStreamingCtx(context.Background())
+}
+
+func (c *Client) StartStopStreamingCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("StartStopStreaming", "ToggleStream"))
+	return err
+}
+
+// GetStreamingStatus returns the streaming and recording status. The
+// 5.x protocol only reports the streaming part, see
+// GetRecordingStatus.
+func (c *Client) GetStreamingStatus() (*GetStreamingStatusResponse, error) {
+	return c.GetStreamingStatusCtx(context.Background())
+}
+
+func (c *Client) GetStreamingStatusCtx(ctx context.Context) (*GetStreamingStatusResponse, error) {
+	resp := &GetStreamingStatusResponse{}
+	r := forgeRequestWithExpectedResponse("GetStreamingStatus", resp)
+	r.setV5Request("GetStreamStatus", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetRecordingStatus returns the recording status. The 5.x protocol
+// does not report the file name.
+func (c *Client) GetRecordingStatus() (*GetRecordingStatusResponse, error) {
+	return c.GetRecordingStatusCtx(context.Background())
+}
+
+func (c *Client) GetRecordingStatusCtx(ctx context.Context) (*GetRecordingStatusResponse, error) {
+	resp := &GetRecordingStatusResponse{}
+	r := forgeRequestWithExpectedResponse("GetRecordingStatus", resp)
+	r.setV5Request("GetRecordStatus", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StartRecording starts the recording.
+func (c *Client) StartRecording() error {
+	return c.StartRecordingCtx(context.Background())
+}
+
+func (c *Client) StartRecordingCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("StartRecording", "StartRecord"))
+	return err
+}
+
+// StopRecording stops the recording.
+func (c *Client) StopRecording() error {
+	return c.StopRecordingCtx(context.Background())
+}
+
+func (c *Client) StopRecordingCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("StopRecording", "StopRecord"))
+	return err
+}
+
+// PauseRecording pauses the recording.
+func (c *Client) PauseRecording() error {
+	return c.PauseRecordingCtx(context.Background())
+}
+
+func (c *Client) PauseRecordingCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("PauseRecording", "PauseRecord"))
+	return err
+}
+
+// ResumeRecording resumes the paused recording.
+func (c *Client) ResumeRecording() error {
+	return c.ResumeRecordingCtx(context.Background())
+}
+
+func (c *Client) ResumeRecordingCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeOutputRequest("ResumeRecording", "ResumeRecord"))
+	return err
+}
+
+// SetRecordingFolder changes the folder where recordings are saved.
+func (c *Client) SetRecordingFolder(folder string) error {
+	return c.SetRecordingFolderCtx(context.Background(), folder)
+}
+
+func (c *Client) SetRecordingFolderCtx(ctx context.Context, folder string) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetRecordingFolder(folder))
+	return err
+}
+
+// GetRecordingFolder returns the folder where recordings are saved.
+func (c *Client) GetRecordingFolder() (*GetRecordingFolderResponse, error) {
+	return c.GetRecordingFolderCtx(context.Background())
+}
+
+func (c *Client) GetRecordingFolderCtx(ctx context.Context) (*GetRecordingFolderResponse, error) {
+	resp := &GetRecordingFolderResponse{}
+	r := forgeRequestWithExpectedResponse("GetRecordingFolder", resp)
+	r.setV5Request("GetRecordDirectory", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetStreamSettings changes the streaming service settings. They
+// are written to disk if save is true, which the 5.x protocol always
+// does.
+func (c *Client) SetStreamSettings(streamType string, settings StreamSettings, save bool) error {
+	return c.SetStreamSettingsCtx(context.Background(), streamType, settings, save)
+}
+
+func (c *Client) SetStreamSettingsCtx(ctx context.Context, streamType string, settings StreamSettings, save bool) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetStreamSettings(streamType, settings, save))
+	return err
+}
+
+// GetStreamSettings returns the streaming service settings.
+func (c *Client) GetStreamSettings() (*GetStreamSettingsResponse, error) {
+	return c.GetStreamSettingsCtx(context.Background())
+}
+
+func (c *Client) GetStreamSettingsCtx(ctx context.Context) (*GetStreamSettingsResponse, error) {
+	resp := &GetStreamSettingsResponse{}
+	r := forgeRequestWithExpectedResponse("GetStreamSettings", resp)
+	r.setV5Request("GetStreamServiceSettings", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
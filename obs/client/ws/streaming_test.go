@@ -0,0 +1,173 @@
+package ws
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type StreamingSuite struct{}
+
+var _ = Suite(&StreamingSuite{})
+
+func (s *StreamingSuite) TestStreamingRequests(c *C) {
+	useAuth, noAuth := true, false
+	noArgs := map[string]func(*Client) error{
+		"StartStreaming":     (*Client).StartStreaming,
+		"StopStreaming":      (*Client).StopStreaming,
+		"StartStopStreaming": (*Client).StartStopStreaming,
+		"StartRecording":     (*Client).StartRecording,
+		"StopRecording":      (*Client).StopRecording,
+		"PauseRecording":     (*Client).PauseRecording,
+		"ResumeRecording":    (*Client).ResumeRecording,
+	}
+	cases := []requestCase{}
+	for name, call := range noArgs {
+		call := call
+		cases = append(cases, requestCase{
+			call: func(client *Client) (interface{}, error) {
+				return nil, call(client)
+			},
+			request:  `{"request-type":"` + name + `"}`,
+			response: `{}`,
+		})
+	}
+
+	cases = append(cases, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetStreamingStatus()
+			},
+			request:  `{"request-type":"GetStreamingStatus"}`,
+			response: `{"streaming":true,"recording":true,"recording-paused":true,"virtualcam":false,"preview-only":false,"stream-timecode":"01:02:03.456","rec-timecode":"00:10:00.000"}`,
+			expected: &GetStreamingStatusResponse{
+				Streaming:       true,
+				Recording:       true,
+				RecordingPaused: true,
+				StreamTimecode:  "01:02:03.456",
+				RecTimecode:     "00:10:00.000",
+				responseBase:    recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetRecordingStatus()
+			},
+			request:  `{"request-type":"GetRecordingStatus"}`,
+			response: `{"isRecording":true,"isRecordingPaused":false,"recordTimecode":"00:10:00.000","recordingFilename":"/vods/a.mkv"}`,
+			expected: &GetRecordingStatusResponse{
+				IsRecording:       true,
+				RecordTimecode:    "00:10:00.000",
+				RecordingFilename: "/vods/a.mkv",
+				responseBase:      recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetRecordingFolder("/home/stream/vods")
+			},
+			request:  `{"request-type":"SetRecordingFolder","rec-folder":"/home/stream/vods"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetRecordingFolder()
+			},
+			request:  `{"request-type":"GetRecordingFolder"}`,
+			response: `{"rec-folder":"/home/stream/vods"}`,
+			expected: &GetRecordingFolderResponse{
+				RecFolder:    "/home/stream/vods",
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetStreamSettings("rtmp_custom", StreamSettings{
+					Server: "rtmp://live.twitch.tv/app",
+					Key:    "live_123",
+				}, true)
+			},
+			request:  `{"request-type":"SetStreamSettings","type":"rtmp_custom","settings":{"server":"rtmp://live.twitch.tv/app","key":"live_123"},"save":true}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetStreamSettings("rtmp_custom", StreamSettings{
+					UseAuth:  &useAuth,
+					Username: "streamer",
+					Password: "hunter2",
+				}, false)
+			},
+			request:  `{"request-type":"SetStreamSettings","type":"rtmp_custom","settings":{"use_auth":true,"username":"streamer","password":"hunter2"},"save":false}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetStreamSettings()
+			},
+			request:  `{"request-type":"GetStreamSettings"}`,
+			response: `{"type":"rtmp_common","settings":{"server":"auto","key":"live_123","use_auth":false}}`,
+			expected: &GetStreamSettingsResponse{
+				Type: "rtmp_common",
+				Settings: StreamSettings{
+					Server:  "auto",
+					Key:     "live_123",
+					UseAuth: &noAuth,
+				},
+				responseBase: recordedOK,
+			},
+		},
+	}...)
+	checkRequests(c, cases)
+}
+
+func (s *StreamingSuite) TestStreamingEvents(c *C) {
+	tdata := map[string]Event{
+		`{"update-type":"StreamStarting","preview-only":false}`: &EventStreamStarting{
+			rawEvent: newRawEvent("StreamStarting"),
+		},
+		`{"update-type":"StreamStarted","stream-timecode":"00:00:00.000"}`: &EventStreamStarted{
+			rawEvent: rawEvent{"StreamStarted", 0, -1},
+		},
+		`{"update-type":"StreamStopped"}`: &EventStreamStopped{
+			rawEvent: newRawEvent("StreamStopped"),
+		},
+		`{"update-type":"RecordingStopped","recordingFilename":"/vods/2020-01-01.mkv"}`: &EventRecordingStopped{
+			RecordingFilename: "/vods/2020-01-01.mkv",
+			rawEvent:          newRawEvent("RecordingStopped"),
+		},
+		`{"update-type":"RecordingPaused","rec-timecode":"00:01:00.000"}`: &EventRecordingPaused{
+			rawEvent: rawEvent{"RecordingPaused", -1, time.Minute},
+		},
+	}
+	for data, expected := range tdata {
+		ev, err := UnmarshalEvent([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+}
+
+func (s *StreamingSuite) TestStreamingEventsV5(c *C) {
+	tdata := map[string]Event{
+		`{"eventType":"StreamStateChanged","eventIntent":64,"eventData":{"outputActive":false,"outputState":"OBS_WEBSOCKET_OUTPUT_STARTING"}}`: &EventStreamStarting{
+			rawEvent: newRawEvent("StreamStarting"),
+		},
+		`{"eventType":"RecordStateChanged","eventIntent":64,"eventData":{"outputActive":false,"outputState":"OBS_WEBSOCKET_OUTPUT_STOPPED","outputPath":"/vods/a.mkv"}}`: &EventRecordingStopped{
+			RecordingFilename: "/vods/a.mkv",
+			rawEvent:          newRawEvent("RecordingStopped"),
+		},
+		`{"eventType":"RecordStateChanged","eventIntent":64,"eventData":{"outputActive":true,"outputState":"OBS_WEBSOCKET_OUTPUT_RESUMED","outputPath":null}}`: &EventRecordingResumed{
+			rawEvent: newRawEvent("RecordingResumed"),
+		},
+	}
+	for data, expected := range tdata {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+
+	_, err := unmarshalEventV5([]byte(`{"eventType":"StreamStateChanged","eventData":{"outputState":"OBS_WEBSOCKET_OUTPUT_RECONNECTING"}}`))
+	c.Check(err, ErrorMatches, "obsws: unknown event type 'StreamStateChanged/OBS_WEBSOCKET_OUTPUT_RECONNECTING'")
+}
@@ -0,0 +1,299 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// TransitionOverride replaces the current transition for one
+// TransitionToProgram. An empty Name keeps the current transition,
+// and a zero Duration keeps its duration.
+type TransitionOverride struct {
+	Name string `json:"name,omitempty"`
+	// Duration is in milliseconds
+	Duration int `json:"duration,omitempty"`
+}
+
+type GetStudioModeStatusResponse struct {
+	StudioMode bool `json:"studio-mode"`
+	responseBase
+}
+
+func (r *GetStudioModeStatusResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		StudioModeEnabled bool `json:"studioModeEnabled"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.StudioMode = aux.StudioModeEnabled
+	return nil
+}
+
+type GetPreviewSceneResponse struct {
+	Scene
+	responseBase
+}
+
+func (r *GetPreviewSceneResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		CurrentPreviewSceneName string `json:"currentPreviewSceneName"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Name = aux.CurrentPreviewSceneName
+	return nil
+}
+
+// forgeStudioModeRequest forges the requests enabling or disabling
+// studio mode, which are a single request in obs-websocket 5.
+func forgeStudioModeRequest(name string, enabled bool) request {
+	r := forgeRequest(name)
+	r.setV5Request("SetStudioModeEnabled", map[string]interface{}{"studioModeEnabled": enabled})
+	return r
+}
+
+func forgeSetPreviewScene(name string) request {
+	type setPreviewScene struct {
+		requestBase
+		SceneName string `json:"scene-name"`
+	}
+	return &setPreviewScene{
+		requestBase: requestBase{
+			RequestType: "SetPreviewScene",
+			rType:       &responseBase{},
+			v5Type:      "SetCurrentPreviewScene",
+			v5Data:      map[string]interface{}{"sceneName": name},
+		},
+		SceneName: name,
+	}
+}
+
+func forgeTransitionToProgram(with *TransitionOverride) request {
+	type transitionToProgram struct {
+		requestBase
+		WithTransition *TransitionOverride `json:"with-transition,omitempty"`
+	}
+	r := &transitionToProgram{
+		requestBase: requestBase{
+			RequestType: "TransitionToProgram",
+			rType:       &responseBase{},
+		},
+		WithTransition: with,
+	}
+	// obs-websocket 5 cannot override the transition, the Client
+	// changes the current one instead
+	if with == nil {
+		r.setV5Request("TriggerStudioModeTransition", nil)
+	}
+	return r
+}
+
+// requestsV5 returns the obs-websocket 5 requests of a
+// TransitionToProgram with the override, for a Batch which cannot wait
+// for the TransitionEnd event: the current transition and its
+// duration are changed, then restored after a pause of the duration
+// of the transition.
+func (with TransitionOverride) requestsV5(current *GetCurrentTransitionResponse) []requestV5 {
+	var requests, restore []requestV5
+	add := func(r request) {
+		requestType, data := r.v5Request()
+		requests = append(requests, requestV5{RequestType: requestType, RequestData: data})
+	}
+	restoring := func(r request) {
+		requestType, data := r.v5Request()
+		restore = append(restore, requestV5{RequestType: requestType, RequestData: data})
+	}
+
+	duration := current.Duration
+	if len(with.Name) > 0 && with.Name != current.Name {
+		add(forgeSetCurrentTransition(with.Name))
+		restoring(forgeSetCurrentTransition(current.Name))
+	}
+	if with.Duration > 0 {
+		add(forgeSetTransitionDuration(with.Duration))
+		if current.Duration > 0 && current.Duration != with.Duration {
+			restoring(forgeSetTransitionDuration(current.Duration))
+		}
+		duration = with.Duration
+	}
+	add(forgeTransitionToProgram(nil))
+	if len(restore) > 0 {
+		// changing the transition while it runs would cut it short
+		add(forgeSleep(time.Duration(duration)*time.Millisecond + batchTransitionMargin))
+	}
+	return append(requests, restore...)
+}
+
+// batchTransitionMargin is the pause of a Batch past the duration of an
+// overridden transition, before the current transition is restored.
+const batchTransitionMargin = 100 * time.Millisecond
+
+// GetStudioModeStatus tells if studio mode is enabled.
+func (c *Client) GetStudioModeStatus() (*GetStudioModeStatusResponse, error) {
+	return c.GetStudioModeStatusCtx(context.Background())
+}
+
+func (c *Client) GetStudioModeStatusCtx(ctx context.Context) (*GetStudioModeStatusResponse, error) {
+	resp := &GetStudioModeStatusResponse{}
+	r := forgeRequestWithExpectedResponse("GetStudioModeStatus", resp)
+	r.setV5Request("GetStudioModeEnabled", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// EnableStudioMode enables studio mode.
+func (c *Client) EnableStudioMode() error {
+	return c.EnableStudioModeCtx(context.Background())
+}
+
+func (c *Client) EnableStudioModeCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeStudioModeRequest("EnableStudioMode", true))
+	return err
+}
+
+// DisableStudioMode disables studio mode.
+func (c *Client) DisableStudioMode() error {
+	return c.DisableStudioModeCtx(context.Background())
+}
+
+func (c *Client) DisableStudioModeCtx(ctx context.Context) error {
+	_, err := c.submitRequestCtx(ctx, forgeStudioModeRequest("DisableStudioMode", false))
+	return err
+}
+
+// ToggleStudioMode enables or disables studio mode. With
+// obs-websocket 5 the state is read then changed by two requests.
+func (c *Client) ToggleStudioMode() error {
+	return c.ToggleStudioModeCtx(context.Background())
+}
+
+func (c *Client) ToggleStudioModeCtx(ctx context.Context) error {
+	r := forgeRequest("ToggleStudioMode")
+	if c.Protocol() == ProtocolV5 {
+		status, err := c.GetStudioModeStatusCtx(ctx)
+		if err != nil {
+			return err
+		}
+		r = forgeStudioModeRequest("ToggleStudioMode", !status.StudioMode)
+	}
+	_, err := c.submitRequestCtx(ctx, r)
+	return err
+}
+
+// GetPreviewScene returns the scene in preview. It fails if studio
+// mode is not enabled.
+func (c *Client) GetPreviewScene() (*GetPreviewSceneResponse, error) {
+	return c.GetPreviewSceneCtx(context.Background())
+}
+
+func (c *Client) GetPreviewSceneCtx(ctx context.Context) (*GetPreviewSceneResponse, error) {
+	resp := &GetPreviewSceneResponse{}
+	r := forgeRequestWithExpectedResponse("GetPreviewScene", resp)
+	r.setV5Request("GetCurrentPreviewScene", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetPreviewScene puts the scene in preview. It fails if studio mode
+// is not enabled.
+func (c *Client) SetPreviewScene(name string) error {
+	return c.SetPreviewSceneCtx(context.Background(), name)
+}
+
+func (c *Client) SetPreviewSceneCtx(ctx context.Context, name string) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetPreviewScene(name))
+	return err
+}
+
+// TransitionToProgram transitions the scene in preview to program,
+// with the current transition or with the given override. With
+// obs-websocket 5 the override is applied by changing the current
+// transition and its duration, which are restored once the transition
+// ended.
+func (c *Client) TransitionToProgram(with *TransitionOverride) error {
+	return c.TransitionToProgramCtx(context.Background(), with)
+}
+
+func (c *Client) TransitionToProgramCtx(ctx context.Context, with *TransitionOverride) error {
+	c.sceneSwitchLock.Lock()
+	defer c.sceneSwitchLock.Unlock()
+	if c.Protocol() == ProtocolV5 && with != nil {
+		return c.transitionToProgramV5(ctx, *with)
+	}
+	_, err := c.submitRequestCtx(ctx, forgeTransitionToProgram(with))
+	return err
+}
+
+// transitionEndMargin is how long TransitionToProgram waits for the end
+// of an overridden transition past its duration, in case the
+// TransitionEnd event is not received.
+const transitionEndMargin = time.Second
+
+// transitionToProgramV5 overrides the transition for obs-websocket 5,
+// which cannot do it. The current transition and its duration are
+// changed, then restored once the transition ended, since changing
+// them while it runs would cut it short. They are restored even if
+// ctx is done meanwhile.
+func (c *Client) transitionToProgramV5(ctx context.Context, with TransitionOverride) (err error) {
+	current, err := c.GetCurrentTransitionCtx(ctx)
+	if err != nil {
+		return err
+	}
+	var restore []request
+	defer func() {
+		restoreCtx := context.WithoutCancel(ctx)
+		for _, r := range restore {
+			if _, rerr := c.submitRequestCtx(restoreCtx, r); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+	}()
+
+	duration := current.Duration
+	if len(with.Name) > 0 && with.Name != current.Name {
+		if _, err := c.submitRequestCtx(ctx, forgeSetCurrentTransition(with.Name)); err != nil {
+			return err
+		}
+		restore = append(restore, forgeSetCurrentTransition(current.Name))
+	}
+	if with.Duration > 0 {
+		previous := current.Duration
+		if previous == 0 && len(restore) > 0 {
+			// a fixed transition does not report the duration, the
+			// overriding one does
+			overriding, err := c.GetCurrentTransitionCtx(ctx)
+			if err != nil {
+				return err
+			}
+			previous = overriding.Duration
+		}
+		if _, err := c.submitRequestCtx(ctx, forgeSetTransitionDuration(with.Duration)); err != nil {
+			return err
+		}
+		if previous > 0 && previous != with.Duration {
+			restore = append(restore, forgeSetTransitionDuration(previous))
+		}
+		duration = with.Duration
+	}
+
+	ended, cancel := c.Subscribe("TransitionEnd")
+	defer cancel()
+	if _, err := c.submitRequestCtx(ctx, forgeTransitionToProgram(nil)); err != nil {
+		return err
+	}
+	if len(restore) > 0 {
+		select {
+		case <-ended:
+		case <-time.After(time.Duration(duration)*time.Millisecond + transitionEndMargin):
+		case <-ctx.Done():
+		}
+	}
+	return nil
+}
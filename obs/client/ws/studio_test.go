@@ -0,0 +1,153 @@
+package ws
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type StudioSuite struct{}
+
+var _ = Suite(&StudioSuite{})
+
+func (s *StudioSuite) TestStudioRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetStudioModeStatus()
+			},
+			request:  `{"request-type":"GetStudioModeStatus"}`,
+			response: `{"studio-mode":true}`,
+			expected: &GetStudioModeStatusResponse{
+				StudioMode:   true,
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.EnableStudioMode()
+			},
+			request:  `{"request-type":"EnableStudioMode"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.DisableStudioMode()
+			},
+			request:  `{"request-type":"DisableStudioMode"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.ToggleStudioMode()
+			},
+			request:  `{"request-type":"ToggleStudioMode"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetPreviewScene()
+			},
+			request:  `{"request-type":"GetPreviewScene"}`,
+			response: `{"name":"BRB","sources":[]}`,
+			expected: &GetPreviewSceneResponse{
+				Scene:        Scene{Name: "BRB", Sources: []Source{}},
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetPreviewScene("BRB")
+			},
+			request:  `{"request-type":"SetPreviewScene","scene-name":"BRB"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.TransitionToProgram(nil)
+			},
+			request:  `{"request-type":"TransitionToProgram"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.TransitionToProgram(&TransitionOverride{Name: "Stinger", Duration: 1000})
+			},
+			request:  `{"request-type":"TransitionToProgram","with-transition":{"name":"Stinger","duration":1000}}`,
+			response: `{}`,
+		},
+	})
+}
+
+func (s *StudioSuite) TestStudioRequestsV5(c *C) {
+	msg, err := protocolV5{}.marshalRequest(forgeStudioModeRequest("DisableStudioMode", false), "1")
+	c.Assert(err, IsNil)
+	c.Check(msg, DeepEquals, outgoingMessageV5{
+		Op: opRequest,
+		D: requestV5{
+			RequestType: "SetStudioModeEnabled",
+			RequestID:   "1",
+			RequestData: map[string]interface{}{"studioModeEnabled": false},
+		},
+	})
+
+	_, err = protocolV5{}.marshalRequest(forgeTransitionToProgram(nil), "2")
+	c.Check(err, IsNil)
+}
+
+func (s *StudioSuite) TestStudioWorkflowV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	c.Assert(client.ToggleStudioMode(), IsNil)
+	c.Check(server.StudioMode(), Equals, true)
+	c.Assert(client.SetPreviewScene("BRB"), IsNil)
+	start := time.Now()
+	c.Assert(client.TransitionToProgram(&TransitionOverride{Name: "Cut", Duration: 1000}), IsNil)
+	// the end of the transition was waited for with TransitionEnd
+	c.Check(time.Since(start) < time.Second, Equals, true)
+	c.Check(server.CurrentScene(), Equals, "BRB")
+	// the override does not stay selected
+	transition, duration := server.CurrentTransition()
+	c.Check(transition, Equals, "Fade")
+	c.Check(duration, Equals, 300*time.Millisecond)
+	c.Assert(client.ToggleStudioMode(), IsNil)
+	c.Check(server.StudioMode(), Equals, false)
+
+	c.Check(server.Requests(), DeepEquals, []wstest.Request{
+		{Type: "GetStudioModeEnabled", Fields: nil},
+		{Type: "SetStudioModeEnabled", Fields: map[string]interface{}{"studioModeEnabled": true}},
+		{Type: "SetCurrentPreviewScene", Fields: map[string]interface{}{"sceneName": "BRB"}},
+		{Type: "GetCurrentSceneTransition", Fields: nil},
+		{Type: "SetCurrentSceneTransition", Fields: map[string]interface{}{"transitionName": "Cut"}},
+		{Type: "SetCurrentSceneTransitionDuration", Fields: map[string]interface{}{"transitionDuration": 1000.0}},
+		{Type: "TriggerStudioModeTransition", Fields: nil},
+		{Type: "SetCurrentSceneTransition", Fields: map[string]interface{}{"transitionName": "Fade"}},
+		{Type: "SetCurrentSceneTransitionDuration", Fields: map[string]interface{}{"transitionDuration": 300.0}},
+		{Type: "GetStudioModeEnabled", Fields: nil},
+		{Type: "SetStudioModeEnabled", Fields: map[string]interface{}{"studioModeEnabled": false}},
+	})
+}
+
+func (s *StudioSuite) TestStudioEventsV5(c *C) {
+	tdata := map[string]Event{
+		`{"eventType":"CurrentPreviewSceneChanged","eventIntent":4,"eventData":{"sceneName":"BRB"}}`: &EventPreviewSceneChanged{
+			SceneName: "BRB",
+			rawEvent:  newRawEvent("PreviewSceneChanged"),
+		},
+		`{"eventType":"StudioModeStateChanged","eventIntent":1024,"eventData":{"studioModeEnabled":true}}`: &EventStudioModeSwitched{
+			NewState: true,
+			rawEvent: newRawEvent("StudioModeSwitched"),
+		},
+	}
+	for data, expected := range tdata {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package ws
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// subscriberBufferSize is the number of events kept for a subscriber
+// created with Subscribe.
+const subscriberBufferSize = 64
+
+// eventQueueSize is the number of events waiting to be delivered to
+// the subscribers, past which the events are dropped.
+const eventQueueSize = 1024
+
+// ErrEventDropped is reported on the Errors channel for an event
+// which was not delivered: to any subscriber when too many events
+// wait to be delivered, or to a DeliveryBlock subscriber which did
+// not receive it within the delivery timeout. The events dropped by
+// DeliveryDrop subscribers are not reported.
+type ErrEventDropped struct {
+	UpdateType string
+	// QueueFull is set when the event was dropped for every
+	// subscriber
+	QueueFull bool
+}
+
+func (e ErrEventDropped) Error() string {
+	if e.QueueFull == true {
+		return fmt.Sprintf("obsws: event '%s' dropped, too many events waiting to be delivered", e.UpdateType)
+	}
+	return fmt.Sprintf("obsws: event '%s' dropped, a subscriber did not receive it in time", e.UpdateType)
+}
+
+// DeliveryPolicy tells what happens to the events of a subscriber
+// which does not keep up with them.
+type DeliveryPolicy int
+
+const (
+	// DeliveryDrop drops the events while the channel of the
+	// subscriber is full.
+	DeliveryDrop DeliveryPolicy = iota
+	// DeliveryBlock waits for the subscriber to receive each event,
+	// delaying the events of every other subscriber, up to the
+	// delivery timeout set with WithDeliveryTimeout. The responses
+	// to the requests are never delayed.
+	DeliveryBlock
+)
+
+// subscriber receives the events of some types, or of every type if
+// types is empty.
+type subscriber struct {
+	types  map[string]bool
+	policy DeliveryPolicy
+	// unbounded DeliveryBlock subscribers wait for each event
+	// whatever the delivery timeout
+	unbounded bool
+	events    chan Event
+	// done is closed when the subscriber is cancelled, to unblock a
+	// pending delivery
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// lock guards closed and the sends on events
+	lock   sync.Mutex
+	closed bool
+}
+
+func newSubscriber(policy DeliveryPolicy, bufferSize int, types []string) *subscriber {
+	s := &subscriber{
+		types:  make(map[string]bool, len(types)),
+		policy: policy,
+		events: make(chan Event, bufferSize),
+		done:   make(chan struct{}),
+	}
+	for _, t := range types {
+		s.types[t] = true
+	}
+	return s
+}
+
+// deliver sends ev to the subscriber if it wants it, following its
+// policy. It tells if a DeliveryBlock subscriber did not receive ev
+// within timeout, zero waiting forever.
+func (s *subscriber) deliver(ev Event, closing <-chan struct{}, timeout time.Duration) bool {
+	if len(s.types) > 0 && s.types[ev.UpdateType()] == false {
+		return true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed == true {
+		return true
+	}
+	if s.policy == DeliveryDrop {
+		select {
+		case s.events <- ev:
+		default:
+		}
+		return true
+	}
+	var expired <-chan time.Time
+	if timeout > 0 && s.unbounded == false {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case s.events <- ev:
+	case <-s.done:
+	case <-closing:
+	case <-expired:
+		return false
+	}
+	return true
+}
+
+// close closes the channel of the subscriber, once no delivery is
+// pending.
+func (s *subscriber) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.closed = true
+		close(s.events)
+	})
+}
+
+// dispatchEvent queues ev for the subscribers. It never blocks, so a
+// slow subscriber cannot delay the handling of the responses.
+func (c *Client) dispatchEvent(ev Event) {
+	c.queueLock.Lock()
+	if len(c.queue) >= eventQueueSize {
+		c.queueLock.Unlock()
+		c.reportError(ErrEventDropped{UpdateType: ev.UpdateType(), QueueFull: true})
+		return
+	}
+	c.queue = append(c.queue, ev)
+	c.queueLock.Unlock()
+	select {
+	case c.queued <- struct{}{}:
+	default:
+	}
+}
+
+// deliverLoop sends the queued events to the subscribers, in order.
+func (c *Client) deliverLoop() {
+	defer c.wg.Done()
+	for {
+		select {
+		case <-c.queued:
+		case <-c.closing:
+			return
+		}
+		for {
+			c.queueLock.Lock()
+			if len(c.queue) == 0 {
+				c.queueLock.Unlock()
+				break
+			}
+			ev := c.queue[0]
+			c.queue[0] = nil
+			c.queue = c.queue[1:]
+			c.queueLock.Unlock()
+			if c.subscribers.deliver(ev, c.closing, c.conf.deliveryTimeout) == false {
+				c.reportError(ErrEventDropped{UpdateType: ev.UpdateType()})
+			}
+		}
+	}
+}
+
+// subscriberSet holds the subscribers of an event source.
+type subscriberSet struct {
+	lock sync.Mutex
+	uid  int
+	// subscribers is nil once the set is closed
+	subscribers map[int]*subscriber
+}
+
+func newSubscriberSet() *subscriberSet {
+	return &subscriberSet{subscribers: make(map[int]*subscriber)}
+}
+
+// add registers s and returns the function cancelling it. The
+// channel of s is closed right away if the set is closed.
+func (set *subscriberSet) add(s *subscriber) func() {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+	if set.subscribers == nil {
+		s.close()
+		return func() {}
+	}
+	set.uid++
+	uid := set.uid
+	set.subscribers[uid] = s
+	return func() {
+		set.lock.Lock()
+		delete(set.subscribers, uid)
+		set.lock.Unlock()
+		s.close()
+	}
+}
+
+// deliver sends ev to every subscriber, following their policy. It
+// tells if every DeliveryBlock subscriber received ev within timeout.
+func (set *subscriberSet) deliver(ev Event, closing <-chan struct{}, timeout time.Duration) bool {
+	set.lock.Lock()
+	subscribers := make([]*subscriber, 0, len(set.subscribers))
+	for _, s := range set.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	set.lock.Unlock()
+	delivered := true
+	for _, s := range subscribers {
+		if s.deliver(ev, closing, timeout) == false {
+			delivered = false
+		}
+	}
+	return delivered
+}
+
+// close closes the channel of every subscriber, and of the later
+// ones.
+func (set *subscriberSet) close() {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+	for _, s := range set.subscribers {
+		s.close()
+	}
+	set.subscribers = nil
+}
+
+// Subscribe returns a channel receiving the events of the given
+// types, or of every type if none is given, from now on. Events are
+// dropped while the channel is full. The channel is closed when the
+// returned function is called or when the client is closed.
+func (c *Client) Subscribe(types ...string) (<-chan Event, func()) {
+	return c.SubscribeWithPolicy(DeliveryDrop, subscriberBufferSize, types...)
+}
+
+// SubscribeWithPolicy is like Subscribe, with a channel holding
+// bufferSize events and the given delivery policy. A negative
+// bufferSize is taken as zero.
+func (c *Client) SubscribeWithPolicy(policy DeliveryPolicy, bufferSize int, types ...string) (<-chan Event, func()) {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	s := newSubscriber(policy, bufferSize, types)
+	return s.events, c.subscribers.add(s)
+}
+
+// On calls handler with every event of the given type, from its own
+// goroutine, until the returned function is called. Events are
+// dropped while the handler does not keep up, like with Subscribe.
+func (c *Client) On(eventType string, handler func(Event)) func() {
+	events, cancel := c.Subscribe(eventType)
+	go func() {
+		for ev := range events {
+			handler(ev)
+		}
+	}()
+	return cancel
+}
+
+// EventChannel returns a channel to read Event from. It is the same
+// unbuffered channel for every call, receiving every event with
+// DeliveryBlock, and closed when the client is closed. Each event
+// waits for the channel to be read, whatever the delivery timeout,
+// and meanwhile the other subscribers do not receive the next
+// events: a channel which is not read fills the event queue.
+func (c *Client) EventChannel() <-chan Event {
+	c.eventChannelLock.Lock()
+	defer c.eventChannelLock.Unlock()
+	if c.eventChannel == nil {
+		c.eventChannel = newSubscriber(DeliveryBlock, 0, nil)
+		c.eventChannel.unbounded = true
+		c.subscribers.add(c.eventChannel)
+	}
+	return c.eventChannel.events
+}
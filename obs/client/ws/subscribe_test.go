@@ -0,0 +1,187 @@
+package ws
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type SubscribeSuite struct{}
+
+var _ = Suite(&SubscribeSuite{})
+
+func (s *SubscribeSuite) TestSubscribeTypes(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	all, cancelAll := client.Subscribe()
+	defer cancelAll()
+	studio, cancelStudio := client.Subscribe("StudioModeSwitched", "Exiting")
+
+	server.Emit("ScenesChanged", nil)
+	server.Emit("StudioModeSwitched", map[string]interface{}{"new-state": true})
+
+	c.Check(nextEvent(c, all).UpdateType(), Equals, "ScenesChanged")
+	c.Check(nextEvent(c, all).UpdateType(), Equals, "StudioModeSwitched")
+	c.Check(nextEvent(c, studio), DeepEquals, &EventStudioModeSwitched{
+		NewState: true,
+		rawEvent: newRawEvent("StudioModeSwitched"),
+	})
+
+	cancelStudio()
+	_, ok := <-studio
+	c.Check(ok, Equals, false)
+	// cancelling twice is harmless
+	cancelStudio()
+}
+
+func (s *SubscribeSuite) TestSlowSubscribers(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	dropped, cancelDropped := client.SubscribeWithPolicy(DeliveryDrop, 1)
+	defer cancelDropped()
+	blocked, cancelBlocked := client.SubscribeWithPolicy(DeliveryBlock, 0)
+	defer cancelBlocked()
+
+	for i := 0; i < 3; i++ {
+		server.Emit("ScenesChanged", nil)
+	}
+	// nobody reads the events, the responses still come through
+	_, err := client.GetStudioModeStatus()
+	c.Check(err, IsNil)
+
+	for i := 0; i < 3; i++ {
+		nextEvent(c, blocked)
+	}
+	// let the last delivery to dropped happen
+	time.Sleep(50 * time.Millisecond)
+	nextEvent(c, dropped)
+	select {
+	case ev := <-dropped:
+		c.Errorf("unexpected event %v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (s *SubscribeSuite) TestDeliveryTimeout(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server, WithDeliveryTimeout(20*time.Millisecond))
+	defer client.Close()
+
+	// nobody reads the blocked channel, the other subscribers still
+	// receive the events
+	_, cancelBlocked := client.SubscribeWithPolicy(DeliveryBlock, 0)
+	defer cancelBlocked()
+	events, cancel := client.Subscribe()
+	defer cancel()
+	server.Emit("ScenesChanged", nil)
+	server.Emit("ProfileChanged", map[string]interface{}{"profile": "Twitch"})
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "ScenesChanged")
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "ProfileChanged")
+	for _, updateType := range []string{"ScenesChanged", "ProfileChanged"} {
+		select {
+		case err := <-client.Errors():
+			c.Check(err, Equals, ErrEventDropped{UpdateType: updateType})
+		case <-time.After(time.Second):
+			c.Fatal("dropped event not reported")
+		}
+	}
+}
+
+func (s *SubscribeSuite) TestEventChannelWaits(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server, WithDeliveryTimeout(20*time.Millisecond))
+	defer client.Close()
+
+	events := client.EventChannel()
+	server.Emit("ScenesChanged", nil)
+	time.Sleep(100 * time.Millisecond)
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "ScenesChanged")
+	select {
+	case err := <-client.Errors():
+		c.Errorf("unexpected error %v", err)
+	default:
+	}
+}
+
+func (s *SubscribeSuite) TestNegativeBufferSize(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	events, cancel := client.SubscribeWithPolicy(DeliveryDrop, -1)
+	defer cancel()
+	c.Check(cap(events), Equals, 0)
+}
+
+func (s *SubscribeSuite) TestQueueFull(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server, WithDeliveryTimeout(0))
+	defer client.Close()
+
+	blocked, cancel := client.SubscribeWithPolicy(DeliveryBlock, 0)
+	defer cancel()
+	for i := 0; i < eventQueueSize+2; i++ {
+		client.dispatchEvent(&EventScenesChanged{rawEvent: newRawEvent("ScenesChanged")})
+	}
+	select {
+	case err := <-client.Errors():
+		c.Check(err, Equals, ErrEventDropped{UpdateType: "ScenesChanged", QueueFull: true})
+	case <-time.After(time.Second):
+		c.Fatal("dropped event not reported")
+	}
+	// the queued events are still delivered
+	nextEvent(c, blocked)
+}
+
+func (s *SubscribeSuite) TestOn(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	profiles := make(chan string, 1)
+	cancel := client.On("ProfileChanged", func(ev Event) {
+		profiles <- ev.(*EventProfileChanged).Profile
+	})
+	defer cancel()
+
+	server.Emit("ScenesChanged", nil)
+	server.Emit("ProfileChanged", map[string]interface{}{"profile": "Twitch"})
+	select {
+	case profile := <-profiles:
+		c.Check(profile, Equals, "Twitch")
+	case <-time.After(time.Second):
+		c.Fatal("handler not called")
+	}
+}
+
+func (s *SubscribeSuite) TestClose(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+
+	events, cancel := client.Subscribe()
+	defer cancel()
+	client.Close()
+	_, ok := <-events
+	c.Check(ok, Equals, false)
+
+	// subscribing to a closed client returns a closed channel
+	events, _ = client.Subscribe()
+	_, ok = <-events
+	c.Check(ok, Equals, false)
+	_, ok = <-client.EventChannel()
+	c.Check(ok, Equals, false)
+}
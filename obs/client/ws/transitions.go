@@ -0,0 +1,248 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type Transition struct {
+	Name string `json:"name"`
+}
+
+type GetTransitionListResponse struct {
+	CurrentTransition string       `json:"current-transition"`
+	Transitions       []Transition `json:"transitions"`
+	responseBase
+}
+
+func (r *GetTransitionListResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		CurrentSceneTransitionName string `json:"currentSceneTransitionName"`
+		Transitions                []struct {
+			TransitionName string `json:"transitionName"`
+		} `json:"transitions"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.CurrentTransition = aux.CurrentSceneTransitionName
+	r.Transitions = make([]Transition, 0, len(aux.Transitions))
+	for _, t := range aux.Transitions {
+		r.Transitions = append(r.Transitions, Transition{Name: t.TransitionName})
+	}
+	return nil
+}
+
+type GetCurrentTransitionResponse struct {
+	Name string `json:"name"`
+	// Duration is in milliseconds, and zero for fixed transitions
+	Duration int `json:"duration"`
+	responseBase
+}
+
+func (r *GetCurrentTransitionResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		TransitionName     string `json:"transitionName"`
+		TransitionDuration int    `json:"transitionDuration"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Name = aux.TransitionName
+	r.Duration = aux.TransitionDuration
+	return nil
+}
+
+type GetTransitionDurationResponse struct {
+	// TransitionDuration is in milliseconds
+	TransitionDuration int `json:"transition-duration"`
+	responseBase
+}
+
+func (r *GetTransitionDurationResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		TransitionDuration int `json:"transitionDuration"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.TransitionDuration = aux.TransitionDuration
+	return nil
+}
+
+type GetTransitionPositionResponse struct {
+	// Position is between 0 and 1, and 0 when no transition is
+	// running
+	Position float64 `json:"position"`
+	responseBase
+}
+
+func (r *GetTransitionPositionResponse) unmarshalV5(data []byte) error {
+	aux := struct {
+		TransitionCursor float64 `json:"transitionCursor"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Position = aux.TransitionCursor
+	return nil
+}
+
+func forgeSetCurrentTransition(name string) request {
+	type setCurrentTransition struct {
+		requestBase
+		TransitionName string `json:"transition-name"`
+	}
+	return &setCurrentTransition{
+		requestBase: requestBase{
+			RequestType: "SetCurrentTransition",
+			rType:       &responseBase{},
+			v5Type:      "SetCurrentSceneTransition",
+			v5Data:      map[string]interface{}{"transitionName": name},
+		},
+		TransitionName: name,
+	}
+}
+
+func forgeSetTransitionDuration(duration int) request {
+	type setTransitionDuration struct {
+		requestBase
+		Duration int `json:"duration"`
+	}
+	return &setTransitionDuration{
+		requestBase: requestBase{
+			RequestType: "SetTransitionDuration",
+			rType:       &responseBase{},
+			v5Type:      "SetCurrentSceneTransitionDuration",
+			v5Data:      map[string]interface{}{"transitionDuration": duration},
+		},
+		Duration: duration,
+	}
+}
+
+// GetTransitionList returns the available transitions and the
+// current one.
+func (c *Client) GetTransitionList() (*GetTransitionListResponse, error) {
+	return c.GetTransitionListCtx(context.Background())
+}
+
+func (c *Client) GetTransitionListCtx(ctx context.Context) (*GetTransitionListResponse, error) {
+	resp := &GetTransitionListResponse{}
+	r := forgeRequestWithExpectedResponse("GetTransitionList", resp)
+	r.setV5Request("GetSceneTransitionList", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetCurrentTransition returns the transition used by scene switches.
+func (c *Client) GetCurrentTransition() (*GetCurrentTransitionResponse, error) {
+	return c.GetCurrentTransitionCtx(context.Background())
+}
+
+func (c *Client) GetCurrentTransitionCtx(ctx context.Context) (*GetCurrentTransitionResponse, error) {
+	resp := &GetCurrentTransitionResponse{}
+	r := forgeRequestWithExpectedResponse("GetCurrentTransition", resp)
+	r.setV5Request("GetCurrentSceneTransition", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetCurrentTransition changes the transition used by scene switches.
+func (c *Client) SetCurrentTransition(name string) error {
+	return c.SetCurrentTransitionCtx(context.Background(), name)
+}
+
+func (c *Client) SetCurrentTransitionCtx(ctx context.Context, name string) error {
+	c.sceneSwitchLock.Lock()
+	defer c.sceneSwitchLock.Unlock()
+	_, err := c.submitRequestCtx(ctx, forgeSetCurrentTransition(name))
+	return err
+}
+
+// SetTransitionDuration changes the duration of the current
+// transition, in milliseconds.
+func (c *Client) SetTransitionDuration(duration int) error {
+	return c.SetTransitionDurationCtx(context.Background(), duration)
+}
+
+func (c *Client) SetTransitionDurationCtx(ctx context.Context, duration int) error {
+	_, err := c.submitRequestCtx(ctx, forgeSetTransitionDuration(duration))
+	return err
+}
+
+// GetTransitionDuration returns the duration of the current
+// transition, in milliseconds.
+func (c *Client) GetTransitionDuration() (*GetTransitionDurationResponse, error) {
+	return c.GetTransitionDurationCtx(context.Background())
+}
+
+func (c *Client) GetTransitionDurationCtx(ctx context.Context) (*GetTransitionDurationResponse, error) {
+	resp := &GetTransitionDurationResponse{}
+	r := forgeRequestWithExpectedResponse("GetTransitionDuration", resp)
+	r.setV5Request("GetCurrentSceneTransition", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetTransitionPosition returns the progress of the running
+// transition.
+func (c *Client) GetTransitionPosition() (*GetTransitionPositionResponse, error) {
+	return c.GetTransitionPositionCtx(context.Background())
+}
+
+func (c *Client) GetTransitionPositionCtx(ctx context.Context) (*GetTransitionPositionResponse, error) {
+	resp := &GetTransitionPositionResponse{}
+	r := forgeRequestWithExpectedResponse("GetTransitionPosition", resp)
+	r.setV5Request("GetCurrentSceneTransitionCursor", nil)
+	if _, err := c.submitRequestCtx(ctx, r); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetCurrentSceneWithTransition switches to the scene using the
+// transition. Both changes are sent as one batch when the instance
+// supports it (see ExecuteBatch), so that no other client can change
+// the transition in between. With older versions they are sent one
+// after the other, and only the scene and transition changes of this
+// Client are kept from happening between both. The transition stays
+// selected if the scene switch fails.
+func (c *Client) SetCurrentSceneWithTransition(scene, transition string) error {
+	return c.SetCurrentSceneWithTransitionCtx(context.Background(), scene, transition)
+}
+
+func (c *Client) SetCurrentSceneWithTransitionCtx(ctx context.Context, scene, transition string) error {
+	native, err := c.supportsBatch(ctx)
+	if err != nil {
+		return err
+	}
+	if native == true {
+		b := &Batch{HaltOnFailure: true}
+		b.SetCurrentTransition(transition)
+		b.SetCurrentScene(scene)
+		results, err := c.executeNativeBatch(ctx, b)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			if result.Err != nil {
+				return result.Err
+			}
+		}
+		return nil
+	}
+
+	c.sceneSwitchLock.Lock()
+	defer c.sceneSwitchLock.Unlock()
+	if _, err := c.submitRequestCtx(ctx, forgeSetCurrentTransition(transition)); err != nil {
+		return err
+	}
+	_, err = c.submitRequestCtx(ctx, forgeSetCurrentScene(scene))
+	return err
+}
@@ -0,0 +1,191 @@
+package ws
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+type TransitionSuite struct{}
+
+var _ = Suite(&TransitionSuite{})
+
+func (s *TransitionSuite) TestTransitionRequests(c *C) {
+	checkRequests(c, []requestCase{
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetTransitionList()
+			},
+			request:  `{"request-type":"GetTransitionList"}`,
+			response: `{"current-transition":"Fade","transitions":[{"name":"Cut"},{"name":"Fade"},{"name":"Stinger"}]}`,
+			expected: &GetTransitionListResponse{
+				CurrentTransition: "Fade",
+				Transitions:       []Transition{{Name: "Cut"}, {Name: "Fade"}, {Name: "Stinger"}},
+				responseBase:      recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetCurrentTransition()
+			},
+			request:  `{"request-type":"GetCurrentTransition"}`,
+			response: `{"name":"Fade","duration":300}`,
+			expected: &GetCurrentTransitionResponse{
+				Name:         "Fade",
+				Duration:     300,
+				responseBase: recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetCurrentTransition("Stinger")
+			},
+			request:  `{"request-type":"SetCurrentTransition","transition-name":"Stinger"}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return nil, client.SetTransitionDuration(500)
+			},
+			request:  `{"request-type":"SetTransitionDuration","duration":500}`,
+			response: `{}`,
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetTransitionDuration()
+			},
+			request:  `{"request-type":"GetTransitionDuration"}`,
+			response: `{"transition-duration":500}`,
+			expected: &GetTransitionDurationResponse{
+				TransitionDuration: 500,
+				responseBase:       recordedOK,
+			},
+		},
+		{
+			call: func(client *Client) (interface{}, error) {
+				return client.GetTransitionPosition()
+			},
+			request:  `{"request-type":"GetTransitionPosition"}`,
+			response: `{"position":0.25}`,
+			expected: &GetTransitionPositionResponse{
+				Position:     0.25,
+				responseBase: recordedOK,
+			},
+		},
+	})
+}
+
+func (s *TransitionSuite) TestSetCurrentSceneWithTransition(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	c.Assert(client.SetCurrentSceneWithTransition("BRB", "Cut"), IsNil)
+	requests := server.Requests()
+	c.Assert(requests, HasLen, 2)
+	c.Check(requests[0].Type, Equals, "GetVersion")
+	c.Check(requests[1], DeepEquals, wstest.Request{Type: "ExecuteBatch", Fields: map[string]interface{}{
+		"abortOnFail": true,
+		"requests": []interface{}{
+			map[string]interface{}{"message-id": "0", "request-type": "SetCurrentTransition", "transition-name": "Cut"},
+			map[string]interface{}{"message-id": "1", "request-type": "SetCurrentScene", "scene-name": "BRB"},
+		},
+	}})
+	transition, _ := server.CurrentTransition()
+	c.Check(transition, Equals, "Cut")
+	c.Check(server.CurrentScene(), Equals, "BRB")
+
+	c.Check(client.SetCurrentSceneWithTransition("Nowhere", "Fade"), ErrorMatches, ".*requested scene does not exist.*")
+	transition, _ = server.CurrentTransition()
+	c.Check(transition, Equals, "Fade")
+}
+
+func (s *TransitionSuite) TestSetCurrentSceneWithTransitionSequential(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	server.Handle("GetVersion", func(map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"available-requests": "SetCurrentScene,SetCurrentTransition"}, nil
+	})
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	c.Assert(client.SetCurrentSceneWithTransition("BRB", "Cut"), IsNil)
+	c.Check(server.Requests()[1:], DeepEquals, []wstest.Request{
+		{Type: "SetCurrentTransition", Fields: map[string]interface{}{"transition-name": "Cut"}},
+		{Type: "SetCurrentScene", Fields: map[string]interface{}{"scene-name": "BRB"}},
+	})
+	transition, _ := server.CurrentTransition()
+	c.Check(transition, Equals, "Cut")
+	c.Check(server.CurrentScene(), Equals, "BRB")
+}
+
+func (s *TransitionSuite) TestSetCurrentSceneWithTransitionV5(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newServerClient(c, server)
+	defer client.Close()
+
+	c.Assert(client.SetCurrentSceneWithTransition("BRB", "Cut"), IsNil)
+	c.Check(server.Requests(), HasLen, 1)
+	transition, _ := server.CurrentTransition()
+	c.Check(transition, Equals, "Cut")
+	c.Check(server.CurrentScene(), Equals, "BRB")
+}
+
+func (s *TransitionSuite) TestTransitionResponsesV5(c *C) {
+	list := &GetTransitionListResponse{}
+	c.Assert(list.unmarshalV5([]byte(`{"currentSceneTransitionName":"Fade","currentSceneTransitionKind":"fade_transition","transitions":[{"transitionName":"Cut","transitionKind":"cut_transition","transitionFixed":true,"transitionConfigurable":false},{"transitionName":"Fade","transitionKind":"fade_transition","transitionFixed":false,"transitionConfigurable":false}]}`)), IsNil)
+	c.Check(list, DeepEquals, &GetTransitionListResponse{
+		CurrentTransition: "Fade",
+		Transitions:       []Transition{{Name: "Cut"}, {Name: "Fade"}},
+	})
+
+	duration := &GetTransitionDurationResponse{}
+	c.Assert(duration.unmarshalV5([]byte(`{"transitionName":"Fade","transitionKind":"fade_transition","transitionFixed":false,"transitionDuration":300}`)), IsNil)
+	c.Check(duration.TransitionDuration, Equals, 300)
+
+	position := &GetTransitionPositionResponse{}
+	c.Assert(position.unmarshalV5([]byte(`{"transitionCursor":0.5}`)), IsNil)
+	c.Check(position.Position, Equals, 0.5)
+}
+
+func (s *TransitionSuite) TestTransitionEvents(c *C) {
+	v4 := map[string]Event{
+		`{"update-type":"TransitionBegin","name":"Fade","type":"fade_transition","duration":300,"from-scene":"Live","to-scene":"BRB"}`: &EventTransitionBegin{
+			Name:      "Fade",
+			Type:      "fade_transition",
+			Duration:  300,
+			FromScene: "Live",
+			ToScene:   "BRB",
+			rawEvent:  newRawEvent("TransitionBegin"),
+		},
+		`{"update-type":"TransitionListChanged","transitions":[{"name":"Cut"}]}`: &EventTransitionListChanged{
+			Transitions: []Transition{{Name: "Cut"}},
+			rawEvent:    newRawEvent("TransitionListChanged"),
+		},
+	}
+	for data, expected := range v4 {
+		ev, err := UnmarshalEvent([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+
+	v5 := map[string]Event{
+		`{"eventType":"CurrentSceneTransitionChanged","eventIntent":16,"eventData":{"transitionName":"Stinger"}}`: &EventSwitchTransition{
+			TransitionName: "Stinger",
+			rawEvent:       newRawEvent("SwitchTransition"),
+		},
+		`{"eventType":"SceneTransitionVideoEnded","eventIntent":16,"eventData":{"transitionName":"Stinger"}}`: &EventTransitionVideoEnd{
+			Name:     "Stinger",
+			rawEvent: newRawEvent("TransitionVideoEnd"),
+		},
+	}
+	for data, expected := range v5 {
+		ev, err := unmarshalEventV5([]byte(data))
+		if c.Check(err, IsNil, Commentf(data)) == true {
+			c.Check(ev, DeepEquals, expected)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package ws
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"runtime"
 )
@@ -15,3 +17,13 @@ func NotYetImplemented() error {
 
 	return fmt.Errorf("%s is not yet implemented", fun.Name())
 }
+
+// authResponse computes the authentication string expected by OBS
+// from the password and the salt and challenge it sent us:
+// base64(sha256(base64(sha256(password + salt)) + challenge))
+func authResponse(password, salt, challenge string) string {
+	secret := sha256.Sum256([]byte(password + salt))
+	secretStr := base64.StdEncoding.EncodeToString(secret[:])
+	auth := sha256.Sum256([]byte(secretStr + challenge))
+	return base64.StdEncoding.EncodeToString(auth[:])
+}
@@ -0,0 +1,856 @@
+package wstest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// call is a request being answered by a built-in handler, which reads
+// its parameters and forges its response and events in the protocol
+// of the server.
+type call struct {
+	v5     bool
+	fields map[string]interface{}
+	events []event
+}
+
+func (c *call) field(v4, v5 string) (interface{}, bool) {
+	name := v4
+	if c.v5 == true {
+		name = v5
+	}
+	v, ok := c.fields[name]
+	return v, ok
+}
+
+func (c *call) string(v4, v5 string) string {
+	v, _ := c.field(v4, v5)
+	s, _ := v.(string)
+	return s
+}
+
+func (c *call) bool(v4, v5 string) bool {
+	v, _ := c.field(v4, v5)
+	b, _ := v.(bool)
+	return b
+}
+
+func (c *call) number(v4, v5 string) float64 {
+	v, _ := c.field(v4, v5)
+	n, _ := v.(float64)
+	return n
+}
+
+func (c *call) has(v4, v5 string) bool {
+	_, ok := c.field(v4, v5)
+	return ok
+}
+
+// reply returns the response fields of the protocol.
+func (c *call) reply(v4, v5 map[string]interface{}) map[string]interface{} {
+	if c.v5 == true {
+		return v5
+	}
+	return v4
+}
+
+// emit queues an event, sent after the response.
+func (c *call) emit(v4Type string, v4 map[string]interface{}, v5Type string, v5 map[string]interface{}) {
+	c.events = append(c.events, event{v4Type: v4Type, v4: v4, v5Type: v5Type, v5: v5})
+}
+
+type builtin struct {
+	// v4 and v5 are the request types in each protocol, empty if the
+	// request does not exist in the protocol
+	v4, v5 string
+	handle func(st *state, c *call) (map[string]interface{}, error)
+	// sleep is set for Sleep, which is not answered under the lock
+	sleep bool
+}
+
+var (
+	errSceneNotFound      = errors.New("requested scene does not exist")
+	errSourceNotFound     = errors.New("specified source doesn't exist")
+	errItemNotFound       = errors.New("specified scene item doesn't exist")
+	errTransitionNotFound = errors.New("specified transition doesn't exist")
+	errStudioModeDisabled = errors.New("studio mode not enabled")
+)
+
+var builtinList = []builtin{
+	{v4: "GetVersion", v5: "GetVersion", handle: getVersion},
+	{v4: "Sleep", v5: "Sleep", sleep: true},
+	{v4: "BroadcastCustomMessage", v5: "BroadcastCustomEvent", handle: broadcastCustomMessage},
+	{v4: "TriggerHotkeyByName", v5: "TriggerHotkeyByName", handle: triggerHotkey},
+	{v4: "TriggerHotkeyBySequence", v5: "TriggerHotkeyByKeySequence", handle: triggerHotkey},
+
+	{v4: "GetSceneList", v5: "GetSceneList", handle: getSceneList},
+	{v4: "GetCurrentScene", v5: "GetCurrentProgramScene", handle: getCurrentScene},
+	{v4: "SetCurrentScene", v5: "SetCurrentProgramScene", handle: setCurrentScene},
+	{v4: "GetSceneItemList", v5: "GetSceneItemList", handle: getSceneItemList},
+	{v5: "GetSceneItemId", handle: getSceneItemID},
+	{v4: "SetSceneItemRender", v5: "SetSceneItemEnabled", handle: setSceneItemRender},
+
+	{v4: "GetStudioModeStatus", v5: "GetStudioModeEnabled", handle: getStudioModeStatus},
+	{v4: "EnableStudioMode", handle: setStudioMode(true)},
+	{v4: "DisableStudioMode", handle: setStudioMode(false)},
+	{v5: "SetStudioModeEnabled", handle: setStudioModeEnabled},
+	{v4: "GetPreviewScene", v5: "GetCurrentPreviewScene", handle: getPreviewScene},
+	{v4: "SetPreviewScene", v5: "SetCurrentPreviewScene", handle: setPreviewScene},
+	{v4: "TransitionToProgram", v5: "TriggerStudioModeTransition", handle: transitionToProgram},
+
+	{v4: "GetStreamingStatus", v5: "GetStreamStatus", handle: getStreamingStatus},
+	{v5: "GetRecordStatus", handle: getRecordStatus},
+	{v4: "StartStreaming", v5: "StartStream", handle: setStreaming(true, false)},
+	{v4: "StopStreaming", v5: "StopStream", handle: setStreaming(false, false)},
+	{v4: "StartStopStreaming", v5: "ToggleStream", handle: setStreaming(false, true)},
+	{v4: "StartRecording", v5: "StartRecord", handle: startRecording},
+	{v4: "StopRecording", v5: "StopRecord", handle: stopRecording},
+	{v4: "PauseRecording", v5: "PauseRecord", handle: pauseRecording(true)},
+	{v4: "ResumeRecording", v5: "ResumeRecord", handle: pauseRecording(false)},
+	{v4: "GetReplayBufferStatus", v5: "GetReplayBufferStatus", handle: getReplayBufferStatus},
+	{v4: "StartReplayBuffer", v5: "StartReplayBuffer", handle: setReplayBuffer(true)},
+	{v4: "StopReplayBuffer", v5: "StopReplayBuffer", handle: setReplayBuffer(false)},
+	{v4: "SaveReplayBuffer", v5: "SaveReplayBuffer", handle: saveReplayBuffer},
+
+	{v4: "GetMute", v5: "GetInputMute", handle: getMute},
+	{v4: "SetMute", v5: "SetInputMute", handle: setMute(false)},
+	{v4: "ToggleMute", v5: "ToggleInputMute", handle: setMute(true)},
+	{v4: "GetVolume", v5: "GetInputVolume", handle: getVolume},
+	{v4: "SetVolume", v5: "SetInputVolume", handle: setVolume},
+
+	{v4: "GetTransitionList", v5: "GetSceneTransitionList", handle: getTransitionList},
+	{v4: "GetCurrentTransition", v5: "GetCurrentSceneTransition", handle: getCurrentTransition},
+	{v4: "SetCurrentTransition", v5: "SetCurrentSceneTransition", handle: setCurrentTransition},
+	{v4: "GetTransitionDuration", handle: getTransitionDuration},
+	{v4: "SetTransitionDuration", v5: "SetCurrentSceneTransitionDuration", handle: setTransitionDuration},
+
+	{v4: "GetSourceSettings", v5: "GetInputSettings", handle: getSourceSettings},
+	{v4: "SetSourceSettings", v5: "SetInputSettings", handle: setSourceSettings},
+	{v4: "GetTextGDIPlusProperties", handle: getProperties("text_gdiplus")},
+	{v4: "SetTextGDIPlusProperties", handle: setProperties("text_gdiplus")},
+	{v4: "GetTextFreetype2Properties", handle: getProperties("text_ft2_source")},
+	{v4: "SetTextFreetype2Properties", handle: setProperties("text_ft2_source")},
+	{v4: "GetBrowserSourceProperties", handle: getProperties("browser_source")},
+	{v4: "SetBrowserSourceProperties", handle: setProperties("browser_source")},
+
+	{v4: "GetSourceFilters", v5: "GetSourceFilterList", handle: getSourceFilters},
+	{v4: "GetSourceFilterInfo", v5: "GetSourceFilter", handle: getSourceFilterInfo},
+	{v4: "AddFilterToSource", v5: "CreateSourceFilter", handle: addFilterToSource},
+	{v4: "RemoveFilterFromSource", v5: "RemoveSourceFilter", handle: removeFilterFromSource},
+	{v4: "ReorderSourceFilter", v5: "SetSourceFilterIndex", handle: reorderSourceFilter},
+	{v4: "SetSourceFilterSettings", v5: "SetSourceFilterSettings", handle: setSourceFilterSettings},
+	{v4: "SetSourceFilterVisibility", v5: "SetSourceFilterEnabled", handle: setSourceFilterVisibility},
+
+	{v4: "TakeSourceScreenshot", v5: "GetSourceScreenshot", handle: takeSourceScreenshot},
+	{v5: "SaveSourceScreenshot", handle: takeSourceScreenshot},
+
+	{v4: "PlayPauseMedia", handle: playPauseMedia},
+	{v4: "RestartMedia", handle: mediaAction("RESTART")},
+	{v4: "StopMedia", handle: mediaAction("STOP")},
+	{v4: "NextMedia", handle: mediaAction("NEXT")},
+	{v4: "PreviousMedia", handle: mediaAction("PREVIOUS")},
+	{v5: "TriggerMediaInputAction", handle: triggerMediaInputAction},
+	{v4: "GetMediaDuration", handle: getMediaDuration},
+	{v4: "GetMediaTime", handle: getMediaTime},
+	{v4: "GetMediaState", handle: getMediaState},
+	{v5: "GetMediaInputStatus", handle: getMediaInputStatus},
+	{v4: "SetMediaTime", v5: "SetMediaInputCursor", handle: setMediaTime},
+	{v4: "ScrubMedia", v5: "OffsetMediaInputCursor", handle: scrubMedia},
+	{v4: "GetMediaSourcesList", handle: getMediaSourcesList},
+
+	{v4: "ListProfiles", v5: "GetProfileList", handle: listProfiles},
+	{v4: "GetCurrentProfile", handle: getCurrentProfile},
+	{v4: "SetCurrentProfile", v5: "SetCurrentProfile", handle: setCurrentProfile},
+	{v4: "ListSceneCollections", v5: "GetSceneCollectionList", handle: listSceneCollections},
+	{v4: "GetCurrentSceneCollection", handle: getCurrentSceneCollection},
+	{v4: "SetCurrentSceneCollection", v5: "SetCurrentSceneCollection", handle: setCurrentSceneCollection},
+}
+
+// builtins indexes builtinList by protocol and request type, and
+// requestTypes lists the requests supported in each protocol,
+// ExecuteBatch included for 4.x.
+var (
+	builtins     = map[Protocol]map[string]builtin{}
+	requestTypes = map[Protocol][]string{}
+)
+
+func init() {
+	builtins[ProtocolV4] = make(map[string]builtin)
+	builtins[ProtocolV5] = make(map[string]builtin)
+	for _, b := range builtinList {
+		if len(b.v4) > 0 {
+			builtins[ProtocolV4][b.v4] = b
+		}
+		if len(b.v5) > 0 {
+			builtins[ProtocolV5][b.v5] = b
+		}
+	}
+	for protocol, table := range builtins {
+		for requestType := range table {
+			requestTypes[protocol] = append(requestTypes[protocol], requestType)
+		}
+	}
+	requestTypes[ProtocolV4] = append(requestTypes[ProtocolV4], "ExecuteBatch")
+	for _, types := range requestTypes {
+		sort.Strings(types)
+	}
+}
+
+func getVersion(st *state, c *call) (map[string]interface{}, error) {
+	return c.reply(map[string]interface{}{
+		"version":                        1.1,
+		"obs-websocket-version":          "4.9.1",
+		"obs-studio-version":             "27.0.0",
+		"available-requests":             strings.Join(requestTypes[ProtocolV4], ","),
+		"supported-image-export-formats": "png",
+	}, map[string]interface{}{
+		"obsVersion":            "29.1.0",
+		"obsWebSocketVersion":   "5.1.0",
+		"rpcVersion":            1,
+		"availableRequests":     requestTypes[ProtocolV5],
+		"supportedImageFormats": []string{"png"},
+	}), nil
+}
+
+// broadcastCustomMessage sends the message back to every client.
+func broadcastCustomMessage(st *state, c *call) (map[string]interface{}, error) {
+	if c.v5 == true {
+		data, ok := c.fields["eventData"].(map[string]interface{})
+		if ok == false {
+			return nil, errors.New("missing eventData")
+		}
+		c.emit("", nil, "CustomEvent", data)
+		return nil, nil
+	}
+	realm := c.string("realm", "")
+	data, ok := c.fields["data"].(map[string]interface{})
+	if len(realm) == 0 || ok == false {
+		return nil, errors.New("missing realm or data")
+	}
+	c.emit("BroadcastCustomMessage", map[string]interface{}{"realm": realm, "data": data}, "", nil)
+	return nil, nil
+}
+
+// triggerHotkey accepts any hotkey, the fake OBS has no action bound
+// to them. The requests show in Server.Requests.
+func triggerHotkey(st *state, c *call) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+// sources returns the items of the scene as 4.x sources.
+func sources(scene *Scene) []map[string]interface{} {
+	res := make([]map[string]interface{}, 0, len(scene.Items))
+	for _, item := range scene.Items {
+		res = append(res, map[string]interface{}{
+			"name":   item.Name,
+			"id":     item.ID,
+			"type":   "input",
+			"render": item.Visible,
+		})
+	}
+	return res
+}
+
+func getSceneList(st *state, c *call) (map[string]interface{}, error) {
+	if c.v5 == false {
+		scenes := make([]map[string]interface{}, 0, len(st.scenes))
+		for i := range st.scenes {
+			scenes = append(scenes, map[string]interface{}{
+				"name":    st.scenes[i].Name,
+				"sources": sources(&st.scenes[i]),
+			})
+		}
+		return map[string]interface{}{"current-scene": st.currentScene, "scenes": scenes}, nil
+	}
+	// 5.x indexes scenes from the bottom of the list
+	scenes := make([]map[string]interface{}, 0, len(st.scenes))
+	for i, scene := range st.scenes {
+		scenes = append(scenes, map[string]interface{}{
+			"sceneName":  scene.Name,
+			"sceneIndex": len(st.scenes) - 1 - i,
+		})
+	}
+	resp := map[string]interface{}{
+		"currentProgramSceneName": st.currentScene,
+		"currentPreviewSceneName": nil,
+		"scenes":                  scenes,
+	}
+	if st.studioMode == true {
+		resp["currentPreviewSceneName"] = st.previewScene
+	}
+	return resp, nil
+}
+
+func getCurrentScene(st *state, c *call) (map[string]interface{}, error) {
+	scene := st.scene(st.currentScene)
+	if scene == nil {
+		return nil, errSceneNotFound
+	}
+	return c.reply(
+		map[string]interface{}{"name": scene.Name, "sources": sources(scene)},
+		map[string]interface{}{"currentProgramSceneName": scene.Name},
+	), nil
+}
+
+func setCurrentScene(st *state, c *call) (map[string]interface{}, error) {
+	scene := st.scene(c.string("scene-name", "sceneName"))
+	if scene == nil {
+		return nil, errSceneNotFound
+	}
+	st.switchScene(c, scene)
+	return nil, nil
+}
+
+// switchScene makes scene the program scene.
+func (st *state) switchScene(c *call, scene *Scene) {
+	st.currentScene = scene.Name
+	c.emit("SwitchScenes", map[string]interface{}{"scene-name": scene.Name, "sources": sources(scene)},
+		"CurrentProgramSceneChanged", map[string]interface{}{"sceneName": scene.Name})
+}
+
+// itemScene returns the scene of a scene item request, the current
+// scene if not given.
+func (st *state) itemScene(c *call) (*Scene, error) {
+	name := c.string("scene-name", "sceneName")
+	if len(name) == 0 {
+		name = st.currentScene
+	}
+	if c.v5 == false && c.has("sceneName", "") == true {
+		// GetSceneItemList has a 5.x-like parameter in 4.x
+		name = c.string("sceneName", "")
+	}
+	scene := st.scene(name)
+	if scene == nil {
+		return nil, errSceneNotFound
+	}
+	return scene, nil
+}
+
+func getSceneItemList(st *state, c *call) (map[string]interface{}, error) {
+	scene, err := st.itemScene(c)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(scene.Items))
+	for i, item := range scene.Items {
+		if c.v5 == false {
+			items = append(items, map[string]interface{}{
+				"itemId":     item.ID,
+				"sourceName": item.Name,
+				"sourceType": "input",
+			})
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"sceneItemId":      item.ID,
+			"sourceName":       item.Name,
+			"sceneItemEnabled": item.Visible,
+			"sceneItemIndex":   len(scene.Items) - 1 - i,
+		})
+	}
+	return c.reply(
+		map[string]interface{}{"sceneName": scene.Name, "sceneItems": items},
+		map[string]interface{}{"sceneItems": items},
+	), nil
+}
+
+func getSceneItemID(st *state, c *call) (map[string]interface{}, error) {
+	scene, err := st.itemScene(c)
+	if err != nil {
+		return nil, err
+	}
+	name := c.string("", "sourceName")
+	for _, item := range scene.Items {
+		if item.Name == name {
+			return map[string]interface{}{"sceneItemId": item.ID}, nil
+		}
+	}
+	return nil, errItemNotFound
+}
+
+func setSceneItemRender(st *state, c *call) (map[string]interface{}, error) {
+	scene, err := st.itemScene(c)
+	if err != nil {
+		return nil, err
+	}
+	name := c.string("source", "")
+	id := int(c.number("", "sceneItemId"))
+	for i := range scene.Items {
+		item := &scene.Items[i]
+		if (c.v5 == false && item.Name != name) || (c.v5 == true && item.ID != id) {
+			continue
+		}
+		item.Visible = c.bool("render", "sceneItemEnabled")
+		c.emit("SceneItemVisibilityChanged", map[string]interface{}{
+			"scene-name":   scene.Name,
+			"item-name":    item.Name,
+			"item-id":      item.ID,
+			"item-visible": item.Visible,
+		}, "SceneItemEnableStateChanged", map[string]interface{}{
+			"sceneName":        scene.Name,
+			"sceneItemId":      item.ID,
+			"sceneItemEnabled": item.Visible,
+		})
+		return nil, nil
+	}
+	return nil, errItemNotFound
+}
+
+func getStudioModeStatus(st *state, c *call) (map[string]interface{}, error) {
+	return c.reply(
+		map[string]interface{}{"studio-mode": st.studioMode},
+		map[string]interface{}{"studioModeEnabled": st.studioMode},
+	), nil
+}
+
+func setStudioMode(enabled bool) func(st *state, c *call) (map[string]interface{}, error) {
+	return func(st *state, c *call) (map[string]interface{}, error) {
+		st.setStudioMode(c, enabled)
+		return nil, nil
+	}
+}
+
+func setStudioModeEnabled(st *state, c *call) (map[string]interface{}, error) {
+	st.setStudioMode(c, c.bool("", "studioModeEnabled"))
+	return nil, nil
+}
+
+// setStudioMode enables or disables studio mode, the preview scene
+// starting as the program scene.
+func (st *state) setStudioMode(c *call, enabled bool) {
+	if st.studioMode == enabled {
+		return
+	}
+	st.studioMode = enabled
+	st.previewScene = ""
+	if enabled == true {
+		st.previewScene = st.currentScene
+	}
+	c.emit("StudioModeSwitched", map[string]interface{}{"new-state": enabled},
+		"StudioModeStateChanged", map[string]interface{}{"studioModeEnabled": enabled})
+}
+
+func getPreviewScene(st *state, c *call) (map[string]interface{}, error) {
+	if st.studioMode == false {
+		return nil, errStudioModeDisabled
+	}
+	scene := st.scene(st.previewScene)
+	if scene == nil {
+		return nil, errSceneNotFound
+	}
+	return c.reply(
+		map[string]interface{}{"name": scene.Name, "sources": sources(scene)},
+		map[string]interface{}{"currentPreviewSceneName": scene.Name},
+	), nil
+}
+
+func setPreviewScene(st *state, c *call) (map[string]interface{}, error) {
+	if st.studioMode == false {
+		return nil, errStudioModeDisabled
+	}
+	scene := st.scene(c.string("scene-name", "sceneName"))
+	if scene == nil {
+		return nil, errSceneNotFound
+	}
+	st.previewScene = scene.Name
+	c.emit("PreviewSceneChanged", map[string]interface{}{"scene-name": scene.Name, "sources": sources(scene)},
+		"CurrentPreviewSceneChanged", map[string]interface{}{"sceneName": scene.Name})
+	return nil, nil
+}
+
+// transitionToProgram swaps the program and preview scenes, like OBS
+// does by default.
+func transitionToProgram(st *state, c *call) (map[string]interface{}, error) {
+	if st.studioMode == false {
+		return nil, errStudioModeDisabled
+	}
+	preview := st.scene(st.previewScene)
+	if preview == nil {
+		return nil, errSceneNotFound
+	}
+	program := st.currentScene
+	st.switchScene(c, preview)
+	if scene := st.scene(program); scene != nil {
+		st.previewScene = scene.Name
+		c.emit("PreviewSceneChanged", map[string]interface{}{"scene-name": scene.Name, "sources": sources(scene)},
+			"CurrentPreviewSceneChanged", map[string]interface{}{"sceneName": scene.Name})
+	}
+	// the transition ends at once
+	c.emit("TransitionEnd", map[string]interface{}{
+		"name":     st.currentTransition,
+		"duration": int(st.transitionDuration / time.Millisecond),
+		"to-scene": preview.Name,
+	}, "SceneTransitionEnded", map[string]interface{}{"transitionName": st.currentTransition})
+	return nil, nil
+}
+
+func getStreamingStatus(st *state, c *call) (map[string]interface{}, error) {
+	return c.reply(map[string]interface{}{
+		"streaming":        st.streaming,
+		"recording":        st.recording,
+		"recording-paused": st.recordingPaused,
+		"preview-only":     false,
+	}, map[string]interface{}{
+		"outputActive":       st.streaming,
+		"outputReconnecting": false,
+		"outputTimecode":     "00:00:00.000",
+	}), nil
+}
+
+func getRecordStatus(st *state, c *call) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"outputActive":   st.recording,
+		"outputPaused":   st.recordingPaused,
+		"outputTimecode": "00:00:00.000",
+	}, nil
+}
+
+// setStreaming starts or stops the stream, or toggles it.
+func setStreaming(start, toggle bool) func(st *state, c *call) (map[string]interface{}, error) {
+	return func(st *state, c *call) (map[string]interface{}, error) {
+		start := start
+		if toggle == true {
+			start = st.streaming == false
+		}
+		if start == st.streaming {
+			if start == true {
+				return nil, errors.New("streaming already active")
+			}
+			return nil, errors.New("streaming not active")
+		}
+		st.streaming = start
+		v4Type, outputState := "StreamStopped", "OBS_WEBSOCKET_OUTPUT_STOPPED"
+		if start == true {
+			v4Type, outputState = "StreamStarted", "OBS_WEBSOCKET_OUTPUT_STARTED"
+		}
+		c.emit(v4Type, map[string]interface{}{},
+			"StreamStateChanged", map[string]interface{}{"outputActive": start, "outputState": outputState})
+		if toggle == true && c.v5 == true {
+			return map[string]interface{}{"outputActive": start}, nil
+		}
+		return nil, nil
+	}
+}
+
+const recordingFilename = "/tmp/recording.mkv"
+
+func startRecording(st *state, c *call) (map[string]interface{}, error) {
+	if st.recording == true {
+		return nil, errors.New("recording already active")
+	}
+	st.recording = true
+	st.recordingPaused = false
+	c.emit("RecordingStarted", map[string]interface{}{"recordingFilename": recordingFilename},
+		"RecordStateChanged", map[string]interface{}{
+			"outputActive": true,
+			"outputState":  "OBS_WEBSOCKET_OUTPUT_STARTED",
+			"outputPath":   recordingFilename,
+		})
+	return nil, nil
+}
+
+func stopRecording(st *state, c *call) (map[string]interface{}, error) {
+	if st.recording == false {
+		return nil, errors.New("recording not active")
+	}
+	st.recording = false
+	st.recordingPaused = false
+	c.emit("RecordingStopped", map[string]interface{}{"recordingFilename": recordingFilename},
+		"RecordStateChanged", map[string]interface{}{
+			"outputActive": false,
+			"outputState":  "OBS_WEBSOCKET_OUTPUT_STOPPED",
+			"outputPath":   recordingFilename,
+		})
+	return c.reply(nil, map[string]interface{}{"outputPath": recordingFilename}), nil
+}
+
+func pauseRecording(pause bool) func(st *state, c *call) (map[string]interface{}, error) {
+	return func(st *state, c *call) (map[string]interface{}, error) {
+		if st.recording == false {
+			return nil, errors.New("recording not active")
+		}
+		if st.recordingPaused == pause {
+			if pause == true {
+				return nil, errors.New("recording already paused")
+			}
+			return nil, errors.New("recording is not paused")
+		}
+		st.recordingPaused = pause
+		v4Type, outputState := "RecordingResumed", "OBS_WEBSOCKET_OUTPUT_RESUMED"
+		if pause == true {
+			v4Type, outputState = "RecordingPaused", "OBS_WEBSOCKET_OUTPUT_PAUSED"
+		}
+		c.emit(v4Type, map[string]interface{}{},
+			"RecordStateChanged", map[string]interface{}{"outputActive": true, "outputState": outputState})
+		return nil, nil
+	}
+}
+
+func getReplayBufferStatus(st *state, c *call) (map[string]interface{}, error) {
+	return c.reply(
+		map[string]interface{}{"isReplayBufferActive": st.replayBuffer},
+		map[string]interface{}{"outputActive": st.replayBuffer},
+	), nil
+}
+
+// setReplayBuffer starts or stops the replay buffer.
+func setReplayBuffer(start bool) func(st *state, c *call) (map[string]interface{}, error) {
+	return func(st *state, c *call) (map[string]interface{}, error) {
+		if start == st.replayBuffer {
+			if start == true {
+				return nil, errors.New("replay buffer already active")
+			}
+			return nil, errors.New("replay buffer not active")
+		}
+		st.replayBuffer = start
+		v4Type, outputState := "ReplayStopped", "OBS_WEBSOCKET_OUTPUT_STOPPED"
+		if start == true {
+			v4Type, outputState = "ReplayStarted", "OBS_WEBSOCKET_OUTPUT_STARTED"
+		}
+		c.emit(v4Type, map[string]interface{}{},
+			"ReplayBufferStateChanged", map[string]interface{}{"outputActive": start, "outputState": outputState})
+		return nil, nil
+	}
+}
+
+const replayFilename = "/tmp/replay.mkv"
+
+// saveReplayBuffer saves the replay buffer, which 5.x reports with
+// the ReplayBufferSaved event.
+func saveReplayBuffer(st *state, c *call) (map[string]interface{}, error) {
+	if st.replayBuffer == false {
+		return nil, errors.New("replay buffer not active")
+	}
+	if c.v5 == true {
+		c.emit("", nil, "ReplayBufferSaved", map[string]interface{}{"savedReplayPath": replayFilename})
+	}
+	return nil, nil
+}
+
+func (st *state) callInput(c *call) (*Input, error) {
+	in := st.input(c.string("source", "inputName"))
+	if in == nil {
+		return nil, errSourceNotFound
+	}
+	return in, nil
+}
+
+func getMute(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.callInput(c)
+	if err != nil {
+		return nil, err
+	}
+	return c.reply(
+		map[string]interface{}{"name": in.Name, "muted": in.Muted},
+		map[string]interface{}{"inputMuted": in.Muted},
+	), nil
+}
+
+// setMute sets the mute state of an input, or toggles it.
+func setMute(toggle bool) func(st *state, c *call) (map[string]interface{}, error) {
+	return func(st *state, c *call) (map[string]interface{}, error) {
+		in, err := st.callInput(c)
+		if err != nil {
+			return nil, err
+		}
+		muted := in.Muted == false
+		if toggle == false {
+			muted = c.bool("mute", "inputMuted")
+		}
+		if muted != in.Muted {
+			in.Muted = muted
+			c.emit("SourceMuteStateChanged", map[string]interface{}{"sourceName": in.Name, "muted": muted},
+				"InputMuteStateChanged", map[string]interface{}{"inputName": in.Name, "inputMuted": muted})
+		}
+		if toggle == true {
+			return c.reply(nil, map[string]interface{}{"inputMuted": muted}), nil
+		}
+		return nil, nil
+	}
+}
+
+// decibels converts a volume multiplier to dB, -100 standing for
+// silence as JSON has no infinity.
+func decibels(mul float64) float64 {
+	if mul <= 0 {
+		return -100
+	}
+	return math.Max(20*math.Log10(mul), -100)
+}
+
+func getVolume(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.callInput(c)
+	if err != nil {
+		return nil, err
+	}
+	volume := in.Volume
+	if c.bool("useDecibel", "") == true {
+		volume = decibels(in.Volume)
+	}
+	return c.reply(
+		map[string]interface{}{"name": in.Name, "volume": volume, "muted": in.Muted},
+		map[string]interface{}{"inputVolumeMul": in.Volume, "inputVolumeDb": decibels(in.Volume)},
+	), nil
+}
+
+func setVolume(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.callInput(c)
+	if err != nil {
+		return nil, err
+	}
+	volume := c.number("volume", "inputVolumeMul")
+	if c.bool("useDecibel", "") == true || c.has("", "inputVolumeDb") == true {
+		volume = math.Pow(10, c.number("volume", "inputVolumeDb")/20)
+	}
+	if volume < 0 || volume > 1 {
+		return nil, fmt.Errorf("invalid volume %g", volume)
+	}
+	in.Volume = volume
+	c.emit("SourceVolumeChanged", map[string]interface{}{
+		"sourceName": in.Name,
+		"volume":     volume,
+		"volumeDb":   decibels(volume),
+	}, "InputVolumeChanged", map[string]interface{}{
+		"inputName":      in.Name,
+		"inputVolumeMul": volume,
+		"inputVolumeDb":  decibels(volume),
+	})
+	return nil, nil
+}
+
+func getTransitionList(st *state, c *call) (map[string]interface{}, error) {
+	transitions := make([]map[string]interface{}, 0, len(st.transitions))
+	for _, t := range st.transitions {
+		if c.v5 == false {
+			transitions = append(transitions, map[string]interface{}{"name": t.Name})
+			continue
+		}
+		transitions = append(transitions, map[string]interface{}{
+			"transitionName":  t.Name,
+			"transitionFixed": t.Fixed,
+		})
+	}
+	return c.reply(
+		map[string]interface{}{"current-transition": st.currentTransition, "transitions": transitions},
+		map[string]interface{}{"currentSceneTransitionName": st.currentTransition, "transitions": transitions},
+	), nil
+}
+
+func getCurrentTransition(st *state, c *call) (map[string]interface{}, error) {
+	t := st.transition(st.currentTransition)
+	if t == nil {
+		return nil, errTransitionNotFound
+	}
+	duration := int(st.transitionDuration / time.Millisecond)
+	if c.v5 == true {
+		resp := map[string]interface{}{
+			"transitionName":     t.Name,
+			"transitionFixed":    t.Fixed,
+			"transitionDuration": nil,
+		}
+		if t.Fixed == false {
+			resp["transitionDuration"] = duration
+		}
+		return resp, nil
+	}
+	resp := map[string]interface{}{"name": t.Name}
+	if t.Fixed == false {
+		resp["duration"] = duration
+	}
+	return resp, nil
+}
+
+func setCurrentTransition(st *state, c *call) (map[string]interface{}, error) {
+	t := st.transition(c.string("transition-name", "transitionName"))
+	if t == nil {
+		return nil, errTransitionNotFound
+	}
+	st.currentTransition = t.Name
+	c.emit("SwitchTransition", map[string]interface{}{"transition-name": t.Name},
+		"CurrentSceneTransitionChanged", map[string]interface{}{"transitionName": t.Name})
+	return nil, nil
+}
+
+func getTransitionDuration(st *state, c *call) (map[string]interface{}, error) {
+	return map[string]interface{}{"transition-duration": int(st.transitionDuration / time.Millisecond)}, nil
+}
+
+func setTransitionDuration(st *state, c *call) (map[string]interface{}, error) {
+	duration := int(c.number("duration", "transitionDuration"))
+	if duration < 0 {
+		return nil, fmt.Errorf("invalid transition duration %d", duration)
+	}
+	old := int(st.transitionDuration / time.Millisecond)
+	st.transitionDuration = time.Duration(duration) * time.Millisecond
+	c.emit("TransitionDurationChanged", map[string]interface{}{"old-duration": old, "new-duration": duration},
+		"CurrentSceneTransitionDurationChanged", map[string]interface{}{"transitionDuration": duration})
+	return nil, nil
+}
+
+func listProfiles(st *state, c *call) (map[string]interface{}, error) {
+	if c.v5 == true {
+		return map[string]interface{}{
+			"currentProfileName": st.currentProfile,
+			"profiles":           st.profiles,
+		}, nil
+	}
+	profiles := make([]map[string]interface{}, 0, len(st.profiles))
+	for _, name := range st.profiles {
+		profiles = append(profiles, map[string]interface{}{"profile-name": name})
+	}
+	return map[string]interface{}{"profiles": profiles}, nil
+}
+
+func getCurrentProfile(st *state, c *call) (map[string]interface{}, error) {
+	return map[string]interface{}{"profile-name": st.currentProfile}, nil
+}
+
+func setCurrentProfile(st *state, c *call) (map[string]interface{}, error) {
+	name := c.string("profile-name", "profileName")
+	for _, profile := range st.profiles {
+		if profile == name {
+			st.currentProfile = name
+			c.emit("ProfileChanged", map[string]interface{}{"profile": name},
+				"CurrentProfileChanged", map[string]interface{}{"profileName": name})
+			return nil, nil
+		}
+	}
+	return nil, errors.New("profile does not exist")
+}
+
+func listSceneCollections(st *state, c *call) (map[string]interface{}, error) {
+	if c.v5 == true {
+		return map[string]interface{}{
+			"currentSceneCollectionName": st.currentCollection,
+			"sceneCollections":           st.sceneCollections,
+		}, nil
+	}
+	collections := make([]map[string]interface{}, 0, len(st.sceneCollections))
+	for _, name := range st.sceneCollections {
+		collections = append(collections, map[string]interface{}{"sc-name": name})
+	}
+	return map[string]interface{}{"scene-collections": collections}, nil
+}
+
+func getCurrentSceneCollection(st *state, c *call) (map[string]interface{}, error) {
+	return map[string]interface{}{"sc-name": st.currentCollection}, nil
+}
+
+// setCurrentSceneCollection switches the scene collection, the scenes
+// of the server are left as is.
+func setCurrentSceneCollection(st *state, c *call) (map[string]interface{}, error) {
+	name := c.string("sc-name", "sceneCollectionName")
+	for _, collection := range st.sceneCollections {
+		if collection == name {
+			st.currentCollection = name
+			c.emit("SceneCollectionChanged", map[string]interface{}{"sceneCollection": name},
+				"CurrentSceneCollectionChanged", map[string]interface{}{"sceneCollectionName": name})
+			return nil, nil
+		}
+	}
+	return nil, errors.New("scene collection does not exist")
+}
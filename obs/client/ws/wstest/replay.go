@@ -0,0 +1,97 @@
+package wstest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// captureRecord is a line of a capture file written by a ws.Client
+// created with ws.WithCapture.
+type captureRecord struct {
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"`
+	Protocol  string          `json:"protocol"`
+	Frame     json.RawMessage `json:"frame"`
+}
+
+var captureProtocols = map[Protocol]string{
+	ProtocolV4: "4.x",
+	ProtocolV5: "5.x",
+}
+
+// isEvent tells if the frame of the protocol is an event.
+func isEvent(p Protocol, frame []byte) bool {
+	var msg struct {
+		UpdateType string `json:"update-type"`
+		Op         *int   `json:"op"`
+	}
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		return false
+	}
+	if p == ProtocolV5 {
+		return msg.Op != nil && *msg.Op == opEvent
+	}
+	return len(msg.UpdateType) > 0
+}
+
+// Replay reads a capture file written by a ws.Client created with
+// ws.WithCapture, and sends the events received by that client to
+// every client of the server, as they were captured. The delays
+// between the events are divided by speed, a speed of 0 sends them at
+// once. Only the events captured with the protocol of the server are
+// replayed, the responses are not: the requests are still answered
+// from the state of the server, which the replayed events do not
+// change.
+//
+// Replay returns once every event was sent, or with the error of ctx
+// if it is done first.
+func (s *Server) Replay(ctx context.Context, r io.Reader, speed float64) error {
+	var frames []json.RawMessage
+	var times []time.Time
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record captureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("wstest: invalid capture record at line %d: %s", line, err)
+		}
+		if record.Direction != "received" || record.Protocol != captureProtocols[s.protocol] {
+			continue
+		}
+		if isEvent(s.protocol, record.Frame) == false {
+			continue
+		}
+		frames = append(frames, record.Frame)
+		times = append(times, record.Time)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for i, frame := range frames {
+		if i > 0 && speed > 0 {
+			delay := time.Duration(float64(times[i].Sub(times[i-1])) / speed)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.lock.Lock()
+		conns := s.connections()
+		s.lock.Unlock()
+		for _, c := range conns {
+			c.send(frame)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,640 @@
+// Package wstest provides an in-process fake obs-websocket server to
+// test the clients of OBS without a running instance.
+//
+// A Server speaks either the 4.x or the 5.x protocol. It holds a small
+// OBS state (scenes and their items, inputs with their settings,
+// filters and media playback, outputs and the replay buffer, studio
+// mode, transitions, profiles and scene collections), answers the
+// requests of ws.Client from it, screenshots included, and
+// emits the events of the changes like OBS does. Failures, delays and
+// disconnections can be injected per request type, and the events of
+// the sessions captured with ws.WithCapture can be replayed with
+// Replay.
+package wstest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Protocol is the obs-websocket protocol version spoken by a Server.
+type Protocol int
+
+const (
+	// ProtocolV4 is the legacy 4.x JSON protocol.
+	ProtocolV4 Protocol = iota
+	// ProtocolV5 is the op-code based protocol shipped with OBS 28+.
+	ProtocolV5
+)
+
+const (
+	opHello                = 0
+	opIdentify             = 1
+	opIdentified           = 2
+	opEvent                = 5
+	opRequest              = 6
+	opRequestResponse      = 7
+	opRequestBatch         = 8
+	opRequestBatchResponse = 9
+)
+
+// An Option customizes a Server when it is created with NewServer.
+type Option func(s *Server)
+
+// WithProtocol sets the protocol spoken by the server, 4.x by
+// default.
+func WithProtocol(p Protocol) Option {
+	return func(s *Server) {
+		s.protocol = p
+	}
+}
+
+// WithGreeting makes the server send the frames as is to each client
+// as soon as it connects, like the StreamStatus events a streaming
+// 4.x OBS pushes to every client, authenticated or not.
+func WithGreeting(frames ...string) Option {
+	return func(s *Server) {
+		s.greeting = append(s.greeting, frames...)
+	}
+}
+
+// WithPassword makes the server require authentication with the
+// password.
+func WithPassword(password string) Option {
+	return func(s *Server) {
+		s.password = password
+	}
+}
+
+// Request is a request received by a Server.
+type Request struct {
+	// Type is the request type, in the protocol of the server
+	Type string
+	// Fields are the parameters of the request: the fields of the
+	// 4.x message but request-type and message-id, or the 5.x
+	// requestData. A 5.x RequestBatch has the fields of the message
+	// but requestId.
+	Fields map[string]interface{}
+}
+
+// A Handler answers a request with its response fields, or fails
+// with an error. The fields follow the protocol of the server.
+type Handler func(fields map[string]interface{}) (map[string]interface{}, error)
+
+// Server is a fake obs-websocket server. Its methods are safe for
+// concurrent use.
+type Server struct {
+	protocol  Protocol
+	password  string
+	salt      string
+	challenge string
+	greeting  []string
+
+	http *httptest.Server
+
+	// guards everything below and the OBS state
+	lock     sync.Mutex
+	conns    map[*conn]bool
+	handlers map[string]Handler
+	failures map[string][]string
+	delays   map[string][]time.Duration
+	dropNext int
+	requests []Request
+	// subscriptions holds the eventSubscriptions of the 5.x Identify
+	// messages
+	subscriptions []uint32
+	state
+}
+
+// conn is a client connection. Its lock serializes the writes.
+type conn struct {
+	ws   *websocket.Conn
+	lock sync.Mutex
+}
+
+func (c *conn) send(v interface{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return websocket.JSON.Send(c.ws, v)
+}
+
+func (c *conn) sendFrame(frame string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return websocket.Message.Send(c.ws, frame)
+}
+
+// NewServer starts a fake obs-websocket server with the default
+// state, see NewState.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		salt:      "PZVbYpvAnZut2SS6JNJytDm9",
+		challenge: "ztTBnnuqrqaKDzRM3xcVdbYm",
+		conns:     make(map[*conn]bool),
+		handlers:  make(map[string]Handler),
+		failures:  make(map[string][]string),
+		delays:    make(map[string][]time.Duration),
+		state:     newState(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.http = httptest.NewServer(websocket.Handler(s.serve))
+	return s
+}
+
+// Address returns the host and port to give to ws.NewClient.
+func (s *Server) Address() (string, int) {
+	host, port, err := net.SplitHostPort(s.http.Listener.Addr().String())
+	if err != nil {
+		panic(err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		panic(err)
+	}
+	return host, p
+}
+
+// Close shuts the server down and closes the connections.
+func (s *Server) Close() {
+	s.DropConnections()
+	s.http.Close()
+}
+
+// Protocol returns the protocol spoken by the server.
+func (s *Server) Protocol() Protocol {
+	return s.protocol
+}
+
+// Handle makes h answer the requests of the type, instead of the
+// built-in handler if any. The type is in the protocol of the server.
+func (s *Server) Handle(requestType string, h Handler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers[requestType] = h
+}
+
+// FailNext makes the next request of the type fail with the message.
+func (s *Server) FailNext(requestType, message string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failures[requestType] = append(s.failures[requestType], message)
+}
+
+// DelayNext delays the answer to the next request of the type.
+func (s *Server) DelayNext(requestType string, delay time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.delays[requestType] = append(s.delays[requestType], delay)
+}
+
+// DropNext closes the connection on the next request, instead of
+// answering it.
+func (s *Server) DropNext() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dropNext++
+}
+
+// DropConnections closes the connections of the clients.
+func (s *Server) DropConnections() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for c := range s.conns {
+		c.ws.Close()
+		delete(s.conns, c)
+	}
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// EventSubscriptions returns the event subscriptions of the 5.x
+// Identify messages received so far, one for each connection.
+func (s *Server) EventSubscriptions() []uint32 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]uint32(nil), s.subscriptions...)
+}
+
+// SendFrame sends the frame as is to every client, to test malformed
+// or unexpected messages.
+func (s *Server) SendFrame(frame string) {
+	s.lock.Lock()
+	conns := s.connections()
+	s.lock.Unlock()
+	for _, c := range conns {
+		c.sendFrame(frame)
+	}
+}
+
+// Emit sends an event to every client, with the fields of the
+// protocol of the server.
+func (s *Server) Emit(eventType string, fields map[string]interface{}) {
+	s.lock.Lock()
+	conns := s.connections()
+	s.lock.Unlock()
+	ev := s.forgeEvent(event{v4Type: eventType, v4: fields, v5Type: eventType, v5: fields})
+	for _, c := range conns {
+		c.send(ev)
+	}
+}
+
+// connections returns the open connections. s.lock must be held.
+func (s *Server) connections() []*conn {
+	res := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		res = append(res, c)
+	}
+	return res
+}
+
+func (s *Server) serve(ws *websocket.Conn) {
+	c := &conn{ws: ws}
+	s.lock.Lock()
+	s.conns[c] = true
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, c)
+		s.lock.Unlock()
+		ws.Close()
+	}()
+
+	for _, frame := range s.greeting {
+		if err := c.sendFrame(frame); err != nil {
+			return
+		}
+	}
+	if s.protocol == ProtocolV5 {
+		s.serveV5(c)
+		return
+	}
+	s.serveV4(c)
+}
+
+func (s *Server) serveV4(c *conn) {
+	authenticated := len(s.password) == 0
+	for {
+		var fields map[string]interface{}
+		if err := websocket.JSON.Receive(c.ws, &fields); err != nil {
+			return
+		}
+		requestType, _ := fields["request-type"].(string)
+		messageID := fields["message-id"]
+		delete(fields, "request-type")
+		delete(fields, "message-id")
+
+		var resp map[string]interface{}
+		var events []event
+		switch {
+		case requestType == "GetAuthRequired":
+			resp = map[string]interface{}{"authRequired": len(s.password) > 0}
+			if len(s.password) > 0 {
+				resp["challenge"] = s.challenge
+				resp["salt"] = s.salt
+			}
+		case requestType == "Authenticate":
+			if fields["auth"] != s.expectedAuth() {
+				resp = errorV4("Authentication Failed.")
+				break
+			}
+			authenticated = true
+			resp = map[string]interface{}{}
+		case authenticated == false:
+			resp = errorV4("Not Authenticated")
+		default:
+			var ok bool
+			if resp, events, ok = s.handleV4(requestType, fields); ok == false {
+				return
+			}
+		}
+		if _, ok := resp["status"]; ok == false {
+			resp["status"] = "ok"
+		}
+		resp["message-id"] = messageID
+		if err := c.send(resp); err != nil {
+			return
+		}
+		s.sendEvents(events)
+	}
+}
+
+// handleV4 answers a 4.x request, and returns false if the connection
+// must be dropped.
+func (s *Server) handleV4(requestType string, fields map[string]interface{}) (map[string]interface{}, []event, bool) {
+	if requestType == "ExecuteBatch" {
+		if s.intercept(requestType, fields) == false {
+			return nil, nil, false
+		}
+		abort, _ := fields["abortOnFail"].(bool)
+		requests, _ := fields["requests"].([]interface{})
+		results := []map[string]interface{}{}
+		var events []event
+		for _, r := range requests {
+			// copied to keep the recorded batch whole
+			fields := map[string]interface{}{}
+			raw, _ := r.(map[string]interface{})
+			for k, v := range raw {
+				fields[k] = v
+			}
+			requestType, _ := fields["request-type"].(string)
+			messageID := fields["message-id"]
+			delete(fields, "request-type")
+			delete(fields, "message-id")
+			result, evs, err := s.handle(requestType, fields)
+			if err != nil {
+				result = errorV4(err.Error())
+			} else {
+				result["status"] = "ok"
+			}
+			result["message-id"] = messageID
+			results = append(results, result)
+			events = append(events, evs...)
+			if err != nil && abort == true {
+				break
+			}
+		}
+		return map[string]interface{}{"results": results}, events, true
+	}
+
+	if s.intercept(requestType, fields) == false {
+		return nil, nil, false
+	}
+	resp, events, err := s.handle(requestType, fields)
+	if err != nil {
+		return errorV4(err.Error()), nil, true
+	}
+	return resp, events, true
+}
+
+func errorV4(message string) map[string]interface{} {
+	return map[string]interface{}{"status": "error", "error": message}
+}
+
+func (s *Server) expectedAuth() string {
+	secret := sha256.Sum256([]byte(s.password + s.salt))
+	secretB64 := base64.StdEncoding.EncodeToString(secret[:])
+	auth := sha256.Sum256([]byte(secretB64 + s.challenge))
+	return base64.StdEncoding.EncodeToString(auth[:])
+}
+
+type messageV5 struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+type requestV5 struct {
+	RequestType string                 `json:"requestType"`
+	RequestID   string                 `json:"requestId"`
+	RequestData map[string]interface{} `json:"requestData"`
+}
+
+func (s *Server) serveV5(c *conn) {
+	hello := map[string]interface{}{
+		"obsWebSocketVersion": "5.1.0",
+		"rpcVersion":          1,
+	}
+	if len(s.password) > 0 {
+		hello["authentication"] = map[string]string{
+			"challenge": s.challenge,
+			"salt":      s.salt,
+		}
+	}
+	if err := c.send(map[string]interface{}{"op": opHello, "d": hello}); err != nil {
+		return
+	}
+
+	var msg messageV5
+	if err := websocket.JSON.Receive(c.ws, &msg); err != nil || msg.Op != opIdentify {
+		return
+	}
+	var identify struct {
+		Authentication     string `json:"authentication"`
+		EventSubscriptions uint32 `json:"eventSubscriptions"`
+	}
+	if err := json.Unmarshal(msg.D, &identify); err != nil {
+		return
+	}
+	if len(s.password) > 0 && identify.Authentication != s.expectedAuth() {
+		// OBS closes with code 4009, the client only sees the close
+		return
+	}
+	s.lock.Lock()
+	s.subscriptions = append(s.subscriptions, identify.EventSubscriptions)
+	s.lock.Unlock()
+	if err := c.send(map[string]interface{}{"op": opIdentified, "d": map[string]interface{}{"negotiatedRpcVersion": 1}}); err != nil {
+		return
+	}
+
+	for {
+		if err := websocket.JSON.Receive(c.ws, &msg); err != nil {
+			return
+		}
+		var resp interface{}
+		var events []event
+		switch msg.Op {
+		case opRequest:
+			var req requestV5
+			if err := json.Unmarshal(msg.D, &req); err != nil {
+				return
+			}
+			if s.intercept(req.RequestType, req.RequestData) == false {
+				return
+			}
+			result, evs := s.handleV5(req)
+			resp = map[string]interface{}{"op": opRequestResponse, "d": result}
+			events = evs
+		case opRequestBatch:
+			var batch struct {
+				RequestID     string      `json:"requestId"`
+				HaltOnFailure bool        `json:"haltOnFailure"`
+				Requests      []requestV5 `json:"requests"`
+			}
+			var fields map[string]interface{}
+			if err := json.Unmarshal(msg.D, &batch); err != nil {
+				return
+			}
+			if err := json.Unmarshal(msg.D, &fields); err != nil {
+				return
+			}
+			delete(fields, "requestId")
+			if s.intercept("RequestBatch", fields) == false {
+				return
+			}
+			results := []map[string]interface{}{}
+			for _, req := range batch.Requests {
+				result, evs := s.handleV5(req)
+				results = append(results, result)
+				events = append(events, evs...)
+				status := result["requestStatus"].(map[string]interface{})
+				if status["result"] == false && batch.HaltOnFailure == true {
+					break
+				}
+			}
+			resp = map[string]interface{}{
+				"op": opRequestBatchResponse,
+				"d":  map[string]interface{}{"requestId": batch.RequestID, "results": results},
+			}
+		default:
+			continue
+		}
+		if err := c.send(resp); err != nil {
+			return
+		}
+		s.sendEvents(events)
+	}
+}
+
+// handleV5 answers a 5.x request.
+func (s *Server) handleV5(req requestV5) (map[string]interface{}, []event) {
+	status := map[string]interface{}{"result": true, "code": 100}
+	resp := map[string]interface{}{
+		"requestType":   req.RequestType,
+		"requestId":     req.RequestID,
+		"requestStatus": status,
+	}
+	data, events, err := s.handle(req.RequestType, req.RequestData)
+	if err != nil {
+		status["result"] = false
+		status["code"] = 600
+		if e, ok := err.(errUnknownRequest); ok == true {
+			status["code"] = 204
+			err = e
+		}
+		status["comment"] = err.Error()
+		return resp, nil
+	}
+	if len(data) > 0 {
+		resp["responseData"] = data
+	}
+	return resp, events
+}
+
+// intercept records the request and applies the injected delay, and
+// returns false if the connection must be dropped.
+func (s *Server) intercept(requestType string, fields map[string]interface{}) bool {
+	s.lock.Lock()
+	s.requests = append(s.requests, Request{Type: requestType, Fields: fields})
+	if s.dropNext > 0 {
+		s.dropNext--
+		s.lock.Unlock()
+		return false
+	}
+	var delay time.Duration
+	if delays := s.delays[requestType]; len(delays) > 0 {
+		delay = delays[0]
+		s.delays[requestType] = delays[1:]
+	}
+	s.lock.Unlock()
+	time.Sleep(delay)
+	return true
+}
+
+type errUnknownRequest struct {
+	requestType string
+}
+
+func (e errUnknownRequest) Error() string {
+	return fmt.Sprintf("invalid request type '%s'", e.requestType)
+}
+
+// handle answers a request with the injected failure, the custom
+// handler or the built-in one, in this order.
+func (s *Server) handle(requestType string, fields map[string]interface{}) (map[string]interface{}, []event, error) {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	s.lock.Lock()
+	if failures := s.failures[requestType]; len(failures) > 0 {
+		s.failures[requestType] = failures[1:]
+		s.lock.Unlock()
+		return nil, nil, fmt.Errorf("%s", failures[0])
+	}
+	h, ok := s.handlers[requestType]
+	s.lock.Unlock()
+	if ok == true {
+		resp, err := h(fields)
+		if resp == nil {
+			resp = map[string]interface{}{}
+		}
+		return resp, nil, err
+	}
+
+	b, ok := builtins[s.protocol][requestType]
+	if ok == false {
+		return nil, nil, errUnknownRequest{requestType}
+	}
+	if b.sleep == true {
+		// outside of the lock, the other connections go on
+		millis, _ := fields["sleepMillis"].(float64)
+		time.Sleep(time.Duration(millis) * time.Millisecond)
+		return map[string]interface{}{}, nil, nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	call := &call{v5: s.protocol == ProtocolV5, fields: fields}
+	resp, err := b.handle(&s.state, call)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp == nil {
+		resp = map[string]interface{}{}
+	}
+	return resp, call.events, nil
+}
+
+// event is an event to emit, in both protocols.
+type event struct {
+	v4Type string
+	v4     map[string]interface{}
+	v5Type string
+	v5     map[string]interface{}
+}
+
+func (s *Server) forgeEvent(ev event) interface{} {
+	if s.protocol == ProtocolV5 {
+		return map[string]interface{}{
+			"op": opEvent,
+			"d": map[string]interface{}{
+				"eventType":   ev.v5Type,
+				"eventIntent": 0,
+				"eventData":   ev.v5,
+			},
+		}
+	}
+	msg := map[string]interface{}{"update-type": ev.v4Type}
+	for k, v := range ev.v4 {
+		msg[k] = v
+	}
+	return msg
+}
+
+func (s *Server) sendEvents(events []event) {
+	if len(events) == 0 {
+		return
+	}
+	s.lock.Lock()
+	conns := s.connections()
+	s.lock.Unlock()
+	for _, ev := range events {
+		msg := s.forgeEvent(ev)
+		for _, c := range conns {
+			c.send(msg)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package wstest_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/i-root-you/twitch-client/obs/client/ws"
+	"github.com/i-root-you/twitch-client/obs/client/ws/wstest"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type ServerSuite struct{}
+
+var _ = Suite(&ServerSuite{})
+
+var protocols = map[wstest.Protocol]ws.Protocol{
+	wstest.ProtocolV4: ws.ProtocolV4,
+	wstest.ProtocolV5: ws.ProtocolV5,
+}
+
+func newClient(c *C, s *wstest.Server, opts ...ws.Option) *ws.Client {
+	host, port := s.Address()
+	opts = append(opts, ws.WithProtocol(protocols[s.Protocol()]))
+	client, err := ws.NewClient(host, port, opts...)
+	c.Assert(err, IsNil)
+	return client
+}
+
+func nextEvent(c *C, events <-chan ws.Event) ws.Event {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(time.Second):
+		c.Fatal("did not receive event")
+	}
+	return nil
+}
+
+func (s *ServerSuite) TestScenes(c *C) {
+	for p := range protocols {
+		server := wstest.NewServer(wstest.WithProtocol(p), wstest.WithPassword("secret"))
+		client := newClient(c, server, ws.WithPassword("secret"))
+		events, _ := client.Subscribe("SwitchScenes", "SourceMuteStateChanged")
+
+		scenes, err := client.GetSceneList()
+		c.Assert(err, IsNil)
+		c.Check(scenes.CurrentScene, Equals, "Live")
+		c.Check(scenes.Scenes, HasLen, 2)
+		c.Check(scenes.Scenes[1].Name, Equals, "BRB")
+
+		c.Check(client.SetCurrentScene("BRB"), IsNil)
+		ev := nextEvent(c, events).(*ws.EventSwitchScenes)
+		c.Check(ev.SceneName, Equals, "BRB")
+		c.Check(server.CurrentScene(), Equals, "BRB")
+		c.Check(client.SetCurrentScene("Nope"), NotNil)
+
+		c.Check(client.SetMute("Mic", true), IsNil)
+		mute := nextEvent(c, events).(*ws.EventSourceMuteStateChanged)
+		c.Check(mute.SourceName, Equals, "Mic")
+		c.Check(mute.Muted, Equals, true)
+		in, _ := server.Input("Mic")
+		c.Check(in.Muted, Equals, true)
+
+		c.Check(server.Requests()[0].Type, Equals, "GetSceneList")
+		client.Close()
+		server.Close()
+	}
+}
+
+func (s *ServerSuite) TestStudioMode(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newClient(c, server)
+	defer client.Close()
+
+	c.Check(client.SetPreviewScene("BRB"), ErrorMatches, ".*studio mode not enabled")
+	c.Check(client.EnableStudioMode(), IsNil)
+	c.Check(client.SetPreviewScene("BRB"), IsNil)
+	c.Check(client.TransitionToProgram(nil), IsNil)
+	c.Check(server.CurrentScene(), Equals, "BRB")
+	c.Check(server.PreviewScene(), Equals, "Live")
+}
+
+func (s *ServerSuite) TestInjection(c *C) {
+	server := wstest.NewServer(wstest.WithProtocol(wstest.ProtocolV5))
+	defer server.Close()
+	client := newClient(c, server)
+	defer client.Close()
+
+	server.FailNext("StartStream", "no stream service")
+	c.Check(client.StartStreaming(), ErrorMatches, ".*no stream service.*")
+	c.Check(client.StartStreaming(), IsNil)
+	c.Check(server.Streaming(), Equals, true)
+
+	server.DelayNext("GetSceneList", 100*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.GetSceneListCtx(ctx)
+	c.Check(err, Equals, context.DeadlineExceeded)
+
+	server.Handle("GetCurrentProgramScene", func(fields map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"currentProgramSceneName": "BRB"}, nil
+	})
+	scene, err := client.GetCurrentScene()
+	c.Assert(err, IsNil)
+	c.Check(scene.Name, Equals, "BRB")
+
+	events, _ := client.Subscribe("Heartbeat")
+	server.Emit("ExitStarted", nil)
+	server.Emit("Heartbeat", map[string]interface{}{"pulse": true})
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Heartbeat")
+}
+
+func (s *ServerSuite) TestDrop(c *C) {
+	server := wstest.NewServer()
+	defer server.Close()
+	client := newClient(c, server, ws.WithReconnect(ws.Backoff{Initial: 10 * time.Millisecond}))
+	defer client.Close()
+	events, _ := client.Subscribe("Disconnected", "Connected")
+
+	server.DropNext()
+	_, err := client.GetSceneList()
+	c.Check(err, FitsTypeOf, ws.ErrDisconnected{})
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Disconnected")
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Connected")
+	_, err = client.GetSceneList()
+	c.Check(err, IsNil)
+
+	server.DropConnections()
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Disconnected")
+	c.Check(nextEvent(c, events).UpdateType(), Equals, "Connected")
+}
+
+func (s *ServerSuite) TestBatch(c *C) {
+	for p := range protocols {
+		server := wstest.NewServer(wstest.WithProtocol(p))
+		client := newClient(c, server)
+
+		b := &ws.Batch{HaltOnFailure: true}
+		b.SetMute("Desktop Audio", true)
+		b.Sleep(10 * time.Millisecond)
+		b.SetCurrentTransition("Cut")
+		b.SetCurrentScene("Nope")
+		b.SetCurrentScene("BRB")
+		results, err := client.ExecuteBatch(b)
+		c.Check(err, FitsTypeOf, ws.ErrBatch{})
+		c.Check(results[2].Err, IsNil)
+		c.Check(results[3].Err, NotNil)
+		c.Check(results[4].Err, Equals, ws.ErrBatchSkipped{})
+		in, _ := server.Input("Desktop Audio")
+		c.Check(in.Muted, Equals, true)
+		transition, _ := server.CurrentTransition()
+		c.Check(transition, Equals, "Cut")
+		c.Check(server.CurrentScene(), Equals, "Live")
+
+		client.Close()
+		server.Close()
+	}
+}
+
+func (s *ServerSuite) TestCustomMessages(c *C) {
+	type alert struct {
+		User string `json:"user"`
+	}
+	for p := range protocols {
+		server := wstest.NewServer(wstest.WithProtocol(p))
+		bot := newClient(c, server)
+		overlay := newClient(c, server)
+		events, _ := overlay.Subscribe("BroadcastCustomMessage")
+
+		c.Check(bot.SendCustomMessage("overlay", "follow", alert{User: "someone"}), IsNil)
+		ev := nextEvent(c, events).(*ws.EventBroadcastCustomMessage)
+		c.Check(ev.Realm, Equals, "overlay")
+		m, err := ev.Message()
+		c.Assert(err, IsNil)
+		var decoded alert
+		c.Check(m.Decode(&decoded), IsNil)
+		c.Check(decoded.User, Equals, "someone")
+
+		c.Check(bot.TriggerHotkeyByName("OBSBasic.Screenshot"), IsNil)
+
+		bot.Close()
+		overlay.Close()
+		server.Close()
+	}
+}
+
+func (s *ServerSuite) TestReplay(c *C) {
+	for p := range protocols {
+		incident := wstest.NewServer(wstest.WithProtocol(p))
+		var capture bytes.Buffer
+		client := newClient(c, incident, ws.WithCapture(&capture))
+		events, _ := client.Subscribe("SwitchScenes")
+		c.Check(client.SetCurrentScene("BRB"), IsNil)
+		nextEvent(c, events)
+		c.Check(client.SetCurrentScene("Live"), IsNil)
+		nextEvent(c, events)
+		client.Close()
+		incident.Close()
+
+		server := wstest.NewServer(wstest.WithProtocol(p))
+		client = newClient(c, server)
+		events, _ = client.Subscribe("SwitchScenes")
+		c.Check(server.Replay(context.Background(), &capture, 1), IsNil)
+		c.Check(nextEvent(c, events).(*ws.EventSwitchScenes).SceneName, Equals, "BRB")
+		c.Check(nextEvent(c, events).(*ws.EventSwitchScenes).SceneName, Equals, "Live")
+		// the state of the server is left as is
+		c.Check(server.CurrentScene(), Equals, "Live")
+
+		client.Close()
+		server.Close()
+	}
+}
+
+func (s *ServerSuite) TestSources(c *C) {
+	for p := range protocols {
+		server := wstest.NewServer(wstest.WithProtocol(p))
+		client := newClient(c, server)
+		events, _ := client.Subscribe("SourceFilterAdded", "SourceFilterVisibilityChanged")
+
+		c.Check(client.SetText("Game", "Paused"), IsNil)
+		props, err := client.GetSourceSettings("Game")
+		c.Assert(err, IsNil)
+		c.Check(props.SourceType, Equals, "text_ft2_source_v2")
+		c.Check(props.SourceSettings["text"], Equals, "Paused")
+		c.Check(client.SetText("Mic", "Nope"), FitsTypeOf, ws.ErrNotTextSource{})
+		outline := true
+		c.Check(client.SetTextFreetype2Properties("Game", ws.TextFreetype2PropertiesUpdate{Outline: &outline}), IsNil)
+		in, _ := server.Input("Game")
+		c.Check(in.Settings["text"], Equals, "Paused")
+		c.Check(in.Settings["outline"], Equals, true)
+
+		c.Check(client.AddFilterToSource("Mic", "Gain", "gain_filter", ws.FilterSettings{"db": 6}), IsNil)
+		added := nextEvent(c, events).(*ws.EventSourceFilterAdded)
+		c.Check(added.FilterType, Equals, "gain_filter")
+		c.Check(client.ReorderSourceFilter("Mic", "Gain", 0), IsNil)
+		c.Check(client.SetSourceFilterVisibility("Mic", "Noise Suppression", false), IsNil)
+		visibility := nextEvent(c, events).(*ws.EventSourceFilterVisibilityChanged)
+		c.Check(visibility.FilterEnabled, Equals, false)
+		filters, err := client.GetSourceFilters("Mic")
+		c.Assert(err, IsNil)
+		c.Assert(filters.Filters, HasLen, 2)
+		c.Check(filters.Filters[0].Name, Equals, "Gain")
+		c.Check(filters.Filters[1].Enabled, Equals, false)
+		info, err := client.GetSourceFilterInfo("Mic", "Gain")
+		c.Assert(err, IsNil)
+		c.Check(info.Name, Equals, "Gain")
+		c.Check(info.Settings["db"], Equals, 6.0)
+		c.Check(client.RemoveFilterFromSource("Mic", "Gain"), IsNil)
+		c.Check(client.RemoveFilterFromSource("Mic", "Gain"), ErrorMatches, ".*filter doesn't exist.*")
+
+		shot, err := client.TakeSourceScreenshot("Live", ws.ScreenshotOptions{Width: 32})
+		c.Assert(err, IsNil)
+		img, err := shot.Image()
+		c.Assert(err, IsNil)
+		c.Check(img.Bounds().Dx(), Equals, 32)
+		path := filepath.Join(c.MkDir(), "game.png")
+		_, err = client.TakeSourceScreenshot("Game", ws.ScreenshotOptions{SaveToFilePath: path})
+		c.Check(err, IsNil)
+		_, err = os.Stat(path)
+		c.Check(err, IsNil)
+		_, err = client.TakeSourceScreenshot("Nope", ws.ScreenshotOptions{})
+		c.Check(err, NotNil)
+
+		client.Close()
+		server.Close()
+	}
+}
+
+func (s *ServerSuite) TestMedia(c *C) {
+	for p := range protocols {
+		server := wstest.NewServer(wstest.WithProtocol(p))
+		client := newClient(c, server)
+		events, _ := client.Subscribe("MediaPlaying", "MediaStopped")
+
+		c.Check(client.PlayPauseMedia("Intro", false), IsNil)
+		c.Check(nextEvent(c, events).(*ws.EventMediaPlaying).SourceName, Equals, "Intro")
+		c.Check(client.SetMediaTime("Intro", 10000), IsNil)
+		c.Check(client.ScrubMedia("Intro", -2500), IsNil)
+		state, err := client.GetMediaState("Intro")
+		c.Assert(err, IsNil)
+		c.Check(state.MediaState, Equals, "playing")
+		timestamp, err := client.GetMediaTime("Intro")
+		c.Assert(err, IsNil)
+		c.Check(timestamp.Timestamp, Equals, 7500)
+		duration, err := client.GetMediaDuration("Intro")
+		c.Assert(err, IsNil)
+		c.Check(duration.MediaDuration, Equals, 30000)
+
+		c.Check(client.StopMedia("Intro"), IsNil)
+		c.Check(nextEvent(c, events).UpdateType(), Equals, "MediaStopped")
+		in, _ := server.Input("Intro")
+		c.Check(*in.Media, Equals, wstest.Media{State: "stopped", Duration: 30 * time.Second})
+		c.Check(client.RestartMedia("Mic"), ErrorMatches, ".*not a media source.*")
+
+		client.Close()
+		server.Close()
+	}
+}
+
+func (s *ServerSuite) TestOutputsAndProfiles(c *C) {
+	for p := range protocols {
+		server := wstest.NewServer(wstest.WithProtocol(p))
+		client := newClient(c, server)
+		events, _ := client.Subscribe("ReplayStarted", "ProfileChanged", "SceneCollectionChanged")
+
+		c.Check(client.SaveReplayBuffer(), ErrorMatches, ".*replay buffer not active.*")
+		c.Check(client.StartReplayBuffer(), IsNil)
+		c.Check(nextEvent(c, events).UpdateType(), Equals, "ReplayStarted")
+		status, err := client.GetReplayBufferStatus()
+		c.Assert(err, IsNil)
+		c.Check(status.IsReplayBufferActive, Equals, true)
+		if p == wstest.ProtocolV5 {
+			path, err := client.Clip()
+			c.Check(err, IsNil)
+			c.Check(path, Equals, "/tmp/replay.mkv")
+		} else {
+			c.Check(client.SaveReplayBuffer(), IsNil)
+		}
+		c.Check(client.StopReplayBuffer(), IsNil)
+		c.Check(server.ReplayBuffer(), Equals, false)
+
+		profiles, err := client.ListProfiles()
+		c.Assert(err, IsNil)
+		c.Check(profiles.Profiles, DeepEquals, []ws.Profile{{Name: "Untitled"}, {Name: "Streaming"}})
+		c.Check(client.SetCurrentProfile("Streaming"), IsNil)
+		c.Check(nextEvent(c, events).(*ws.EventProfileChanged).Profile, Equals, "Streaming")
+		profile, err := client.GetCurrentProfile()
+		c.Assert(err, IsNil)
+		c.Check(profile.ProfileName, Equals, "Streaming")
+		c.Check(client.SetCurrentProfile("Nope"), NotNil)
+
+		collections, err := client.ListSceneCollections()
+		c.Assert(err, IsNil)
+		c.Check(collections.SceneCollections, DeepEquals, []ws.SceneCollection{{Name: "Untitled"}})
+		c.Check(client.SetCurrentSceneCollection("Untitled"), IsNil)
+		c.Check(nextEvent(c, events).UpdateType(), Equals, "SceneCollectionChanged")
+		collection, err := client.GetCurrentSceneCollection()
+		c.Assert(err, IsNil)
+		c.Check(collection.SCName, Equals, "Untitled")
+		c.Check(server.CurrentProfile(), Equals, "Streaming")
+
+		client.Close()
+		server.Close()
+	}
+}
@@ -0,0 +1,533 @@
+package wstest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	errFilterNotFound = errors.New("specified filter doesn't exist on specified source")
+	errFilterExists   = errors.New("filter name already used on this source")
+	errNotMediaSource = errors.New("selected source is not a media source")
+)
+
+// inputNamed returns the input named name.
+func (st *state) inputNamed(name string) (*Input, error) {
+	in := st.input(name)
+	if in == nil {
+		return nil, errSourceNotFound
+	}
+	return in, nil
+}
+
+// overlay sets the fields of settings in the input settings.
+func overlay(in *Input, settings map[string]interface{}) {
+	if in.Settings == nil {
+		in.Settings = make(map[string]interface{}, len(settings))
+	}
+	for k, v := range settings {
+		in.Settings[k] = v
+	}
+}
+
+func getSourceSettings(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.inputNamed(c.string("sourceName", "inputName"))
+	if err != nil {
+		return nil, err
+	}
+	settings := copySettings(in.Settings)
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	return c.reply(
+		map[string]interface{}{"sourceName": in.Name, "sourceType": in.Kind, "sourceSettings": settings},
+		map[string]interface{}{"inputKind": in.Kind, "inputSettings": settings},
+	), nil
+}
+
+func setSourceSettings(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.inputNamed(c.string("sourceName", "inputName"))
+	if err != nil {
+		return nil, err
+	}
+	if kind := c.string("sourceType", ""); len(kind) > 0 && kind != in.Kind {
+		return nil, errors.New("specified source exists but is not of expected type")
+	}
+	settings, ok := c.field("sourceSettings", "inputSettings")
+	if ok == false {
+		return nil, errors.New("missing settings")
+	}
+	fields, _ := settings.(map[string]interface{})
+	if c.has("", "overlay") == true && c.bool("", "overlay") == false {
+		in.Settings = nil
+	}
+	overlay(in, fields)
+	return c.reply(map[string]interface{}{
+		"sourceName":     in.Name,
+		"sourceType":     in.Kind,
+		"sourceSettings": copySettings(in.Settings),
+	}, nil), nil
+}
+
+// propertiesInput returns the input of a 4.x Get*Properties or
+// Set*Properties request, which must be of the kind.
+func (st *state) propertiesInput(c *call, kind string) (*Input, error) {
+	in, err := st.inputNamed(c.string("source", ""))
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(in.Kind, kind) == false {
+		return nil, fmt.Errorf("not a %s source", kind)
+	}
+	return in, nil
+}
+
+// getProperties answers the 4.x Get*Properties requests of the inputs
+// of the kind.
+func getProperties(kind string) func(st *state, c *call) (map[string]interface{}, error) {
+	return func(st *state, c *call) (map[string]interface{}, error) {
+		in, err := st.propertiesInput(c, kind)
+		if err != nil {
+			return nil, err
+		}
+		resp := copySettings(in.Settings)
+		if resp == nil {
+			resp = map[string]interface{}{}
+		}
+		resp["source"] = in.Name
+		return resp, nil
+	}
+}
+
+// setProperties answers the 4.x Set*Properties requests of the inputs
+// of the kind, which only change the given properties.
+func setProperties(kind string) func(st *state, c *call) (map[string]interface{}, error) {
+	return func(st *state, c *call) (map[string]interface{}, error) {
+		in, err := st.propertiesInput(c, kind)
+		if err != nil {
+			return nil, err
+		}
+		settings := make(map[string]interface{}, len(c.fields))
+		for k, v := range c.fields {
+			if k != "source" {
+				settings[k] = v
+			}
+		}
+		overlay(in, settings)
+		return nil, nil
+	}
+}
+
+// filterSource returns the input of a filter request.
+func (st *state) filterSource(c *call) (*Input, error) {
+	return st.inputNamed(c.string("sourceName", "sourceName"))
+}
+
+// filter returns the filter of a filter request and its index.
+func (st *state) filter(c *call) (*Input, int, error) {
+	in, err := st.filterSource(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	name := c.string("filterName", "filterName")
+	for i := range in.Filters {
+		if in.Filters[i].Name == name {
+			return in, i, nil
+		}
+	}
+	return nil, 0, errFilterNotFound
+}
+
+// filterFields returns the fields of the filter in the protocol, with
+// its settings if withSettings is set.
+func filterFields(c *call, f Filter, index int, withSettings bool) map[string]interface{} {
+	settings := copySettings(f.Settings)
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	if c.v5 == true {
+		res := map[string]interface{}{
+			"filterName":    f.Name,
+			"filterKind":    f.Kind,
+			"filterIndex":   index,
+			"filterEnabled": f.Enabled,
+		}
+		if withSettings == true {
+			res["filterSettings"] = settings
+		}
+		return res
+	}
+	res := map[string]interface{}{"name": f.Name, "type": f.Kind, "enabled": f.Enabled}
+	if withSettings == true {
+		res["settings"] = settings
+	}
+	return res
+}
+
+func getSourceFilters(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.filterSource(c)
+	if err != nil {
+		return nil, err
+	}
+	filters := make([]map[string]interface{}, 0, len(in.Filters))
+	for i, f := range in.Filters {
+		filters = append(filters, filterFields(c, f, i, true))
+	}
+	return map[string]interface{}{"filters": filters}, nil
+}
+
+func getSourceFilterInfo(st *state, c *call) (map[string]interface{}, error) {
+	in, i, err := st.filter(c)
+	if err != nil {
+		return nil, err
+	}
+	resp := filterFields(c, in.Filters[i], i, true)
+	// the 5.x response does not repeat the name of the filter
+	delete(resp, "filterName")
+	return resp, nil
+}
+
+func addFilterToSource(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.filterSource(c)
+	if err != nil {
+		return nil, err
+	}
+	name := c.string("filterName", "filterName")
+	for _, f := range in.Filters {
+		if f.Name == name {
+			return nil, errFilterExists
+		}
+	}
+	kind := c.string("filterType", "filterKind")
+	if len(name) == 0 || len(kind) == 0 {
+		return nil, errors.New("missing filter name or kind")
+	}
+	settings, _ := c.fields["filterSettings"].(map[string]interface{})
+	f := Filter{Name: name, Kind: kind, Enabled: true, Settings: copySettings(settings)}
+	in.Filters = append(in.Filters, f)
+	c.emit("SourceFilterAdded", map[string]interface{}{
+		"sourceName":     in.Name,
+		"filterName":     f.Name,
+		"filterType":     f.Kind,
+		"filterSettings": copySettings(f.Settings),
+	}, "SourceFilterCreated", map[string]interface{}{
+		"sourceName":            in.Name,
+		"filterName":            f.Name,
+		"filterKind":            f.Kind,
+		"filterIndex":           len(in.Filters) - 1,
+		"filterSettings":        copySettings(f.Settings),
+		"defaultFilterSettings": map[string]interface{}{},
+	})
+	return nil, nil
+}
+
+func removeFilterFromSource(st *state, c *call) (map[string]interface{}, error) {
+	in, i, err := st.filter(c)
+	if err != nil {
+		return nil, err
+	}
+	f := in.Filters[i]
+	in.Filters = append(in.Filters[:i], in.Filters[i+1:]...)
+	c.emit("SourceFilterRemoved", map[string]interface{}{"sourceName": in.Name, "filterName": f.Name, "filterType": f.Kind},
+		"SourceFilterRemoved", map[string]interface{}{"sourceName": in.Name, "filterName": f.Name})
+	return nil, nil
+}
+
+func reorderSourceFilter(st *state, c *call) (map[string]interface{}, error) {
+	in, i, err := st.filter(c)
+	if err != nil {
+		return nil, err
+	}
+	index := int(c.number("newIndex", "filterIndex"))
+	if index < 0 || index >= len(in.Filters) {
+		return nil, fmt.Errorf("invalid filter index %d", index)
+	}
+	f := in.Filters[i]
+	in.Filters = append(in.Filters[:i], in.Filters[i+1:]...)
+	in.Filters = append(in.Filters[:index], append([]Filter{f}, in.Filters[index:]...)...)
+	filters := make([]map[string]interface{}, 0, len(in.Filters))
+	for i, f := range in.Filters {
+		filters = append(filters, filterFields(c, f, i, false))
+	}
+	c.emit("SourceFiltersReordered", map[string]interface{}{"sourceName": in.Name, "filters": filters},
+		"SourceFilterListReindexed", map[string]interface{}{"sourceName": in.Name, "filters": filters})
+	return nil, nil
+}
+
+func setSourceFilterSettings(st *state, c *call) (map[string]interface{}, error) {
+	in, i, err := st.filter(c)
+	if err != nil {
+		return nil, err
+	}
+	settings, ok := c.fields["filterSettings"].(map[string]interface{})
+	if ok == false {
+		return nil, errors.New("missing filterSettings")
+	}
+	f := &in.Filters[i]
+	if f.Settings == nil || (c.has("", "overlay") == true && c.bool("", "overlay") == false) {
+		f.Settings = make(map[string]interface{}, len(settings))
+	}
+	for k, v := range settings {
+		f.Settings[k] = v
+	}
+	return nil, nil
+}
+
+func setSourceFilterVisibility(st *state, c *call) (map[string]interface{}, error) {
+	in, i, err := st.filter(c)
+	if err != nil {
+		return nil, err
+	}
+	f := &in.Filters[i]
+	f.Enabled = c.bool("filterEnabled", "filterEnabled")
+	fields := map[string]interface{}{"sourceName": in.Name, "filterName": f.Name, "filterEnabled": f.Enabled}
+	c.emit("SourceFilterVisibilityChanged", fields, "SourceFilterEnableStateChanged", fields)
+	return nil, nil
+}
+
+// screenshotSize returns the size of a screenshot, 16:9 when a side
+// is not given.
+func screenshotSize(width, height int) (int, int) {
+	switch {
+	case width > 0 && height > 0:
+		return width, height
+	case width > 0:
+		return width, width*9/16 + 1
+	case height > 0:
+		return height*16/9 + 1, height
+	}
+	return 16, 9
+}
+
+// screenshot returns a gray PNG image of the size.
+func screenshot(width, height int) ([]byte, error) {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// takeSourceScreenshot answers TakeSourceScreenshot in 4.x, and
+// GetSourceScreenshot and SaveSourceScreenshot in 5.x, with a gray
+// image of the source, a scene or an input. Only PNG is supported,
+// the saved images are written to their path.
+func takeSourceScreenshot(st *state, c *call) (map[string]interface{}, error) {
+	name := c.string("sourceName", "sourceName")
+	if len(name) == 0 && c.v5 == false {
+		name = st.currentScene
+	}
+	if st.scene(name) == nil && st.input(name) == nil {
+		return nil, errSourceNotFound
+	}
+
+	path := c.string("saveToFilePath", "imageFilePath")
+	format := c.string("embedPictureFormat", "imageFormat")
+	if c.v5 == false && len(path) > 0 {
+		format = c.string("fileFormat", "")
+		if len(format) == 0 {
+			format = strings.TrimPrefix(filepath.Ext(path), ".")
+		}
+	}
+	if c.v5 == false && len(format) == 0 && len(path) == 0 {
+		return nil, errors.New("at least embedPictureFormat or saveToFilePath must be specified")
+	}
+	if len(format) > 0 && format != "png" {
+		return nil, fmt.Errorf("unsupported image format '%s'", format)
+	}
+
+	data, err := screenshot(screenshotSize(int(c.number("width", "imageWidth")), int(c.number("height", "imageHeight"))))
+	if err != nil {
+		return nil, err
+	}
+	uri := "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)
+	if len(path) > 0 {
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return nil, err
+		}
+	}
+	if c.v5 == true {
+		if len(path) > 0 {
+			return nil, nil
+		}
+		return map[string]interface{}{"imageData": uri}, nil
+	}
+	resp := map[string]interface{}{"sourceName": name}
+	if c.has("embedPictureFormat", "") == true {
+		resp["img"] = uri
+	}
+	if len(path) > 0 {
+		resp["imageFile"] = path
+	}
+	return resp, nil
+}
+
+// mediaActions are the 4.x events of the 5.x media actions.
+var mediaActions = map[string]string{
+	"PLAY":     "MediaPlaying",
+	"PAUSE":    "MediaPaused",
+	"RESTART":  "MediaRestarted",
+	"STOP":     "MediaStopped",
+	"NEXT":     "MediaNext",
+	"PREVIOUS": "MediaPrevious",
+}
+
+// mediaInput returns the media input of a media request.
+func (st *state) mediaInput(c *call) (*Input, error) {
+	in, err := st.inputNamed(c.string("sourceName", "inputName"))
+	if err != nil {
+		return nil, err
+	}
+	if in.Media == nil {
+		return nil, errNotMediaSource
+	}
+	return in, nil
+}
+
+// triggerMediaAction plays, pauses, restarts or stops the media of
+// the input. The next and previous actions leave the playback as is.
+func triggerMediaAction(st *state, c *call, action string) (map[string]interface{}, error) {
+	in, err := st.mediaInput(c)
+	if err != nil {
+		return nil, err
+	}
+	v4Type, ok := mediaActions[action]
+	if ok == false {
+		return nil, fmt.Errorf("invalid media action '%s'", action)
+	}
+	media := in.Media
+	switch action {
+	case "PLAY":
+		if media.State == "ended" {
+			media.Cursor = 0
+		}
+		media.State = "playing"
+	case "PAUSE":
+		media.State = "paused"
+	case "RESTART":
+		media.State, media.Cursor = "playing", 0
+	case "STOP":
+		media.State, media.Cursor = "stopped", 0
+	}
+	c.emit(v4Type, map[string]interface{}{"sourceName": in.Name, "sourceKind": in.Kind},
+		"MediaInputActionTriggered", map[string]interface{}{
+			"inputName":   in.Name,
+			"mediaAction": "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_" + action,
+		})
+	return nil, nil
+}
+
+func playPauseMedia(st *state, c *call) (map[string]interface{}, error) {
+	if c.bool("playPause", "") == true {
+		return triggerMediaAction(st, c, "PAUSE")
+	}
+	return triggerMediaAction(st, c, "PLAY")
+}
+
+// mediaAction answers the 4.x requests of the media action.
+func mediaAction(action string) func(st *state, c *call) (map[string]interface{}, error) {
+	return func(st *state, c *call) (map[string]interface{}, error) {
+		return triggerMediaAction(st, c, action)
+	}
+}
+
+func triggerMediaInputAction(st *state, c *call) (map[string]interface{}, error) {
+	return triggerMediaAction(st, c, strings.TrimPrefix(c.string("", "mediaAction"), "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_"))
+}
+
+func milliseconds(d time.Duration) int {
+	return int(d / time.Millisecond)
+}
+
+func getMediaInputStatus(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.mediaInput(c)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"mediaState":    "OBS_MEDIA_STATE_" + strings.ToUpper(in.Media.State),
+		"mediaDuration": milliseconds(in.Media.Duration),
+		"mediaCursor":   milliseconds(in.Media.Cursor),
+	}, nil
+}
+
+func getMediaDuration(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.mediaInput(c)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"mediaDuration": milliseconds(in.Media.Duration)}, nil
+}
+
+func getMediaTime(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.mediaInput(c)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"timestamp": milliseconds(in.Media.Cursor)}, nil
+}
+
+func getMediaState(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.mediaInput(c)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"mediaState": in.Media.State}, nil
+}
+
+// seek moves the cursor of the media, within its duration.
+func (m *Media) seek(cursor time.Duration) {
+	switch {
+	case cursor < 0:
+		cursor = 0
+	case cursor > m.Duration:
+		cursor = m.Duration
+	}
+	m.Cursor = cursor
+}
+
+func setMediaTime(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.mediaInput(c)
+	if err != nil {
+		return nil, err
+	}
+	in.Media.seek(time.Duration(c.number("timestamp", "mediaCursor")) * time.Millisecond)
+	return nil, nil
+}
+
+func scrubMedia(st *state, c *call) (map[string]interface{}, error) {
+	in, err := st.mediaInput(c)
+	if err != nil {
+		return nil, err
+	}
+	offset := time.Duration(c.number("timeOffset", "mediaCursorOffset")) * time.Millisecond
+	in.Media.seek(in.Media.Cursor + offset)
+	return nil, nil
+}
+
+func getMediaSourcesList(st *state, c *call) (map[string]interface{}, error) {
+	sources := []map[string]interface{}{}
+	for _, in := range st.inputs {
+		if in.Media == nil {
+			continue
+		}
+		sources = append(sources, map[string]interface{}{
+			"sourceName": in.Name,
+			"sourceKind": in.Kind,
+			"mediaState": in.Media.State,
+		})
+	}
+	return map[string]interface{}{"mediaSources": sources}, nil
+}
@@ -0,0 +1,298 @@
+package wstest
+
+import (
+	"time"
+)
+
+// Scene is a scene of the fake OBS, with its items from top to
+// bottom.
+type Scene struct {
+	Name  string
+	Items []SceneItem
+}
+
+// SceneItem is a source shown in a Scene.
+type SceneItem struct {
+	ID      int
+	Name    string
+	Visible bool
+}
+
+// Input is a source of the fake OBS.
+type Input struct {
+	Name  string
+	Kind  string
+	Muted bool
+	// Volume is a multiplier between 0 and 1
+	Volume   float64
+	Settings map[string]interface{}
+	Filters  []Filter
+	// Media is the playback of the media sources, nil for the other
+	// inputs
+	Media *Media
+}
+
+// Filter is a filter of an Input.
+type Filter struct {
+	Name     string
+	Kind     string
+	Enabled  bool
+	Settings map[string]interface{}
+}
+
+// Media is the playback of a media Input.
+type Media struct {
+	// State is the 4.x media state: "playing", "paused", "stopped",
+	// "ended"...
+	State    string
+	Cursor   time.Duration
+	Duration time.Duration
+}
+
+// Transition is a scene transition of the fake OBS.
+type Transition struct {
+	Name string
+	// Fixed transitions have no duration
+	Fixed bool
+}
+
+// state is the OBS state of a Server, guarded by its lock.
+type state struct {
+	scenes             []Scene
+	currentScene       string
+	previewScene       string
+	studioMode         bool
+	inputs             []Input
+	transitions        []Transition
+	currentTransition  string
+	transitionDuration time.Duration
+	streaming          bool
+	recording          bool
+	recordingPaused    bool
+	replayBuffer       bool
+	profiles           []string
+	currentProfile     string
+	sceneCollections   []string
+	currentCollection  string
+}
+
+// newState returns the default state: the scenes Live, with the Cam
+// and Game items, and BRB, with the BRB Screen item; the Mic input,
+// with a Noise Suppression filter, the Desktop Audio input, the Game
+// text input and the Intro media input; the Fade and Cut transitions;
+// the Untitled and Streaming profiles and the Untitled scene
+// collection.
+func newState() state {
+	return state{
+		scenes: []Scene{
+			{Name: "Live", Items: []SceneItem{
+				{ID: 1, Name: "Cam", Visible: true},
+				{ID: 2, Name: "Game", Visible: true},
+			}},
+			{Name: "BRB", Items: []SceneItem{
+				{ID: 3, Name: "BRB Screen", Visible: true},
+			}},
+		},
+		currentScene: "Live",
+		inputs: []Input{
+			{Name: "Mic", Kind: "pulse_input_capture", Volume: 1, Filters: []Filter{
+				{Name: "Noise Suppression", Kind: "noise_suppress_filter_v2", Enabled: true,
+					Settings: map[string]interface{}{"method": "rnnoise"}},
+			}},
+			{Name: "Desktop Audio", Kind: "pulse_output_capture", Volume: 1},
+			{Name: "Game", Kind: "text_ft2_source_v2", Volume: 1,
+				Settings: map[string]interface{}{"text": "Now playing"}},
+			{Name: "Intro", Kind: "ffmpeg_source", Volume: 1,
+				Settings: map[string]interface{}{"local_file": "/tmp/intro.mp4"},
+				Media:    &Media{State: "stopped", Duration: 30 * time.Second}},
+		},
+		transitions:        []Transition{{Name: "Fade"}, {Name: "Cut", Fixed: true}},
+		currentTransition:  "Fade",
+		transitionDuration: 300 * time.Millisecond,
+		profiles:           []string{"Untitled", "Streaming"},
+		currentProfile:     "Untitled",
+		sceneCollections:   []string{"Untitled"},
+		currentCollection:  "Untitled",
+	}
+}
+
+func (st *state) scene(name string) *Scene {
+	for i := range st.scenes {
+		if st.scenes[i].Name == name {
+			return &st.scenes[i]
+		}
+	}
+	return nil
+}
+
+func (st *state) input(name string) *Input {
+	for i := range st.inputs {
+		if st.inputs[i].Name == name {
+			return &st.inputs[i]
+		}
+	}
+	return nil
+}
+
+func (st *state) transition(name string) *Transition {
+	for i := range st.transitions {
+		if st.transitions[i].Name == name {
+			return &st.transitions[i]
+		}
+	}
+	return nil
+}
+
+// SetScenes replaces the scenes, and makes the first one the current
+// scene. No event is emitted.
+func (s *Server) SetScenes(scenes ...Scene) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.scenes = make([]Scene, 0, len(scenes))
+	for _, scene := range scenes {
+		s.scenes = append(s.scenes, copyScene(scene))
+	}
+	s.currentScene = ""
+	s.previewScene = ""
+	if len(scenes) > 0 {
+		s.currentScene = scenes[0].Name
+	}
+}
+
+// AddInput adds an input, or replaces the one of the same name. No
+// event is emitted.
+func (s *Server) AddInput(in Input) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	in = copyInput(in)
+	if existing := s.input(in.Name); existing != nil {
+		*existing = in
+		return
+	}
+	s.inputs = append(s.inputs, in)
+}
+
+// Scenes returns a copy of the scenes.
+func (s *Server) Scenes() []Scene {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := make([]Scene, 0, len(s.scenes))
+	for _, scene := range s.scenes {
+		res = append(res, copyScene(scene))
+	}
+	return res
+}
+
+// CurrentScene returns the name of the program scene.
+func (s *Server) CurrentScene() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.currentScene
+}
+
+// PreviewScene returns the name of the preview scene, empty when
+// studio mode is disabled.
+func (s *Server) PreviewScene() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.previewScene
+}
+
+// StudioMode tells if studio mode is enabled.
+func (s *Server) StudioMode() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.studioMode
+}
+
+// Input returns the input named name.
+func (s *Server) Input(name string) (Input, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	in := s.input(name)
+	if in == nil {
+		return Input{}, false
+	}
+	return copyInput(*in), true
+}
+
+// CurrentTransition returns the name and the duration of the current
+// transition.
+func (s *Server) CurrentTransition() (string, time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.currentTransition, s.transitionDuration
+}
+
+// Streaming tells if the stream is started.
+func (s *Server) Streaming() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.streaming
+}
+
+// Recording tells if the recording is started, and if it is paused.
+func (s *Server) Recording() (recording, paused bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.recording, s.recordingPaused
+}
+
+// ReplayBuffer tells if the replay buffer is started.
+func (s *Server) ReplayBuffer() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.replayBuffer
+}
+
+// CurrentProfile returns the name of the current profile.
+func (s *Server) CurrentProfile() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.currentProfile
+}
+
+// CurrentSceneCollection returns the name of the current scene
+// collection.
+func (s *Server) CurrentSceneCollection() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.currentCollection
+}
+
+func copyScene(scene Scene) Scene {
+	if scene.Items != nil {
+		items := make([]SceneItem, len(scene.Items))
+		copy(items, scene.Items)
+		scene.Items = items
+	}
+	return scene
+}
+
+func copySettings(settings map[string]interface{}) map[string]interface{} {
+	if settings == nil {
+		return nil
+	}
+	res := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		res[k] = v
+	}
+	return res
+}
+
+func copyInput(in Input) Input {
+	in.Settings = copySettings(in.Settings)
+	if in.Filters != nil {
+		filters := make([]Filter, 0, len(in.Filters))
+		for _, f := range in.Filters {
+			f.Settings = copySettings(f.Settings)
+			filters = append(filters, f)
+		}
+		in.Filters = filters
+	}
+	if in.Media != nil {
+		media := *in.Media
+		in.Media = &media
+	}
+	return in
+}